@@ -8,10 +8,14 @@ import (
 	"github.com/kndrad/piccrack/config"
 	apiv1 "github.com/kndrad/piccrack/internal/api/v1"
 	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/ocr/tesseract"
 	"github.com/kndrad/piccrack/pkg/retry"
+	"github.com/kndrad/piccrack/pkg/textproc"
 	"github.com/spf13/cobra"
 
 	"github.com/kndrad/piccrack/cmd/logger"
+	"github.com/kndrad/piccrack/cmd/ocrcache"
 )
 
 var startCmd = &cobra.Command{
@@ -46,26 +50,66 @@ var startCmd = &cobra.Command{
 			return fmt.Errorf("database ping: %w", err)
 		}
 
-		db, err := database.Connect(ctx, pool)
+		// Requests run queries and transactions concurrently, each on a
+		// connection of the pool
+		q := database.New(pool)
+		synonyms, err := textproc.LoadSynonyms(cfg.Words.Synonyms)
 		if err != nil {
-			l.Error("Connecting to database", "err", err.Error())
+			l.Error("Loading synonyms", "err", err.Error())
 
-			return fmt.Errorf("database connection: %w", err)
+			return fmt.Errorf("load synonyms: %w", err)
+		}
+		taxonomy, err := textproc.LoadTaxonomy(cfg.Words.Taxonomy)
+		if err != nil {
+			l.Error("Loading taxonomy", "err", err.Error())
+
+			return fmt.Errorf("load taxonomy: %w", err)
+		}
+		svc := apiv1.NewServiceWithTaxonomy(q, l, synonyms, taxonomy)
+
+		opts := tesseract.DefaultOptions.Merge(cfg.OCR.Options())
+		if err := opts.Validate(); err != nil {
+			l.Error("Validating ocr options", "err", err.Error())
+
+			return fmt.Errorf("ocr options: %w", err)
 		}
-		defer db.Close(ctx)
 
-		q := database.New(db)
-		svc := apiv1.NewService(q, l)
+		// Tesseract clients are reused between requests
+		engines := ocr.NewPool(tesseract.NewEngineFunc(opts), ocr.PoolOptions{
+			MaxSize:     cfg.OCR.Pool.MaxSize,
+			IdleTimeout: cfg.OCR.Pool.IdleTimeout,
+		})
+		defer engines.Close()
+
+		var engine ocr.Engine = engines
+		noCache, err := cmd.Flags().GetBool("no-cache")
+		if err != nil {
+			return fmt.Errorf("get bool: %w", err)
+		}
+		if !noCache {
+			// Duplicate uploads are answered from cache
+			cache, release, err := ocrcache.Open(ctx, cfg, pool)
+			if err != nil {
+				l.Error("Opening ocr cache", "err", err.Error())
+
+				return fmt.Errorf("ocr cache: %w", err)
+			}
+			defer release()
+			if cache != nil {
+				engine = ocr.NewCachedEngine(engines, cache)
+			}
+		}
 
 		// Create server instance
-		srv, err := apiv1.NewServer(cfg.HTTP, svc, l)
+		srv, err := apiv1.NewServer(cfg.HTTP, svc, engine, l)
 		if err != nil {
 			l.Error("Failed to init new http server", "err", err)
 
 			return fmt.Errorf("new http server err: %w", err)
 		}
 
-		if err := srv.Start(ctx); err != nil {
+		// Requests start from configured options, e.g. preprocessing steps
+		if err := srv.Start(ocr.ContextWithOptions(ctx, opts)); err != nil {
 			l.Error("Failed to listen and serve", "err", err)
 
 			return fmt.Errorf("listen and serve err: %w", err)
@@ -78,4 +122,6 @@ var startCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(startCmd)
+
+	startCmd.Flags().Bool("no-cache", false, "recognize every uploaded image instead of using ocr cache")
 }
@@ -0,0 +1,68 @@
+package ocrcache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kndrad/piccrack/config"
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/retry"
+)
+
+// Dir returns directory of the disk cache, dir in user cache directory
+// if it's not configured.
+func Dir(cfg config.OCRCacheConfig) (string, error) {
+	if cfg.Dir != "" {
+		return cfg.Dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("user cache dir: %w", err)
+	}
+
+	return filepath.Join(dir, "piccrack", "ocr"), nil
+}
+
+// Open returns the cache configured in cfg and a func releasing it, the
+// cache is nil if it's disabled. Postgres backend keeps entries using pool,
+// a new one is opened from database config if pool is nil.
+func Open(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool) (ocr.Cache, func(), error) {
+	release := func() {}
+
+	switch cfg.OCR.Cache.Backend {
+	case config.OCRCacheNone:
+		return nil, release, nil
+	case config.OCRCacheDisk, "":
+		dir, err := Dir(cfg.OCR.Cache)
+		if err != nil {
+			return nil, release, err
+		}
+		c, err := ocr.NewDiskCache(dir, cfg.OCR.Cache.Options())
+		if err != nil {
+			return nil, release, fmt.Errorf("new disk cache: %w", err)
+		}
+
+		return c, release, nil
+	case config.OCRCachePostgres:
+		if pool == nil {
+			p, err := database.Pool(ctx, cfg.Database)
+			if err != nil {
+				return nil, release, fmt.Errorf("database pool: %w", err)
+			}
+			if err := retry.Ping(ctx, p, retry.MaxRetries); err != nil {
+				p.Close()
+
+				return nil, release, fmt.Errorf("database ping: %w", err)
+			}
+			pool, release = p, p.Close
+		}
+
+		return database.NewOCRCache(database.New(pool), cfg.OCR.Cache.Options()), release, nil
+	default:
+		return nil, release, fmt.Errorf("unknown ocr cache backend %q", cfg.OCR.Cache.Backend)
+	}
+}
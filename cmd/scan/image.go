@@ -2,12 +2,22 @@ package scan
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/kndrad/piccrack/cmd/logger"
+	"github.com/kndrad/piccrack/cmd/ocrcache"
+	"github.com/kndrad/piccrack/config"
+	"github.com/kndrad/piccrack/pkg/manifest"
 	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/ocr/tesseract"
 	"github.com/kndrad/piccrack/pkg/picphrase"
+	"github.com/kndrad/piccrack/pkg/pproc"
 	"github.com/spf13/cobra"
 )
 
@@ -22,46 +32,324 @@ var phrasesCmd = &cobra.Command{
 			return fmt.Errorf("get string: %w", err)
 		}
 
+		minConfidence, err := cmd.Flags().GetFloat64("min-confidence")
+		if err != nil {
+			return fmt.Errorf("get float64: %w", err)
+		}
+
+		mode, err := cmd.Flags().GetString("mode")
+		if err != nil {
+			return fmt.Errorf("get string: %w", err)
+		}
+		phraseMode, err := picphrase.ParseMode(mode)
+		if err != nil {
+			return fmt.Errorf("parse mode: %w", err)
+		}
+
 		info, err := os.Stat(path)
 		if err != nil {
 			return fmt.Errorf("stat: %w", err)
 		}
 
-		tc := ocr.NewClient()
-		defer tc.Close()
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("config load: %w", err)
+		}
+		opts, err := ocrOptions(cmd, cfg)
+		if err != nil {
+			return fmt.Errorf("ocr options: %w", err)
+		}
+		e, err := tesseract.NewWithOptions(opts)
+		if err != nil {
+			return fmt.Errorf("new engine: %w", err)
+		}
+		defer e.Close()
 
-		ctx := context.Background()
+		ctx := ocr.ContextWithOptions(context.Background(), opts)
 
-		phrases := make([]*picphrase.Phrase, 0)
+		cache, release, err := openCache(ctx, cmd, cfg)
+		if err != nil {
+			return fmt.Errorf("ocr cache: %w", err)
+		}
+		defer release()
 
-		switch info.IsDir() {
-		case false:
-			values, err := picphrase.ScanAt(ctx, path)
-			if err != nil {
-				return fmt.Errorf("scan image: %w", err)
+		walk, err := walkOptions(cmd)
+		if err != nil {
+			return fmt.Errorf("walk options: %w", err)
+		}
+
+		scanner := &picphrase.Scanner{Engine: withCache(e, cache), MinConfidence: minConfidence, Mode: phraseMode, Walk: walk}
+		if scanner.Workers, err = cmd.Flags().GetInt("workers"); err != nil {
+			return fmt.Errorf("get int: %w", err)
+		}
+		if scanner.Workers != 1 {
+			// Every worker recognizes images of directories with its own engine
+			scanner.NewEngine = func() (ocr.Engine, error) {
+				e, err := tesseract.NewWithOptions(opts)
+				if err != nil {
+					return nil, err
+				}
+
+				return withCache(e, cache), nil
 			}
-			for v := range values {
-				phrases = append(phrases, v)
+		}
+
+		files := make(map[manifest.Status][]string)
+		phrases := scanner.PhrasesAt(ctx, path)
+		if info.IsDir() || (walk.Archives && pproc.IsArchive(path)) {
+			if scanner.Manifest, err = openManifest(cmd, "manifest", path); err != nil {
+				return fmt.Errorf("open manifest: %w", err)
 			}
-		case true:
-			values, err := picphrase.ScanDir(ctx, path)
-			if err != nil {
-				return fmt.Errorf("scan images: %w", err)
+			if scanner.Full, err = cmd.Flags().GetBool("full"); err != nil {
+				return fmt.Errorf("get bool: %w", err)
+			}
+			scanner.OnFile = func(path string, status manifest.Status) {
+				files[status] = append(files[status], path)
+			}
+			phrases = scanner.PhrasesInDir(ctx, path)
+		}
+
+		total, failed := 0, 0
+		categories := make(map[picphrase.Category]int)
+		for phrase, err := range phrases {
+			var fileErr *picphrase.FileError
+			if errors.As(err, &fileErr) {
+				l.Error("Failed to scan file", "path", fileErr.Path, "err", fileErr.Err)
+				failed++
+
+				continue
 			}
-			for v := range values {
-				phrases = append(phrases, v)
+			if err != nil {
+				return fmt.Errorf("scan: %w", err)
 			}
+			total++
+			categories[phrase.Category()]++
 		}
 
-		l.Info("Scanned sentences", "total", len(phrases))
+		if scanner.Manifest != nil {
+			for _, path := range files[manifest.StatusAdded] {
+				l.Debug("Added file", "path", path)
+			}
+			for _, path := range files[manifest.StatusChanged] {
+				l.Debug("Changed file", "path", path)
+			}
+			unchanged := "skipped"
+			if scanner.Full {
+				unchanged = "rescanned"
+			}
+			l.Info("Scanned files",
+				"added", len(files[manifest.StatusAdded]),
+				"changed", len(files[manifest.StatusChanged]),
+				unchanged, len(files[manifest.StatusUnchanged]),
+				"failed", failed,
+				"manifest", scanner.Manifest.Path(),
+			)
+		}
+		l.Info("Scanned sentences", "total", total)
+		for _, c := range picphrase.Categories() {
+			l.Info("Classified sentences", "category", c, "total", categories[c])
+		}
 		l.Info("Program completed successfully")
 
 		return nil
 	},
 }
 
+// openManifest opens manifest of dir at path given by flag, or one in user
+// cache dir named after the flag and dir.
+func openManifest(cmd *cobra.Command, flag, dir string) (*manifest.File, error) {
+	path, err := cmd.Flags().GetString(flag)
+	if err != nil {
+		return nil, fmt.Errorf("get string: %w", err)
+	}
+	if path == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("user cache dir: %w", err)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("abs: %w", err)
+		}
+		sum := sha256.Sum256([]byte(abs))
+		path = filepath.Join(cache, "piccrack", flag, hex.EncodeToString(sum[:8])+".json")
+	}
+
+	return manifest.Open(path)
+}
+
+// loadConfig returns nil config if config file doesn't exist.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return cfg, err
+}
+
+// ocrOptions returns ocr options from config overridden by flags which were
+// set explicitly. Defaults are used if there's no config.
+func ocrOptions(cmd *cobra.Command, cfg *config.Config) (ocr.Options, error) {
+	var err error
+
+	opts := tesseract.DefaultOptions
+	if cfg != nil {
+		opts = opts.Merge(cfg.OCR.Options())
+	}
+
+	var override ocr.Options
+	flags := cmd.Flags()
+	if flags.Changed("lang") {
+		if override.Languages, err = flags.GetStringSlice("lang"); err != nil {
+			return opts, fmt.Errorf("get string slice: %w", err)
+		}
+	}
+	if flags.Changed("psm") {
+		psm, err := flags.GetInt("psm")
+		if err != nil {
+			return opts, fmt.Errorf("get int: %w", err)
+		}
+		override.PSM = ocr.PSM(psm)
+	}
+	if override.Whitelist, err = flags.GetString("whitelist"); err != nil {
+		return opts, fmt.Errorf("get string: %w", err)
+	}
+	if override.Blacklist, err = flags.GetString("blacklist"); err != nil {
+		return opts, fmt.Errorf("get string: %w", err)
+	}
+	if override.DPI, err = flags.GetInt("dpi"); err != nil {
+		return opts, fmt.Errorf("get int: %w", err)
+	}
+	if override.Variables, err = flags.GetStringToString("ocr-var"); err != nil {
+		return opts, fmt.Errorf("get string to string: %w", err)
+	}
+	if override.Preprocess, err = flags.GetStringSlice("preprocess"); err != nil {
+		return opts, fmt.Errorf("get string slice: %w", err)
+	}
+	if override.DebugDir, err = flags.GetString("debug-dir"); err != nil {
+		return opts, fmt.Errorf("get string: %w", err)
+	}
+	regions, err := flags.GetStringArray("region")
+	if err != nil {
+		return opts, fmt.Errorf("get string array: %w", err)
+	}
+	for _, v := range regions {
+		r, err := ocr.ParseRegion(v)
+		if err != nil {
+			return opts, fmt.Errorf("parse region: %w", err)
+		}
+		override.Regions = append(override.Regions, r)
+	}
+
+	opts = opts.Merge(override)
+	if err := opts.Validate(); err != nil {
+		return opts, fmt.Errorf("validate: %w", err)
+	}
+
+	return opts, nil
+}
+
+// cachedEngine wraps e with configured ocr cache, see openCache.
+func cachedEngine(ctx context.Context, cmd *cobra.Command, cfg *config.Config, e ocr.Engine) (ocr.Engine, func(), error) {
+	cache, release, err := openCache(ctx, cmd, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return withCache(e, cache), release, nil
+}
+
+// openCache returns configured ocr cache, nil if caching is disabled by a
+// flag. Disk cache with default options is used if there's no config.
+func openCache(ctx context.Context, cmd *cobra.Command, cfg *config.Config) (ocr.Cache, func(), error) {
+	noCache, err := cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		return nil, nil, fmt.Errorf("get bool: %w", err)
+	}
+	if noCache {
+		return nil, func() {}, nil
+	}
+	if cfg == nil {
+		cfg = &config.Config{OCR: config.OCRConfig{Cache: config.DefaultOCRCache}}
+	}
+
+	return ocrcache.Open(ctx, cfg, nil)
+}
+
+// withCache wraps e with cache unless it's nil.
+func withCache(e ocr.Engine, cache ocr.Cache) ocr.Engine {
+	if cache == nil {
+		return e
+	}
+
+	return ocr.NewCachedEngine(e, cache)
+}
+
+// walkOptions returns options selecting files of scanned directories.
+func walkOptions(cmd *cobra.Command) (pproc.WalkOptions, error) {
+	var (
+		opts pproc.WalkOptions
+		err  error
+	)
+	flags := cmd.Flags()
+	if opts.Include, err = flags.GetStringSlice("include"); err != nil {
+		return opts, fmt.Errorf("get string slice: %w", err)
+	}
+	if opts.Exclude, err = flags.GetStringSlice("exclude"); err != nil {
+		return opts, fmt.Errorf("get string slice: %w", err)
+	}
+	if opts.MaxSize, err = flags.GetInt64("max-size"); err != nil {
+		return opts, fmt.Errorf("get int64: %w", err)
+	}
+	if opts.MaxDepth, err = flags.GetInt("max-depth"); err != nil {
+		return opts, fmt.Errorf("get int: %w", err)
+	}
+	hidden, err := flags.GetBool("hidden")
+	if err != nil {
+		return opts, fmt.Errorf("get bool: %w", err)
+	}
+	opts.SkipHidden = !hidden
+	if opts.Archives, err = flags.GetBool("archives"); err != nil {
+		return opts, fmt.Errorf("get bool: %w", err)
+	}
+
+	return opts, opts.Validate()
+}
+
+// addWalkFlags registers flags selecting files of scanned directories.
+func addWalkFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("include", nil, "only scan files matching glob patterns, e.g. *.png,offers/*.pdf")
+	cmd.Flags().StringSlice("exclude", nil, "skip files and directories matching glob patterns, e.g. *.mp4,archive")
+	cmd.Flags().Int64("max-size", 50<<20, "skip files bigger than this many bytes, 0 means no limit")
+	cmd.Flags().Int("max-depth", 0, "skip files nested deeper than this many directories, 0 means no limit")
+	cmd.Flags().Bool("hidden", false, "scan hidden files and directories too")
+	cmd.Flags().Bool("archives", true, "scan files of zip, tar and tar.gz archives too")
+}
+
+// addOCRFlags registers flags overriding configured ocr options.
+func addOCRFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("lang", nil, "tesseract languages, e.g. eng,pol")
+	cmd.Flags().Int("psm", 0, "tesseract page segmentation mode (1-13)")
+	cmd.Flags().String("whitelist", "", "only recognize these characters")
+	cmd.Flags().String("blacklist", "", "never recognize these characters")
+	cmd.Flags().Int("dpi", 0, "image resolution hint")
+	cmd.Flags().StringToString("ocr-var", nil, "tesseract variables, e.g. preserve_interword_spaces=1")
+	cmd.Flags().StringSlice("preprocess", nil, "image preprocessing steps, e.g. grayscale,invert,upscale,otsu")
+	cmd.Flags().String("debug-dir", "", "dump intermediate preprocessed images into directory")
+	cmd.Flags().StringArray("region", nil, "recognize only named crop rectangle name=x,y,width,height of images, in pixels or percent, e.g. description=0,20%,100%,60%")
+	cmd.Flags().Bool("no-cache", false, "recognize every image again instead of using ocr cache")
+}
+
 func init() {
 	rootCmd.AddCommand(phrasesCmd)
+	addOCRFlags(phrasesCmd)
+	addWalkFlags(phrasesCmd)
 
-	phrasesCmd.Flags().String("image", "", "image to image")
+	phrasesCmd.Flags().String("image", "", "image, pdf, html, markdown or text file or a directory or archive of them to scan")
+	phrasesCmd.Flags().Float64("min-confidence", 0, "drop words recognized with lower confidence (0-100)")
+	phrasesCmd.Flags().String("manifest", "", "file recording scanned files of directories, defaults to one in user cache dir")
+	phrasesCmd.Flags().Bool("full", false, "scan every file of a directory, including ones which didn't change since the last scan")
+	phrasesCmd.Flags().Int("workers", 1, "recognize this many images of a directory concurrently, 0 means number of CPUs")
+	phrasesCmd.Flags().String("mode", string(picphrase.ModeLines), "build phrases of lines or of sentences rejoined across wrapped lines (lines, sentences)")
 }
@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const configPath = "config/development.yaml"
+
 var rootCmd = &cobra.Command{
 	Use: "scan",
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,340 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/kndrad/piccrack/cmd/logger"
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/dirwatch"
+	"github.com/kndrad/piccrack/pkg/manifest"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/ocr/tesseract"
+	"github.com/kndrad/piccrack/pkg/picphrase"
+	"github.com/kndrad/piccrack/pkg/pproc"
+	"github.com/kndrad/piccrack/pkg/retry"
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:     "watch",
+	Short:   "Scan images added to a directory into the database.",
+	Example: "piccrack scan watch [DIR]",
+	Args:    cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l := logger.New(true)
+
+		dir, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("abs: %w", err)
+		}
+
+		minConfidence, err := cmd.Flags().GetFloat64("min-confidence")
+		if err != nil {
+			return fmt.Errorf("get float64: %w", err)
+		}
+		mode, err := cmd.Flags().GetString("mode")
+		if err != nil {
+			return fmt.Errorf("get string: %w", err)
+		}
+		phraseMode, err := picphrase.ParseMode(mode)
+		if err != nil {
+			return fmt.Errorf("parse mode: %w", err)
+		}
+		debounce, err := cmd.Flags().GetDuration("debounce")
+		if err != nil {
+			return fmt.Errorf("get duration: %w", err)
+		}
+		walk, err := walkOptions(cmd)
+		if err != nil {
+			return fmt.Errorf("walk options: %w", err)
+		}
+		walk.Sniff = ocr.IsScannable
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("config load: %w", err)
+		}
+		if cfg == nil {
+			return fmt.Errorf("config %s is required to store batches", configPath)
+		}
+
+		pool, err := database.Pool(ctx, cfg.Database)
+		if err != nil {
+			l.Error("Loading database pool", "err", err.Error())
+
+			return fmt.Errorf("database pool: %w", err)
+		}
+		defer pool.Close()
+
+		if err := retry.Ping(ctx, pool, retry.MaxRetries); err != nil {
+			l.Error("Pinging database", "err", err.Error())
+
+			return fmt.Errorf("database ping: %w", err)
+		}
+
+		opts, err := ocrOptions(cmd, cfg)
+		if err != nil {
+			return fmt.Errorf("ocr options: %w", err)
+		}
+		e, err := tesseract.NewWithOptions(opts)
+		if err != nil {
+			return fmt.Errorf("new engine: %w", err)
+		}
+		defer e.Close()
+
+		ctx = ocr.ContextWithOptions(ctx, opts)
+
+		engine, release, err := cachedEngine(ctx, cmd, cfg, e)
+		if err != nil {
+			return fmt.Errorf("ocr cache: %w", err)
+		}
+		defer release()
+
+		ledger, err := openManifest(cmd, "ledger", dir)
+		if err != nil {
+			return fmt.Errorf("open ledger: %w", err)
+		}
+
+		synonyms, err := textproc.LoadSynonyms(cfg.Words.Synonyms)
+		if err != nil {
+			return fmt.Errorf("load synonyms: %w", err)
+		}
+		taxonomy, err := textproc.LoadTaxonomy(cfg.Words.Taxonomy)
+		if err != nil {
+			return fmt.Errorf("load taxonomy: %w", err)
+		}
+
+		w := &watcher{
+			dir:     dir,
+			walk:    walk,
+			q:       database.New(pool),
+			ledger:  ledger,
+			batches: database.NewBatches(synonyms, taxonomy),
+			scanner: &picphrase.Scanner{Engine: engine, MinConfidence: minConfidence, Mode: phraseMode},
+			l:       l,
+		}
+
+		// Watching starts first, so that files added during the initial
+		// scan aren't missed
+		events, err := dirwatch.Watch(ctx, dir, walk, debounce)
+		if err != nil {
+			return fmt.Errorf("watch: %w", err)
+		}
+		l.Info("Watching directory", "dir", dir, "ledger", ledger.Path())
+
+		if err := w.processAll(ctx, dir); err != nil {
+			return fmt.Errorf("process existing files: %w", err)
+		}
+
+		for event := range events {
+			if event.Err != nil {
+				l.Error("Watching directory", "path", event.Path, "err", event.Err.Error())
+
+				continue
+			}
+			if walk.Archives && pproc.IsArchive(event.Path) {
+				if err := w.processAll(ctx, event.Path); err != nil {
+					l.Error("Processing archive", "path", event.Path, "err", err.Error())
+				}
+
+				continue
+			}
+			content, err := walk.ReadFile(event.Path)
+			if err != nil {
+				l.Error("Reading file", "path", event.Path, "err", err.Error())
+
+				continue
+			}
+			if content == nil {
+				continue
+			}
+			w.process(ctx, event.Path, content)
+		}
+		l.Info("Program completed successfully")
+
+		return nil
+	},
+}
+
+// watcher stores words, n-grams and phrases of images of a directory as batches,
+// recording processed files in a ledger.
+type watcher struct {
+	dir     string
+	walk    pproc.WalkOptions
+	q       database.TxQuerier
+	ledger  *manifest.File
+	batches *database.Batches
+	scanner *picphrase.Scanner
+	l       *slog.Logger
+}
+
+// processAll processes files of root, the directory or an archive in it,
+// which weren't processed yet, e.g. ones added while the command wasn't
+// running.
+func (w *watcher) processAll(ctx context.Context, root string) error {
+	entries, err := pproc.WalkWithOptions(ctx, root, w.walk)
+	if err != nil {
+		return fmt.Errorf("walk: %w", err)
+	}
+	for entry := range entries {
+		if err := entry.Err(); err != nil {
+			w.l.Error("Reading file", "path", entry.Path(), "err", err.Error())
+
+			continue
+		}
+		w.process(ctx, entry.Path(), entry.Content())
+	}
+
+	return ctx.Err()
+}
+
+// process stores words and phrases of image at path unless the ledger has
+// it with the same content. Failures are logged, files which failed aren't
+// recorded so they're processed again on the next run.
+func (w *watcher) process(ctx context.Context, path string, content []byte) {
+	rel, err := filepath.Rel(w.dir, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+
+	hash := manifest.Hash(content)
+	status := w.ledger.Status(rel, hash)
+	if status == manifest.StatusUnchanged {
+		w.l.Debug("Skipping processed file", "path", rel)
+
+		return
+	}
+
+	// Files of archives have modification time of the archive
+	stat := path
+	if archive, _, ok := pproc.SplitArchivePath(path); ok {
+		stat = archive
+	}
+	info, err := os.Stat(stat)
+	if err != nil {
+		w.l.Error("Stat file", "path", rel, "err", err.Error())
+
+		return
+	}
+
+	// Batch names are unique, every content of a file gets its own one.
+	// It's stored already if the ledger lost its record, e.g. if it was
+	// removed, and it's recorded again without id of its batch.
+	name := fmt.Sprintf("%s@%s", rel, hash[:12])
+
+	batchID, err := w.store(ctx, name, path, content)
+	if errors.Is(err, database.ErrBatchExists) {
+		w.l.Info("Skipping stored file", "path", rel, "batch", name)
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			w.l.Error("Processing file", "path", rel, "err", err.Error())
+		}
+
+		return
+	}
+
+	if err := w.ledger.Put(manifest.Record{
+		Path:        rel,
+		Size:        int64(len(content)),
+		ModTime:     info.ModTime(),
+		Hash:        hash,
+		BatchID:     batchID,
+		ProcessedAt: time.Now(),
+	}); err != nil {
+		w.l.Error("Recording processed file", "path", rel, "err", err.Error())
+	}
+}
+
+// store recognizes image content once and stores its words, with their
+// n-grams, and phrases as batches called name, in one transaction. It
+// returns id of the words batch, zero if the image has no text.
+func (w *watcher) store(ctx context.Context, name, path string, content []byte) (int64, error) {
+	res, err := ocr.ScanContent(ctx, w.scanner.Engine, content)
+	if err != nil {
+		return 0, fmt.Errorf("scan content: %w", err)
+	}
+	res = res.WithMinConfidence(w.scanner.MinConfidence)
+
+	var (
+		words []string
+		pages []int32
+	)
+	for page, word := range res.PageWords() {
+		words = append(words, word)
+		pages = append(pages, int32(page))
+	}
+	if len(words) == 0 {
+		w.l.Info("No text found", "path", name)
+
+		return 0, nil
+	}
+
+	phrases := database.CreatePhrasesBatchParams{Name: name}
+	src := &picphrase.PagesSource{Recognized: res.Pages(), Path: path}
+	for ph, err := range w.scanner.Phrases(ctx, src) {
+		if err != nil {
+			return 0, fmt.Errorf("phrases: %w", err)
+		}
+		phrases.Column2 = append(phrases.Column2, ph.String())
+		phrases.Column3 = append(phrases.Column3, int32(ph.Page()))
+		phrases.Column4 = append(phrases.Column4, string(ph.Category()))
+		phrases.Column5 = append(phrases.Column5, float32(ph.Confidence()))
+	}
+
+	var (
+		row    database.CreateWordsBatchRow
+		ngrams int
+	)
+	err = w.q.InTx(ctx, func(q database.Querier) error {
+		if row, err = w.batches.CreateWordsBatch(ctx, q, name, words, pages); err != nil {
+			return err
+		}
+		if ngrams, err = w.batches.CreateNgrams(ctx, q, row.BatchID.Int64, res.Text()); err != nil {
+			return err
+		}
+		if len(phrases.Column2) > 0 {
+			if _, err := w.batches.CreatePhrasesBatch(ctx, q, phrases); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("in tx: %w", err)
+	}
+
+	w.l.Info("Stored batch",
+		slog.String("name", name),
+		slog.Int("words", len(words)),
+		slog.Int("ngrams", ngrams),
+		slog.Int("phrases", len(phrases.Column2)),
+	)
+
+	return row.BatchID.Int64, nil
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	addOCRFlags(watchCmd)
+	addWalkFlags(watchCmd)
+
+	watchCmd.Flags().Float64("min-confidence", 0, "drop words recognized with lower confidence (0-100)")
+	watchCmd.Flags().String("mode", string(picphrase.ModeLines), "build phrases of lines or of sentences rejoined across wrapped lines (lines, sentences)")
+	watchCmd.Flags().Duration("debounce", dirwatch.DefaultDebounce, "wait until a file isn't written for this long before scanning it")
+	watchCmd.Flags().String("ledger", "", "file recording processed images, defaults to one in user cache dir")
+}
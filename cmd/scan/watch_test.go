@@ -0,0 +1,178 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/manifest"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/picphrase"
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeEngine recognizes images decoded whole as the same text, so it
+// fails on truncated images.
+type decodeEngine struct {
+	text string
+}
+
+func (e decodeEngine) Recognize(ctx context.Context, content []byte) (*ocr.Recognition, error) {
+	if _, _, err := image.Decode(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return &ocr.Recognition{Text: e.text}, nil
+}
+
+func (decodeEngine) Close() error { return nil }
+
+// txQuerier keeps names of batches stored by committed transactions.
+type txQuerier struct {
+	database.Querier
+
+	committed []string
+	pending   []string
+	ngrams    int
+
+	// phrasesErr fails storing of phrases batches
+	phrasesErr error
+}
+
+func (q *txQuerier) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	q.pending = nil
+	if err := fn(q); err != nil {
+		return err
+	}
+	q.committed = append(q.committed, q.pending...)
+
+	return nil
+}
+
+func (q *txQuerier) CreateWordsBatch(ctx context.Context, arg database.CreateWordsBatchParams) (database.CreateWordsBatchRow, error) {
+	q.pending = append(q.pending, "words:"+arg.Name)
+
+	return database.CreateWordsBatchRow{BatchID: pgtype.Int8{Int64: 1, Valid: true}}, nil
+}
+
+func (q *txQuerier) CreateTerms(ctx context.Context, arg database.CreateTermsParams) error {
+	return nil
+}
+
+func (q *txQuerier) CreateNgrams(ctx context.Context, arg database.CreateNgramsParams) error {
+	q.ngrams = len(arg.Ngrams)
+
+	return nil
+}
+
+func (q *txQuerier) CreatePhrasesBatch(ctx context.Context, arg database.CreatePhrasesBatchParams) (database.CreatePhrasesBatchRow, error) {
+	if q.phrasesErr != nil {
+		return database.CreatePhrasesBatchRow{}, q.phrasesErr
+	}
+	q.pending = append(q.pending, "phrases:"+arg.Name)
+
+	return database.CreatePhrasesBatchRow{}, nil
+}
+
+// noisePNG returns a png image which doesn't compress, bigger than
+// 512KB for side of 1000.
+func noisePNG(t *testing.T, side int) []byte {
+	t.Helper()
+
+	r := rand.New(rand.NewPCG(1, 2))
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.IntN(256))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+func TestWatcherProcess(t *testing.T) {
+	t.Parallel()
+
+	content := noisePNG(t, 1000)
+	require.Greater(t, len(content), 512*1024)
+	// Batches are named after the file and its content
+	name := "offer.png@" + manifest.Hash(content)[:12]
+
+	testCases := []struct {
+		desc string
+
+		phrasesErr error
+
+		wantCommitted []string
+		wantRecorded  bool
+		wantBatchID   int64
+	}{
+		{
+			desc: "stores_batches_of_large_image_and_records_it",
+
+			wantCommitted: []string{"words:" + name, "phrases:" + name},
+			wantRecorded:  true,
+			wantBatchID:   1,
+		},
+		{
+			desc: "rolls_back_and_doesnt_record_failed_image",
+
+			phrasesErr: errors.New("create phrases batch: failed"),
+		},
+		{
+			desc: "records_image_stored_already",
+
+			phrasesErr:   &pgconn.PgError{Code: "23505", ConstraintName: "phrase_batches_name_unique"},
+			wantRecorded: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "offer.png")
+			require.NoError(t, os.WriteFile(path, content, 0o600))
+
+			ledger, err := manifest.Open(filepath.Join(t.TempDir(), "ledger.json"))
+			require.NoError(t, err)
+
+			q := &txQuerier{phrasesErr: tC.phrasesErr}
+			w := &watcher{
+				dir:     dir,
+				q:       q,
+				ledger:  ledger,
+				batches: database.NewBatches(textproc.DefaultSynonyms(), textproc.DefaultTaxonomy()),
+				scanner: &picphrase.Scanner{Engine: decodeEngine{text: "Golang developer\nRemote work"}},
+				l:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			w.process(context.Background(), path, content)
+
+			require.Equal(t, tC.wantCommitted, q.committed)
+			if !tC.wantRecorded {
+				require.Empty(t, ledger.Records())
+
+				return
+			}
+			require.Positive(t, q.ngrams)
+			records := ledger.Records()
+			require.Len(t, records, 1)
+			require.Equal(t, "offer.png", records[0].Path)
+			require.Equal(t, int64(len(content)), records[0].Size)
+			require.Equal(t, tC.wantBatchID, records[0].BatchID)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/kndrad/piccrack/config"
 	"github.com/kndrad/piccrack/internal/database"
 	"github.com/kndrad/piccrack/pkg/retry"
+	"github.com/kndrad/piccrack/pkg/textproc"
 	"github.com/spf13/cobra"
 )
 
@@ -55,8 +56,16 @@ var addCmd = &cobra.Command{
 
 		q := database.New(conn)
 
+		synonyms, err := textproc.LoadSynonyms(cfg.Words.Synonyms)
+		if err != nil {
+			return fmt.Errorf("load synonyms: %w", err)
+		}
+
 		value := args[0]
-		word, err := q.CreateWord(ctx, value)
+		word, err := q.CreateWord(ctx, database.CreateWordParams{
+			Value:     value,
+			Canonical: synonyms.Canonical(value),
+		})
 		if err != nil {
 			l.Error("Inserting word failed", "err", err.Error())
 
@@ -65,6 +74,7 @@ var addCmd = &cobra.Command{
 		l.Info("Inserted word",
 			slog.Int64("word", word.ID),
 			slog.String("value", word.Value),
+			slog.String("canonical", word.Canonical),
 			slog.Time("created_at_time", word.CreatedAt.Time),
 		)
 
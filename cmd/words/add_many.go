@@ -1,8 +1,6 @@
 package words
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,12 +8,14 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/kndrad/piccrack/cmd/logger"
+	"github.com/kndrad/piccrack/cmd/ocrcache"
 	"github.com/kndrad/piccrack/config"
 	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/ocr/tesseract"
 	"github.com/kndrad/piccrack/pkg/retry"
 	"github.com/kndrad/piccrack/pkg/textproc"
 	"github.com/spf13/cobra"
@@ -24,7 +24,7 @@ import (
 var addManyCmd = &cobra.Command{
 	Use:     "many",
 	Short:   "Adds many words to a database.",
-	Example: "piccrack words add many [FILE PATH <name>.txt | <name>.json]",
+	Example: "piccrack words add many [FILE PATH <name>.txt | <name>.json | <name>.pdf]",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		l := logger.New(Verbose)
 
@@ -87,18 +87,48 @@ var addManyCmd = &cobra.Command{
 
 				return fmt.Errorf("read file: %w", err)
 			}
-			scanner := bufio.NewScanner(bytes.NewReader(data))
-			scanner.Split(bufio.ScanWords)
-
-			for scanner.Scan() {
-				word := strings.Trim(scanner.Text(), " ")
+			for _, word := range textproc.Words(string(data)) {
 				analysis.IncWordCount(word)
 			}
+		case ".pdf":
+			// Pages without a text layer need ocr
+			opts := tesseract.DefaultOptions.Merge(cfg.OCR.Options())
+			e, err := tesseract.NewWithOptions(opts)
+			if err != nil {
+				l.Error("Failed to create ocr engine", "err", err.Error())
+
+				return fmt.Errorf("new engine: %w", err)
+			}
+			defer e.Close()
+
+			var engine ocr.Engine = e
+			noCache, err := cmd.Flags().GetBool("no-cache")
+			if err != nil {
+				return fmt.Errorf("get bool: %w", err)
+			}
+			if !noCache {
+				cache, release, err := ocrcache.Open(ctx, cfg, pool)
+				if err != nil {
+					l.Error("Failed to open ocr cache", "err", err.Error())
+
+					return fmt.Errorf("ocr cache: %w", err)
+				}
+				defer release()
+				if cache != nil {
+					engine = ocr.NewCachedEngine(e, cache)
+				}
+			}
 
-			if err := scanner.Err(); err != nil {
-				l.Error("Scanner returned an error", "err", err.Error())
+			res, err := ocr.ScanFile(ocr.ContextWithOptions(ctx, opts), engine, path)
+			if err != nil {
+				l.Error("Failed to scan pdf",
+					slog.String("path", path),
+				)
 
-				return fmt.Errorf("scanner err: %w", err)
+				return fmt.Errorf("scan file: %w", err)
+			}
+			for _, word := range res.PageWords() {
+				analysis.IncWordCount(word)
 			}
 		}
 		if Verbose {
@@ -106,9 +136,16 @@ var addManyCmd = &cobra.Command{
 		}
 
 		// Query db to insert each word
+		synonyms, err := textproc.LoadSynonyms(cfg.Words.Synonyms)
+		if err != nil {
+			return fmt.Errorf("load synonyms: %w", err)
+		}
 		q := database.New(conn)
 		for word := range analysis.WordFrequency {
-			row, err := q.CreateWord(ctx, word)
+			row, err := q.CreateWord(ctx, database.CreateWordParams{
+				Value:     word,
+				Canonical: synonyms.Canonical(word),
+			})
 			if err != nil {
 				l.Error("Failed to insert word",
 					slog.String("word", word),
@@ -130,6 +167,8 @@ var addManyCmd = &cobra.Command{
 
 func init() {
 	addCmd.AddCommand(addManyCmd)
+
+	addManyCmd.Flags().Bool("no-cache", false, "recognize pdf images again instead of using ocr cache")
 }
 
 func printWords(analysis *textproc.TextAnalysis) {
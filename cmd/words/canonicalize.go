@@ -0,0 +1,77 @@
+package words
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kndrad/piccrack/cmd/logger"
+	"github.com/kndrad/piccrack/config"
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/retry"
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/spf13/cobra"
+)
+
+// canonicalizeCmd recomputes canonical forms of stored words.
+var canonicalizeCmd = &cobra.Command{
+	Use:     "canonicalize",
+	Short:   "Update canonical forms of stored words with the synonyms dictionary.",
+	Example: "piccrack words canonicalize",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l := logger.New(Verbose)
+
+		cfg, err := config.Load("config/development.yaml")
+		if err != nil {
+			l.Error("Loading database config", "err", err.Error())
+
+			return fmt.Errorf("config load: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		pool, err := database.Pool(ctx, cfg.Database)
+		if err != nil {
+			l.Error("Loading database pool", "err", err.Error())
+
+			return fmt.Errorf("database pool: %w", err)
+		}
+		defer pool.Close()
+
+		if err := retry.Ping(ctx, pool, retry.MaxRetries); err != nil {
+			l.Error("Pinging database", "err", err.Error())
+
+			return fmt.Errorf("database ping: %w", err)
+		}
+
+		synonyms, err := textproc.LoadSynonyms(cfg.Words.Synonyms)
+		if err != nil {
+			return fmt.Errorf("load synonyms: %w", err)
+		}
+		taxonomy, err := textproc.LoadTaxonomy(cfg.Words.Taxonomy)
+		if err != nil {
+			return fmt.Errorf("load taxonomy: %w", err)
+		}
+
+		var updated int64
+		err = database.New(pool).InTx(ctx, func(q database.Querier) error {
+			updated, err = database.NewBatches(synonyms, taxonomy).Canonicalize(ctx, q)
+
+			return err
+		})
+		if err != nil {
+			l.Error("Updating canonical forms failed", "err", err.Error())
+
+			return fmt.Errorf("canonicalize: %w", err)
+		}
+		l.Info("Updated canonical forms", slog.Int64("words", updated))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(canonicalizeCmd)
+}
@@ -0,0 +1,115 @@
+package words
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kndrad/piccrack/cmd/logger"
+	"github.com/kndrad/piccrack/config"
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/retry"
+	"github.com/spf13/cobra"
+)
+
+var categoriesCmd = &cobra.Command{
+	Use:     "categories",
+	Short:   "Outputs counts of technology terms per category and per batch from a database.",
+	Example: "piccrack words categories --batch offer.png --terms messaging",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l := logger.New(Verbose)
+
+		batch, err := cmd.Flags().GetString("batch")
+		if err != nil {
+			return fmt.Errorf("get string: %w", err)
+		}
+		category, err := cmd.Flags().GetString("terms")
+		if err != nil {
+			return fmt.Errorf("get string: %w", err)
+		}
+		limit, err := cmd.Flags().GetInt32("limit")
+		if err != nil {
+			return fmt.Errorf("get int32: %w", err)
+		}
+
+		cfg, err := config.Load("config/development.yaml")
+		if err != nil {
+			l.Error("Loading database config", "err", err.Error())
+
+			return fmt.Errorf("config load: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		pool, err := database.Pool(ctx, cfg.Database)
+		if err != nil {
+			l.Error("Loading database pool", "err", err.Error())
+
+			return fmt.Errorf("database pool: %w", err)
+		}
+		defer pool.Close()
+
+		if err := retry.Ping(ctx, pool, retry.MaxRetries); err != nil {
+			l.Error("Pinging database", "err", err.Error())
+
+			return fmt.Errorf("database ping: %w", err)
+		}
+
+		q := database.New(pool)
+
+		// Totals of every batch aren't filtered by batch name
+		if batch == "" {
+			rows, err := q.CountTermsByCategory(ctx, database.CountTermsByCategoryParams{RowLimit: limit})
+			if err != nil {
+				l.Error("Failed to count terms by category", "err", err.Error())
+
+				return fmt.Errorf("count terms by category: %w", err)
+			}
+			for _, row := range rows {
+				fmt.Printf("CATEGORY: %s | COUNT: %d | TERMS: %d\n", row.Category, row.Total, row.Terms)
+			}
+		}
+
+		rows, err := q.CountTermsByBatch(ctx, database.CountTermsByBatchParams{
+			BatchName: pgtype.Text{String: batch, Valid: batch != ""},
+			RowLimit:  limit,
+		})
+		if err != nil {
+			l.Error("Failed to count terms by batch", "err", err.Error())
+
+			return fmt.Errorf("count terms by batch: %w", err)
+		}
+		for _, row := range rows {
+			fmt.Printf("BATCH: %s | CATEGORY: %s | COUNT: %d\n", row.BatchName, row.Category, row.Total)
+		}
+
+		if cmd.Flags().Changed("terms") {
+			terms, err := q.ListTermFrequencies(ctx, database.ListTermFrequenciesParams{
+				Category: pgtype.Text{String: category, Valid: category != ""},
+				RowLimit: limit,
+			})
+			if err != nil {
+				l.Error("Failed to list term frequencies", "err", err.Error())
+
+				return fmt.Errorf("list term frequencies: %w", err)
+			}
+			for _, row := range terms {
+				fmt.Printf("TERM: %s | CATEGORY: %s | COUNT: %d\n", row.Value, row.Category, row.Total)
+			}
+		}
+
+		l.Info("Program completed successfully.")
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(categoriesCmd)
+
+	categoriesCmd.Flags().String("batch", "", "count terms of the batch of this name only")
+	categoriesCmd.Flags().String("terms", "", "also list most frequent terms of this category, of every one if empty")
+	categoriesCmd.Flags().Int32("limit", 100, "max number of rows of each listing")
+}
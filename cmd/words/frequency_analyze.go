@@ -1,8 +1,6 @@
 package words
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -35,18 +33,9 @@ var frequencyAnalyzeCmd = &cobra.Command{
 
 			return fmt.Errorf("read file: %w", err)
 		}
-		scanner := bufio.NewScanner(bytes.NewReader(content))
-		scanner.Split(bufio.ScanWords)
-
-		words := make([]string, 0)
-		for scanner.Scan() {
-			word := scanner.Text()
-			words = append(words, word)
-		}
-		if err := scanner.Err(); err != nil {
-			l.Error("Scanning failed", "err", err)
-
-			return fmt.Errorf("scanner: %w", err)
+		words := textproc.Words(string(content))
+		if words == nil {
+			words = make([]string, 0)
 		}
 
 		analysis, err := textproc.AnalyzeWordsFrequency(words)
@@ -0,0 +1,120 @@
+package words
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kndrad/piccrack/cmd/logger"
+	"github.com/kndrad/piccrack/config"
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/retry"
+	"github.com/spf13/cobra"
+)
+
+var ngramsCmd = &cobra.Command{
+	Use:     "ngrams",
+	Short:   "Outputs most frequent bigrams and trigrams of batches, or scored n-grams of a batch, from a database.",
+	Example: "piccrack words ngrams --n 2 --batch offer.png",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l := logger.New(Verbose)
+
+		batch, err := cmd.Flags().GetString("batch")
+		if err != nil {
+			return fmt.Errorf("get string: %w", err)
+		}
+		n, err := cmd.Flags().GetInt32("n")
+		if err != nil {
+			return fmt.Errorf("get int32: %w", err)
+		}
+		if n != 0 && n != 2 && n != 3 {
+			return fmt.Errorf("n must be 2 or 3, got %d", n)
+		}
+		minTotal, err := cmd.Flags().GetInt32("min-total")
+		if err != nil {
+			return fmt.Errorf("get int32: %w", err)
+		}
+		limit, err := cmd.Flags().GetInt32("limit")
+		if err != nil {
+			return fmt.Errorf("get int32: %w", err)
+		}
+
+		cfg, err := config.Load("config/development.yaml")
+		if err != nil {
+			l.Error("Loading database config", "err", err.Error())
+
+			return fmt.Errorf("config load: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		pool, err := database.Pool(ctx, cfg.Database)
+		if err != nil {
+			l.Error("Loading database pool", "err", err.Error())
+
+			return fmt.Errorf("database pool: %w", err)
+		}
+		defer pool.Close()
+
+		if err := retry.Ping(ctx, pool, retry.MaxRetries); err != nil {
+			l.Error("Pinging database", "err", err.Error())
+
+			return fmt.Errorf("database ping: %w", err)
+		}
+
+		q := database.New(pool)
+		size := pgtype.Int4{Int32: n, Valid: n != 0}
+
+		// Scores are of a batch, totals of every batch are summed instead
+		if batch != "" {
+			rows, err := q.ListNgrams(ctx, database.ListNgramsParams{
+				BatchName: pgtype.Text{String: batch, Valid: true},
+				N:         size,
+				MinTotal:  minTotal,
+				RowLimit:  limit,
+			})
+			if err != nil {
+				l.Error("Failed to list ngrams", "err", err.Error())
+
+				return fmt.Errorf("list ngrams: %w", err)
+			}
+			for _, row := range rows {
+				fmt.Printf("NGRAM: %s | N: %d | COUNT: %d | PMI: %.2f | LLR: %.2f\n", row.Value, row.N, row.Total, row.Pmi, row.Llr)
+			}
+			l.Info("Program completed successfully.")
+
+			return nil
+		}
+
+		rows, err := q.ListNgramFrequencies(ctx, database.ListNgramFrequenciesParams{
+			N:        size,
+			RowLimit: limit,
+		})
+		if err != nil {
+			l.Error("Failed to list ngram frequencies", "err", err.Error())
+
+			return fmt.Errorf("list ngram frequencies: %w", err)
+		}
+		for _, row := range rows {
+			if row.Total < int64(minTotal) {
+				continue
+			}
+			fmt.Printf("NGRAM: %s | N: %d | COUNT: %d | BATCHES: %d\n", row.Value, row.N, row.Total, row.Batches)
+		}
+
+		l.Info("Program completed successfully.")
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ngramsCmd)
+
+	ngramsCmd.Flags().String("batch", "", "list scored n-grams of the batch of this name only")
+	ngramsCmd.Flags().Int32("n", 0, "list bigrams (2) or trigrams (3) only, both if zero")
+	ngramsCmd.Flags().Int32("min-total", 0, "skip n-grams occurring fewer times")
+	ngramsCmd.Flags().Int32("limit", 100, "max number of rows")
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
+	"github.com/kndrad/piccrack/pkg/ocr"
 	"github.com/spf13/viper"
 )
 
@@ -11,6 +13,8 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	HTTP     HTTPConfig     `mapstructure:"http"`
 	App      AppConfig      `mapstructure:"app"`
+	OCR      OCRConfig      `mapstructure:"ocr"`
+	Words    WordsConfig    `mapstructure:"words"`
 }
 
 func Load(path string) (*Config, error) {
@@ -19,18 +23,27 @@ func Load(path string) (*Config, error) {
 	v.SetConfigType("yaml")
 	v.SetConfigFile(filepath.Clean(path))
 
-	v.SetDefault("Database.Pool.MaxConns", 25)
-	v.SetDefault("Database.Pool.MinConns", 5)
-	v.SetDefault("Database.Pool.MaxConnLifetime", "1h")
-	v.SetDefault("Database.Pool.MaxConnIdleTime", "30m")
-	v.SetDefault("Database.Pool.ConnectTimeout", "10s")
-	v.SetDefault("Database.Pool.DialerKeepAlive", "5s")
+	// Keys are spelled like mapstructure tags, viper ignores case of keys
+	// but "MaxSize" and "max_size" are different ones
+	v.SetDefault("database.pool.max_conns", 25)
+	v.SetDefault("database.pool.min_conns", 5)
+	v.SetDefault("database.pool.max_conn_lifetime", "1h")
+	v.SetDefault("database.pool.max_conn_idle_time", "30m")
+	v.SetDefault("database.pool.connect_timeout", "10s")
+	v.SetDefault("database.pool.dialer_keep_alive", "5s")
 
-	v.SetDefault("HTTP.Host", "0.0.0.0")
-	v.SetDefault("HTTP.Port", "8080")
+	v.SetDefault("http.host", "0.0.0.0")
+	v.SetDefault("http.port", "8080")
 
-	v.SetDefault("App.Environment", "development")
-	v.SetDefault("App.LogLevel", "info")
+	v.SetDefault("ocr.pool.max_size", 4)
+	v.SetDefault("ocr.pool.idle_timeout", "5m")
+	v.SetDefault("ocr.languages", []string{"eng", "pol"})
+	v.SetDefault("ocr.cache.backend", DefaultOCRCache.Backend)
+	v.SetDefault("ocr.cache.ttl", DefaultOCRCache.TTL)
+	v.SetDefault("ocr.cache.max_size", DefaultOCRCache.MaxSize)
+
+	v.SetDefault("app.environment", "development")
+	v.SetDefault("app.log_level", "info")
 
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("reading config: %w", err)
@@ -71,3 +84,74 @@ type HTTPConfig struct {
 	Port       string `mapstructure:"port"`
 	TLSEnabled bool   `mapstructure:"tls_enabled"`
 }
+
+type OCRConfig struct {
+	Pool  ocrPoolConfig  `mapstructure:"pool"`
+	Cache OCRCacheConfig `mapstructure:"cache"`
+
+	Languages []string          `mapstructure:"languages"`
+	PSM       int               `mapstructure:"psm"`
+	Whitelist string            `mapstructure:"whitelist"`
+	Blacklist string            `mapstructure:"blacklist"`
+	DPI       int               `mapstructure:"dpi"`
+	Variables map[string]string `mapstructure:"variables"`
+
+	Preprocess []string `mapstructure:"preprocess"`
+	DebugDir   string   `mapstructure:"debug_dir"`
+}
+
+// Options returns recognition options configured for ocr engines.
+func (c OCRConfig) Options() ocr.Options {
+	return ocr.Options{
+		Languages:  c.Languages,
+		PSM:        ocr.PSM(c.PSM),
+		Whitelist:  c.Whitelist,
+		Blacklist:  c.Blacklist,
+		DPI:        c.DPI,
+		Variables:  c.Variables,
+		Preprocess: c.Preprocess,
+		DebugDir:   c.DebugDir,
+	}
+}
+
+type ocrPoolConfig struct {
+	MaxSize     int           `mapstructure:"max_size"`
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+}
+
+// OCR cache backends.
+const (
+	OCRCacheDisk     = "disk"
+	OCRCachePostgres = "postgres"
+	OCRCacheNone     = "none"
+)
+
+type OCRCacheConfig struct {
+	// Backend is one of OCRCacheDisk, OCRCachePostgres or OCRCacheNone.
+	Backend string `mapstructure:"backend"`
+	// Dir of the disk backend, user cache directory if empty.
+	Dir     string        `mapstructure:"dir"`
+	TTL     time.Duration `mapstructure:"ttl"`
+	MaxSize int64         `mapstructure:"max_size"`
+}
+
+// DefaultOCRCache is used when cache isn't configured.
+var DefaultOCRCache = OCRCacheConfig{
+	Backend: OCRCacheDisk,
+	TTL:     30 * 24 * time.Hour,
+	MaxSize: 512 * 1024 * 1024,
+}
+
+// Options returns eviction options of the cache.
+func (c OCRCacheConfig) Options() ocr.CacheOptions {
+	return ocr.CacheOptions{TTL: c.TTL, MaxSize: c.MaxSize}
+}
+
+type WordsConfig struct {
+	// Synonyms is path of YAML file overriding synonyms shipped with
+	// textproc, see textproc.LoadSynonyms.
+	Synonyms string `mapstructure:"synonyms"`
+	// Taxonomy is path of YAML file overriding taxonomy of technology
+	// terms shipped with textproc, see textproc.LoadTaxonomy.
+	Taxonomy string `mapstructure:"taxonomy"`
+}
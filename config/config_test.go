@@ -3,9 +3,12 @@ package config_test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/kndrad/piccrack/config"
+	"github.com/kndrad/piccrack/pkg/ocr"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,6 +40,27 @@ database:
     max_conn_idle_time: 30m
     connect_timeout: 10s
     dialer_keep_alive: 5s
+
+ocr:
+  pool:
+    max_size: 2
+    idle_timeout: 90s
+  cache:
+    backend: postgres
+    ttl: 24h
+  languages:
+    - pol
+  psm: 6
+  dpi: 300
+  variables:
+    preserve_interword_spaces: "1"
+  preprocess:
+    - invert
+    - otsu
+
+words:
+  synonyms: /etc/piccrack/synonyms.yaml
+  taxonomy: /etc/piccrack/taxonomy.yaml
 `)
 	if _, err := tmf.Write(data); err != nil {
 		t.Fatalf("Failed to write data: %v", err)
@@ -66,4 +90,40 @@ database:
 	require.Equal(t, "30m", cfg.Database.Pool.MaxConnIdleTime)
 	require.Equal(t, "10s", cfg.Database.Pool.ConnectTimeout)
 	require.Equal(t, "5s", cfg.Database.Pool.DialerKeepAlive)
+
+	require.Equal(t, 2, cfg.OCR.Pool.MaxSize)
+	require.Equal(t, 90*time.Second, cfg.OCR.Pool.IdleTimeout)
+
+	require.Equal(t, config.OCRCachePostgres, cfg.OCR.Cache.Backend)
+	require.Equal(t, ocr.CacheOptions{TTL: 24 * time.Hour, MaxSize: config.DefaultOCRCache.MaxSize}, cfg.OCR.Cache.Options())
+
+	opts := cfg.OCR.Options()
+	require.Equal(t, []string{"pol"}, opts.Languages)
+	require.Equal(t, ocr.PSMSingleBlock, opts.PSM)
+	require.Equal(t, 300, opts.DPI)
+	require.Empty(t, opts.Whitelist)
+	require.Equal(t, map[string]string{"preserve_interword_spaces": "1"}, opts.Variables)
+	require.Equal(t, []string{"invert", "otsu"}, opts.Preprocess)
+	require.NoError(t, opts.Validate())
+
+	require.Equal(t, "/etc/piccrack/synonyms.yaml", cfg.Words.Synonyms)
+	require.Equal(t, "/etc/piccrack/taxonomy.yaml", cfg.Words.Taxonomy)
+}
+
+func TestLoadingConfigDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("app:\n  environment: \"testing\"\n"), 0o600))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	require.Equal(t, 25, cfg.Database.Pool.MaxConns)
+	require.Equal(t, 5, cfg.Database.Pool.MinConns)
+	require.Equal(t, "30m", cfg.Database.Pool.MaxConnIdleTime)
+	require.Equal(t, "0.0.0.0", cfg.HTTP.Host)
+
+	require.Equal(t, 4, cfg.OCR.Pool.MaxSize)
+	require.Equal(t, 5*time.Minute, cfg.OCR.Pool.IdleTimeout)
+	require.Equal(t, []string{"eng", "pol"}, cfg.OCR.Languages)
+	require.Equal(t, config.DefaultOCRCache, cfg.OCR.Cache)
 }
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS phrases
+DROP COLUMN IF EXISTS page;
+
+ALTER TABLE IF EXISTS words
+DROP COLUMN IF EXISTS page;
@@ -0,0 +1,5 @@
+ALTER TABLE words
+ADD COLUMN page INTEGER CHECK (page > 0);
+
+ALTER TABLE phrases
+ADD COLUMN page INTEGER CHECK (page > 0);
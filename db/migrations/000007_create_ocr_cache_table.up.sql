@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS ocr_cache (
+    key TEXT PRIMARY KEY,
+    recognition JSONB NOT NULL,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accessed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (LENGTH(key) > 0),
+    CHECK (size >= 0)
+);
+
+CREATE INDEX idx_ocr_cache_accessed_at ON ocr_cache (accessed_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_phrase_category;
+
+ALTER TABLE IF EXISTS phrases
+DROP COLUMN IF EXISTS confidence,
+DROP COLUMN IF EXISTS category;
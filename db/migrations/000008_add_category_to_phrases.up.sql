@@ -0,0 +1,10 @@
+ALTER TABLE phrases
+ADD COLUMN category TEXT NOT NULL DEFAULT 'other' CHECK (
+    category IN ('requirement', 'nice_to_have', 'benefit', 'boilerplate', 'other')
+),
+ADD COLUMN confidence REAL NOT NULL DEFAULT 0 CHECK (
+    confidence >= 0 AND confidence <= 1
+);
+
+CREATE INDEX idx_phrase_category ON phrases (category)
+WHERE deleted_at IS NULL;
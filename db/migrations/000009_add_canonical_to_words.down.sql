@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_word_canonical;
+
+ALTER TABLE IF EXISTS words
+DROP COLUMN IF EXISTS canonical;
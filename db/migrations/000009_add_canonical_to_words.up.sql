@@ -0,0 +1,11 @@
+ALTER TABLE words
+ADD COLUMN canonical TEXT;
+
+-- Synonyms are applied by "piccrack words canonicalize"
+UPDATE words SET canonical = lower(value);
+
+ALTER TABLE words
+ALTER COLUMN canonical SET NOT NULL;
+
+CREATE INDEX idx_word_canonical ON words (canonical)
+WHERE deleted_at IS NULL;
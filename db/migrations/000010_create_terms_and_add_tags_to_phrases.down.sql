@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_phrase_tags;
+
+ALTER TABLE IF EXISTS phrases
+DROP COLUMN IF EXISTS tags;
+
+DROP TABLE IF EXISTS terms;
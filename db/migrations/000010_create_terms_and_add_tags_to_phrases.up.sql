@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS terms (
+    id BIGSERIAL PRIMARY KEY,
+    value TEXT NOT NULL,
+    category TEXT NOT NULL,
+    batch_id BIGINT NOT NULL REFERENCES word_batches (id) ON DELETE CASCADE,
+    page INTEGER CHECK (page > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CHECK (LENGTH(value) > 0),
+    CHECK (LENGTH(category) > 0)
+);
+
+CREATE INDEX idx_term_category ON terms (category)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_term_batch_id ON terms (batch_id)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE phrases
+ADD COLUMN tags TEXT [] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_phrase_tags ON phrases USING gin (tags);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS ngrams (
+    id BIGSERIAL PRIMARY KEY,
+    value TEXT NOT NULL,
+    n INTEGER NOT NULL CHECK (n IN (2, 3)),
+    total INTEGER NOT NULL CHECK (total > 0),
+    pmi REAL NOT NULL,
+    llr REAL NOT NULL,
+    batch_id BIGINT NOT NULL REFERENCES word_batches (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (batch_id, value)
+);
+
+CREATE INDEX idx_ngram_value ON ngrams (value)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_ngram_batch_id ON ngrams (batch_id)
+WHERE deleted_at IS NULL;
@@ -1,8 +1,8 @@
 package v1
 
 import (
-	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,9 +10,12 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/imgsniff"
 	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/textproc"
 )
 
 func limitValue(values url.Values) (int32, error) {
@@ -53,6 +56,106 @@ func offsetValue(values url.Values) (int32, error) {
 	return int32(n), nil
 }
 
+// minConfidenceValue returns minimum ocr word confidence (0-100) from query.
+// Zero keeps every recognized word.
+func minConfidenceValue(values url.Values) (float64, error) {
+	v := values.Get("min_confidence")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse float: %w", err)
+	}
+	if n < 0 || n > 100 {
+		return 0, fmt.Errorf("min confidence %v out of range 0-100", n)
+	}
+
+	return n, nil
+}
+
+// ocrOptionsValue returns ocr options overriding engine configuration for
+// a single request. Languages and preprocessing steps are given as comma
+// separated "lang" and "preprocess" values, every "region" value is a crop
+// rectangle parsed by ocr.ParseRegion. Arbitrary tesseract variables and
+// debug output can't be set from a request.
+func ocrOptionsValue(values url.Values) (ocr.Options, error) {
+	var opts ocr.Options
+
+	opts.Languages = listValue(values, "lang")
+	opts.Preprocess = listValue(values, "preprocess")
+	if v := values.Get("psm"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("parse psm: %w", err)
+		}
+		opts.PSM = ocr.PSM(n)
+	}
+	if v := values.Get("dpi"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("parse dpi: %w", err)
+		}
+		opts.DPI = n
+	}
+	opts.Whitelist = values.Get("whitelist")
+	opts.Blacklist = values.Get("blacklist")
+	for _, v := range values["region"] {
+		r, err := ocr.ParseRegion(v)
+		if err != nil {
+			return opts, fmt.Errorf("parse region: %w", err)
+		}
+		opts.Regions = append(opts.Regions, r)
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, fmt.Errorf("validate: %w", err)
+	}
+
+	return opts, nil
+}
+
+// listValue returns values of key, splitting comma separated ones.
+func listValue(values url.Values, key string) []string {
+	var list []string
+	for _, v := range values[key] {
+		for _, item := range strings.Split(v, ",") {
+			list = append(list, strings.TrimSpace(item))
+		}
+	}
+
+	return list
+}
+
+// scanErrStatus returns status of a failed scan, regions which don't fit
+// uploaded image are client errors.
+func scanErrStatus(err error) int {
+	if errors.Is(err, ocr.ErrRegionOutside) || errors.Is(err, ocr.ErrInvalidOptions) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ocr.ErrContentTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusInternalServerError
+}
+
+// sniffImage detects image or pdf format from the file header and seeks back
+// to the start of the file. It reports false for unsupported files.
+func sniffImage(f io.ReadSeeker) (imgsniff.Format, bool, error) {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return imgsniff.Format{}, false, fmt.Errorf("read header: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return imgsniff.Format{}, false, fmt.Errorf("seek: %w", err)
+	}
+	format, ok := imgsniff.Detect(header[:n])
+
+	return format, ok, nil
+}
+
 func encode[T any](w http.ResponseWriter, _ *http.Request, status int, v T) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -227,17 +330,14 @@ func uploadWordsHandler(svc Service, logger *slog.Logger) http.HandlerFunc {
 			slog.String("filename", fheader.Filename),
 		)
 
-		// Read many words one by one from file
-		scanner := bufio.NewScanner(f)
-		scanner.Split(bufio.ScanWords)
+		content, err := io.ReadAll(f)
+		if err != nil {
+			respondJSON(w, "Failed to read file", err, http.StatusInternalServerError)
 
-		var words []string
-		for scanner.Scan() {
-			words = append(words, scanner.Text())
-		}
-		if err := scanner.Err(); err != nil {
-			respondJSON(w, "Scanner returned an error", err, http.StatusInternalServerError)
+			return
 		}
+		words := textproc.Words(string(content))
+
 		count := 0
 		for _, word := range words {
 			_, err := svc.CreateWord(r.Context(), word)
@@ -259,83 +359,93 @@ func uploadWordsHandler(svc Service, logger *slog.Logger) http.HandlerFunc {
 	}
 }
 
-func uploadImageWordsHandler(svc Service, logger *slog.Logger) http.HandlerFunc {
+func uploadImageWordsHandler(svc Service, e ocr.Engine, logger *slog.Logger) http.HandlerFunc {
 	var maxSize int64 = 1024 * 1024 * 50 // 50 MB
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		minConfidence, err := minConfidenceValue(r.URL.Query())
+		if err != nil {
+			respondJSON(w, "Failed to get min_confidence query value", err, http.StatusBadRequest)
+
+			return
+		}
+
 		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
 
 		if err := r.ParseMultipartForm(maxSize); err != nil {
 			respondJSON(w, "Image file too big", err, http.StatusBadRequest)
+
+			return
 		}
 		f, header, err := r.FormFile("image")
 		if err != nil {
 			respondJSON(w, "Failed to get image file", err, http.StatusBadRequest)
-		}
-		defer f.Close()
 
-		// Detect content type of file and check if it's a text
-		data := make([]byte, 512)
-		if _, err := f.Read(data); err != nil {
-			respondJSON(w, "Failed to read image file into buffer", err, http.StatusInternalServerError)
+			return
 		}
-		contentType := http.DetectContentType(data)
+		defer f.Close()
 
-		var allowed bool
+		format, ok, err := sniffImage(f)
+		if err != nil {
+			respondJSON(w, "Failed to detect image format", err, http.StatusInternalServerError)
 
-		switch contentType {
-		case "image/jpeg", "image/png":
-			allowed = true
-		default:
-			allowed = false
-		}
-		// Return pointer back to the start of the file after content type detection
-		if _, err := f.Seek(0, io.SeekStart); err != nil {
-			respondJSON(w, "Failed to seek to start of the file", err, http.StatusInternalServerError)
+			return
 		}
-		if !allowed {
-			respondJSON(w,
-				fmt.Sprintf("Content type %s not allowed. Upload text file", contentType),
-				nil,
-				http.StatusBadRequest,
-			)
+		if !ok {
+			respondJSON(w, "Unsupported file format. Upload an image or a pdf", nil, http.StatusBadRequest)
+
+			return
 		}
-		logger.Info("Received form", slog.String("header_filename", header.Filename))
+		logger.Info("Received form",
+			slog.String("header_filename", header.Filename),
+			slog.String("format", format.Name),
+		)
 
-		content := make([]byte, ocr.MaxImageSize)
-		if _, err := f.Read(content); err != nil {
-			respondJSON(w,
-				"Failed to read file content for image words recognition.",
-				err,
-				http.StatusInternalServerError,
-			)
+		opts, err := ocrOptionsValue(r.Form)
+		if err != nil {
+			respondJSON(w, "Invalid ocr options", err, http.StatusBadRequest)
+
+			return
 		}
-		c := ocr.NewClient()
-		defer c.Close()
+		ctx := ocr.ContextWithOptions(r.Context(), opts)
 
-		result, err := ocr.ScanFile(c, header.Filename)
+		result, err := ocr.ScanFrom(ctx, e, f)
 		if err != nil {
 			respondJSON(w,
 				"Failed to recognize words from an image",
 				err,
-				http.StatusInternalServerError,
+				scanErrStatus(err),
 			)
+
+			return
 		}
 
-		var words []string
-		for w := range result.Words() {
+		result = result.WithMinConfidence(minConfidence)
+
+		var (
+			words []string
+			pages []int32
+		)
+		for page, w := range result.PageWords() {
 			words = append(words, w)
+			pages = append(pages, int32(page))
 		}
 
-		row, err := svc.CreateWordsBatch(r.Context(), header.Filename, words)
+		row, ngrams, err := svc.CreateWordsBatch(r.Context(), header.Filename, words, pages, result.Text())
 		if err != nil {
 			respondJSON(w, "Failed to insert words batch", err, http.StatusInternalServerError)
+
+			return
 		}
 
 		response := struct {
-			Row database.CreateWordsBatchRow `json:"row"`
+			Row     database.CreateWordsBatchRow `json:"row"`
+			Ngrams  int                          `json:"ngrams"`
+			Regions []regionResponse             `json:"regions,omitempty"`
 		}{
-			Row: row,
+			Row:     row,
+			Ngrams:  ngrams,
+			Regions: regionsResponse(result.Regions()),
 		}
 		if err := encode(w, r, http.StatusOK, response); err != nil {
 			respondJSON(w, "Failed to encode response", err, http.StatusInternalServerError)
@@ -343,6 +453,22 @@ func uploadImageWordsHandler(svc Service, logger *slog.Logger) http.HandlerFunc
 	}
 }
 
+// regionResponse is recognized text of a region requested in a form.
+type regionResponse struct {
+	Name string `json:"name"`
+	Page int    `json:"page"`
+	Text string `json:"text"`
+}
+
+func regionsResponse(regions []ocr.RegionText) []regionResponse {
+	resp := make([]regionResponse, 0, len(regions))
+	for _, r := range regions {
+		resp = append(resp, regionResponse{Name: r.Name, Page: r.Page, Text: r.Text})
+	}
+
+	return resp
+}
+
 func listWordBatchesHandler(svc Service, logger *slog.Logger) http.HandlerFunc {
 	type response struct {
 		Results []database.ListWordBatchesRow `json:"word_batches"`
@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"math"
 	"math/rand/v2"
@@ -13,10 +15,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/ocr"
 	"github.com/stretchr/testify/require"
 )
 
@@ -191,18 +195,32 @@ func TestInsertWordHandler(t *testing.T) {
 	testCases := []struct {
 		desc string
 		data string // data to send
+
+		wantCanonical string
 	}{
 		{
 			desc: "returns_all_words",
 			data: `{"value":"test1"}`,
+
+			wantCanonical: "test1",
+		},
+		{
+			desc: "stores_canonical_form_of_mixed_case_variant",
+			data: `{"value":"K8S"}`,
+
+			wantCanonical: "kubernetes",
+		},
+		{
+			desc: "stores_canonical_form_of_capitalized_variant",
+			data: `{"value":"Golang"}`,
+
+			wantCanonical: "go",
 		},
 	}
 
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
-			svc := &service{
-				q: NewQueriesMock(NewWordsMock()...),
-			}
+			svc := NewService(NewQueriesMock(NewWordsMock()...), testLogger())
 			handler := createWordHandler(svc, testLogger())
 
 			ctx := context.Background()
@@ -228,6 +246,7 @@ func TestInsertWordHandler(t *testing.T) {
 			if err := json.Unmarshal(data, &row); err != nil {
 				t.Fatalf("unmarshal json err: %v", err)
 			}
+			require.Equal(t, tC.wantCanonical, row.Canonical)
 		})
 	}
 }
@@ -302,6 +321,129 @@ func TestGetOffsetFromQuery(t *testing.T) {
 	}
 }
 
+func TestGetMinConfidenceFromQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		query   string
+		want    float64
+		wantErr bool
+	}{
+		{
+			desc:  "zero_if_not_provided",
+			query: "",
+			want:  0,
+		},
+		{
+			desc:  "parses_float",
+			query: "min_confidence=72.5",
+			want:  72.5,
+		},
+		{
+			desc:    "out_of_range_err",
+			query:   "min_confidence=101",
+			wantErr: true,
+		},
+		{
+			desc:    "not_a_number_err",
+			query:   "min_confidence=high",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			values, err := url.ParseQuery(tC.query)
+			require.NoError(t, err)
+
+			v, err := minConfidenceValue(values)
+			if tC.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+			require.NoError(t, err)
+			require.InDelta(t, tC.want, v, 0)
+		})
+	}
+}
+
+func TestGetOCROptionsFromValues(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		query   string
+		want    ocr.Options
+		wantErr bool
+	}{
+		{
+			desc:  "zero_options_if_not_provided",
+			query: "",
+			want:  ocr.Options{},
+		},
+		{
+			desc:  "parses_options",
+			query: "lang=eng,+pol&lang=deu&psm=7&dpi=300&whitelist=ąęł&blacklist=|&preprocess=invert,otsu",
+			want: ocr.Options{
+				Languages:  []string{"eng", "pol", "deu"},
+				Preprocess: []string{"invert", "otsu"},
+				PSM:        ocr.PSMSingleLine,
+				DPI:        300,
+				Whitelist:  "ąęł",
+				Blacklist:  "|",
+			},
+		},
+		{
+			desc:    "psm_not_a_number_err",
+			query:   "psm=auto",
+			wantErr: true,
+		},
+		{
+			desc:    "invalid_dpi_err",
+			query:   "dpi=-300",
+			wantErr: true,
+		},
+		{
+			desc:    "unknown_preprocess_step_err",
+			query:   "preprocess=sharpen",
+			wantErr: true,
+		},
+		{
+			desc:  "parses_regions",
+			query: "region=title=0,0,100%25,10%25&region=description=0,120,800,600",
+			want: ocr.Options{
+				Regions: []ocr.Region{
+					{Name: "title", Width: 100, Height: 10, Percent: true},
+					{Name: "description", Y: 120, Width: 800, Height: 600},
+				},
+			},
+		},
+		{
+			desc:    "invalid_region_err",
+			query:   "region=title=0,0,100",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			values, err := url.ParseQuery(tC.query)
+			require.NoError(t, err)
+
+			opts, err := ocrOptionsValue(values)
+			if tC.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.want, opts)
+		})
+	}
+}
+
 func TestUploadWordsHandler(t *testing.T) {
 	t.Parallel()
 
@@ -384,6 +526,252 @@ func TestUploadWordsHandler(t *testing.T) {
 	}
 }
 
+func TestUploadImageWordsHandler(t *testing.T) {
+	t.Parallel()
+
+	l := testLogger()
+
+	testCases := []struct {
+		desc string
+
+		path     string
+		query    string
+		wantCode int
+	}{
+		{
+			desc: "creates_words_batch_from_an_image",
+
+			path:     filepath.Join("testdata", "0.png"),
+			wantCode: http.StatusOK,
+		},
+		{
+			desc: "creates_words_batch_from_a_pdf",
+
+			path:     filepath.Join("testdata", "offer.pdf"),
+			wantCode: http.StatusOK,
+		},
+		{
+			desc: "rejects_non_image_file",
+
+			path:     filepath.Join("testdata", "0.png"+ocr.FixtureExt),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			desc: "accepts_ocr_options",
+
+			path:     filepath.Join("testdata", "0.png"),
+			query:    "?lang=eng,pol&psm=6&dpi=300",
+			wantCode: http.StatusOK,
+		},
+		{
+			desc: "rejects_invalid_ocr_options",
+
+			path:     filepath.Join("testdata", "0.png"),
+			query:    "?psm=42",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			body := new(bytes.Buffer)
+			w := multipart.NewWriter(body)
+
+			part, err := w.CreateFormFile("image", filepath.Base(tC.path))
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(tC.path)
+			require.NoError(t, err)
+			if _, err := part.Write(data); err != nil {
+				t.Fatalf("Failed to write form file: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Failed to close multipart writer: %v", err)
+			}
+
+			req := httptest.NewRequestWithContext(
+				context.Background(),
+				http.MethodPost,
+				"/"+tC.query,
+				body,
+			)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+
+			rr := httptest.NewRecorder()
+			q := NewQueriesMock()
+			handler := uploadImageWordsHandler(NewService(q, l), testEngine(t), l)
+			handler(rr, req)
+
+			resp := rr.Result()
+			defer resp.Body.Close()
+
+			require.Equal(t, tC.wantCode, resp.StatusCode)
+			if tC.wantCode == http.StatusOK {
+				// Every word is stored with the page it was found on
+				require.NotEmpty(t, q.wordsBatch.Column2)
+				require.Len(t, q.wordsBatch.Column3, len(q.wordsBatch.Column2))
+				require.Equal(t, int32(1), q.wordsBatch.Column3[0])
+			}
+		})
+	}
+}
+
+func TestUploadImageWordsHandlerLargeImage(t *testing.T) {
+	t.Parallel()
+
+	l := testLogger()
+
+	// Random pixels barely compress
+	r := rand.New(rand.NewPCG(1, 2))
+	img := image.NewGray(image.Rect(0, 0, 1000, 1000))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.IntN(256))
+	}
+	data := new(bytes.Buffer)
+	require.NoError(t, png.Encode(data, img))
+	require.Greater(t, data.Len(), 512*1024)
+
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	part, err := w.CreateFormFile("image", "large.png")
+	require.NoError(t, err)
+	_, err = part.Write(data.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	rr := httptest.NewRecorder()
+	q := NewQueriesMock()
+	uploadImageWordsHandler(NewService(q, l), decodeEngine{text: "golang developer"}, l)(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, []string{"golang", "developer"}, q.wordsBatch.Column2)
+	// Words and their n-grams are stored in a single transaction
+	require.Equal(t, 1, q.txs)
+}
+
+func TestUploadImageWordsHandlerCached(t *testing.T) {
+	t.Parallel()
+
+	l := testLogger()
+	q := NewQueriesMock()
+	e := &countingEngine{Engine: testEngine(t)}
+	handler := uploadImageWordsHandler(NewService(q, l), ocr.NewCachedEngine(e, database.NewOCRCache(q, ocr.CacheOptions{})), l)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "0.png"))
+	require.NoError(t, err)
+
+	var words [][]string
+	for range 2 {
+		body := new(bytes.Buffer)
+		w := multipart.NewWriter(body)
+		part, err := w.CreateFormFile("image", "0.png")
+		require.NoError(t, err)
+		_, err = part.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/", body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		words = append(words, q.wordsBatch.Column2)
+	}
+
+	// Duplicate upload is answered from cache
+	require.Equal(t, int32(1), e.calls.Load())
+	require.Equal(t, words[0], words[1])
+}
+
+func TestUploadImageWordsHandlerRegions(t *testing.T) {
+	t.Parallel()
+
+	l := testLogger()
+
+	testCases := []struct {
+		desc string
+
+		regions     []string
+		wantCode    int
+		wantRegions []string
+	}{
+		{
+			desc: "recognizes_regions_separately",
+
+			regions:     []string{"title=0,0,100%,10%", "description=0,20%,100%,80%"},
+			wantCode:    http.StatusOK,
+			wantRegions: []string{"title", "description"},
+		},
+		{
+			desc: "rejects_invalid_region",
+
+			regions:  []string{"title=0,0"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			desc: "rejects_region_outside_of_image",
+
+			regions:  []string{"title=100000,100000,10,10"},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			body := new(bytes.Buffer)
+			w := multipart.NewWriter(body)
+			for _, r := range tC.regions {
+				require.NoError(t, w.WriteField("region", r))
+			}
+			part, err := w.CreateFormFile("image", "0.png")
+			require.NoError(t, err)
+			data, err := os.ReadFile(filepath.Join("testdata", "0.png"))
+			require.NoError(t, err)
+			_, err = part.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/", body)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+
+			rr := httptest.NewRecorder()
+			q := NewQueriesMock()
+			handler := uploadImageWordsHandler(NewService(q, l), textEngine{text: "golang developer"}, l)
+			handler(rr, req)
+			require.Equal(t, tC.wantCode, rr.Code)
+			if tC.wantCode != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Regions []regionResponse `json:"regions"`
+			}
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.Len(t, resp.Regions, len(tC.wantRegions))
+			for i, name := range tC.wantRegions {
+				require.Equal(t, name, resp.Regions[i].Name)
+				require.Equal(t, 1, resp.Regions[i].Page)
+				require.Equal(t, "golang developer", resp.Regions[i].Text)
+			}
+			// Words of every region are stored
+			require.Len(t, q.wordsBatch.Column2, 2*len(tC.wantRegions))
+			// Along with their canonical forms
+			require.Len(t, q.wordsBatch.Column4, len(q.wordsBatch.Column2))
+			require.Equal(t, []string{"go", "developer"}, q.wordsBatch.Column4[:2])
+			// Terms of the taxonomy are tagged
+			require.Equal(t, int64(1), q.terms.BatchID)
+			require.Len(t, q.terms.Terms, len(tC.wantRegions))
+			require.Equal(t, "go", q.terms.Terms[0])
+			require.Equal(t, "languages", q.terms.Categories[0])
+			require.Len(t, q.terms.Pages, len(tC.wantRegions))
+			// Along with n-grams of the text
+			require.Contains(t, q.ngrams.Ngrams, "go developer")
+		})
+	}
+}
+
 func Humanize(b int) string {
 	const unit = 1024
 
@@ -13,6 +13,7 @@ import (
 
 	"github.com/kndrad/piccrack/config"
 	"github.com/kndrad/piccrack/pkg/middleware"
+	"github.com/kndrad/piccrack/pkg/ocr"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -25,31 +26,41 @@ type server struct {
 	l   *slog.Logger
 }
 
-func NewServer(cfg config.HTTPConfig, svc Service, logger *slog.Logger) (*server, error) {
+// NewServer creates the v1 http server. Engine is shared between requests
+// so it must be safe for concurrent use, e.g. an *ocr.Pool.
+func NewServer(cfg config.HTTPConfig, svc Service, engine ocr.Engine, logger *slog.Logger) (*server, error) {
 	if logger == nil {
 		panic("logger cannot be nil")
 	}
+	if engine == nil {
+		panic("engine cannot be nil")
+	}
 	const prefix = "/api/" + Version
 
 	mux := http.NewServeMux()
 
 	reg := prometheus.NewRegistry()
 	m := NewMetrics(reg)
+	if c, ok := engine.(prometheus.Collector); ok {
+		reg.MustRegister(c)
+	}
 
 	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
 	mux.Handle("GET "+prefix+"/healthz", m.WrapHandlerFunc(healthzHandler(logger)))
 	mux.Handle("POST "+prefix+"/phrases",
 		middleware.LogTime(
-			m.WrapHandlerFunc(uploadImagePhrasesHandler(svc, logger)),
+			m.WrapHandlerFunc(uploadImagePhrasesHandler(svc, engine, logger)),
 			logger,
 		),
 	)
+	mux.Handle("GET "+prefix+"/phrases", middleware.LogTime(listPhrasesHandler(svc, logger), logger))
 
 	mux.Handle("GET "+prefix+"/words", listWordsHandler(svc, logger))
 	mux.Handle("POST "+prefix+"/words", createWordHandler(svc, logger))
 	mux.Handle("POST "+prefix+"/words/file", uploadWordsHandler(svc, logger))
-	mux.Handle("POST "+prefix+"/words/image", uploadImageWordsHandler(svc, logger))
+	mux.Handle("POST "+prefix+"/words/image", uploadImageWordsHandler(svc, engine, logger))
 	mux.Handle("GET "+prefix+"/words/batches", middleware.LogTime(listWordsByBatchNameHandler(svc, logger), logger))
+	mux.Handle("GET "+prefix+"/ngrams", middleware.LogTime(listNgramsHandler(svc, logger), logger))
 
 	var handler http.Handler = mux
 
@@ -68,11 +79,16 @@ func NewServer(cfg config.HTTPConfig, svc Service, logger *slog.Logger) (*server
 	}, nil
 }
 
+// Start listens and serves until ctx is done. Values of ctx, like
+// configured ocr options, are visible to request contexts.
 func (s *server) Start(ctx context.Context) error {
 	if s == nil {
 		panic("server cannot be nil")
 	}
 
+	base := context.WithoutCancel(ctx)
+	s.srv.BaseContext = func(net.Listener) context.Context { return base }
+
 	s.l.Info("Starting to listen and serve",
 		slog.String("addr", s.srv.Addr),
 		slog.Bool("https_enabled", s.cfg.TLSEnabled),
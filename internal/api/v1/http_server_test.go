@@ -47,6 +47,7 @@ func TestServerStart(t *testing.T) {
 					q:      NewQueriesMock(NewWordsMock()...),
 					logger: testLogger(),
 				},
+				testEngine(t),
 				testLogger(),
 			)
 			require.NoError(t, err)
@@ -1,15 +1,27 @@
 package v1
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"image"
+	_ "image/png"
 	"log/slog"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/rand"
 )
 
@@ -17,6 +29,56 @@ func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stdout, nil))
 }
 
+// testEngine returns a fixture engine which reads ground truth text
+// of images from testdata.
+func testEngine(t *testing.T) ocr.Engine {
+	t.Helper()
+
+	e, err := ocr.NewFixtureEngine("testdata")
+	require.NoError(t, err)
+
+	return e
+}
+
+// countingEngine counts recognitions of the wrapped engine.
+type countingEngine struct {
+	ocr.Engine
+	calls atomic.Int32
+}
+
+func (e *countingEngine) Recognize(ctx context.Context, content []byte) (*ocr.Recognition, error) {
+	e.calls.Add(1)
+
+	return e.Engine.Recognize(ctx, content)
+}
+
+// textEngine recognizes every image as the same text.
+type textEngine struct {
+	text string
+}
+
+func (e textEngine) Recognize(ctx context.Context, content []byte) (*ocr.Recognition, error) {
+	return &ocr.Recognition{Text: e.text}, nil
+}
+
+func (textEngine) Close() error { return nil }
+
+// decodeEngine recognizes images decoded whole as the same text, so it
+// fails on truncated images.
+type decodeEngine struct {
+	text string
+}
+
+func (e decodeEngine) Recognize(ctx context.Context, content []byte) (*ocr.Recognition, error) {
+	if _, _, err := image.Decode(bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return &ocr.Recognition{Text: e.text}, nil
+}
+
+func (decodeEngine) Close() error { return nil }
+
 type WordMock struct {
 	id        int64
 	value     string
@@ -174,6 +236,18 @@ type QueriesMock struct {
 	wordsRows            []database.ListWordsRow
 	wordsFrequenciesRows []database.ListWordFrequenciesRow
 	wordsRankRows        []database.ListWordRankingsRow
+
+	// Last created batches
+	wordsBatch   database.CreateWordsBatchParams
+	phrasesBatch database.CreatePhrasesBatchParams
+	terms        database.CreateTermsParams
+	ngrams       database.CreateNgramsParams
+
+	// Number of transactions run
+	txs int
+
+	ocrCacheMu sync.Mutex
+	ocrCache   map[string][]byte
 }
 
 func NewQueriesMock(words ...WordMock) *QueriesMock {
@@ -191,27 +265,167 @@ func NewQueriesMock(words ...WordMock) *QueriesMock {
 	}
 }
 
+func (q *QueriesMock) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	q.txs++
+
+	return fn(q)
+}
+
 func (q *QueriesMock) CreatePhrasesBatch(ctx context.Context, arg database.CreatePhrasesBatchParams) (database.CreatePhrasesBatchRow, error) {
+	q.phrasesBatch = arg
+
 	return database.CreatePhrasesBatchRow{}, nil
 }
 
-func (q *QueriesMock) CreateWord(ctx context.Context, value string) (database.CreateWordRow, error) {
+func (q *QueriesMock) ListPhrases(ctx context.Context, arg database.ListPhrasesParams) ([]database.ListPhrasesRow, error) {
+	rows := make([]database.ListPhrasesRow, 0)
+	for i, value := range q.phrasesBatch.Column2 {
+		category := q.phrasesBatch.Column4[i]
+		if arg.Category.Valid && category != arg.Category.String {
+			continue
+		}
+		if q.phrasesBatch.Column5[i] < arg.MinConfidence {
+			continue
+		}
+		var tags []string
+		if q.phrasesBatch.Column6 != nil && q.phrasesBatch.Column6[i] != "" {
+			tags = strings.Split(q.phrasesBatch.Column6[i], ",")
+		}
+		if arg.Tag.Valid && !slices.Contains(tags, arg.Tag.String) {
+			continue
+		}
+		rows = append(rows, database.ListPhrasesRow{
+			ID:         int64(i) + 1,
+			Value:      value,
+			Category:   category,
+			Confidence: q.phrasesBatch.Column5[i],
+			Tags:       tags,
+			BatchName:  q.phrasesBatch.Name,
+		})
+	}
+
+	return rows, nil
+}
+
+func (q *QueriesMock) CreateWord(ctx context.Context, arg database.CreateWordParams) (database.CreateWordRow, error) {
 	wm := &WordMock{
 		id:        int64(len(q.wordsRows)) + 1,
-		value:     value,
+		value:     arg.Value,
 		createdAt: time.Now().UTC(),
 	}
 	pgw := wm.ToPostgres()
 	row := database.CreateWordRow{
 		ID:        pgw.ID,
 		Value:     pgw.Value,
+		Canonical: arg.Canonical,
 		CreatedAt: pgw.CreatedAt,
 	}
 	return row, nil
 }
 
 func (q *QueriesMock) CreateWordsBatch(ctx context.Context, arg database.CreateWordsBatchParams) (database.CreateWordsBatchRow, error) {
-	return database.CreateWordsBatchRow{}, nil
+	q.wordsBatch = arg
+
+	return database.CreateWordsBatchRow{BatchID: pgtype.Int8{Int64: 1, Valid: true}}, nil
+}
+
+func (q *QueriesMock) ListWordValues(ctx context.Context) ([]string, error) {
+	values := make([]string, 0, len(q.wordsRows))
+	for _, row := range q.wordsRows {
+		values = append(values, row.Value)
+	}
+
+	return values, nil
+}
+
+func (q *QueriesMock) UpdateWordCanonicals(ctx context.Context, arg database.UpdateWordCanonicalsParams) (int64, error) {
+	return 0, nil
+}
+
+func (q *QueriesMock) CreateNgrams(ctx context.Context, arg database.CreateNgramsParams) error {
+	q.ngrams = arg
+
+	return nil
+}
+
+func (q *QueriesMock) ListNgrams(ctx context.Context, arg database.ListNgramsParams) ([]database.ListNgramsRow, error) {
+	rows := make([]database.ListNgramsRow, 0)
+	if arg.BatchName.Valid && arg.BatchName.String != q.wordsBatch.Name {
+		return rows, nil
+	}
+	for i, value := range q.ngrams.Ngrams {
+		if arg.N.Valid && q.ngrams.Ns[i] != arg.N.Int32 {
+			continue
+		}
+		if q.ngrams.Totals[i] < arg.MinTotal {
+			continue
+		}
+		rows = append(rows, database.ListNgramsRow{
+			ID:        int64(i) + 1,
+			Value:     value,
+			N:         q.ngrams.Ns[i],
+			Total:     q.ngrams.Totals[i],
+			Pmi:       q.ngrams.Pmis[i],
+			Llr:       q.ngrams.Llrs[i],
+			BatchName: q.wordsBatch.Name,
+		})
+	}
+
+	return rows, nil
+}
+
+func (q *QueriesMock) ListNgramFrequencies(ctx context.Context, arg database.ListNgramFrequenciesParams) ([]database.ListNgramFrequenciesRow, error) {
+	return []database.ListNgramFrequenciesRow{}, nil
+}
+
+func (q *QueriesMock) CreateTerms(ctx context.Context, arg database.CreateTermsParams) error {
+	q.terms = arg
+
+	return nil
+}
+
+func (q *QueriesMock) CountTermsByCategory(ctx context.Context, arg database.CountTermsByCategoryParams) ([]database.CountTermsByCategoryRow, error) {
+	return []database.CountTermsByCategoryRow{}, nil
+}
+
+func (q *QueriesMock) CountTermsByBatch(ctx context.Context, arg database.CountTermsByBatchParams) ([]database.CountTermsByBatchRow, error) {
+	return []database.CountTermsByBatchRow{}, nil
+}
+
+func (q *QueriesMock) ListTermFrequencies(ctx context.Context, arg database.ListTermFrequenciesParams) ([]database.ListTermFrequenciesRow, error) {
+	return []database.ListTermFrequenciesRow{}, nil
+}
+
+func (q *QueriesMock) DeleteExpiredOCRCacheEntries(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	return 0, nil
+}
+
+func (q *QueriesMock) EvictOCRCacheEntries(ctx context.Context, maxSize int64) (int64, error) {
+	return 0, nil
+}
+
+func (q *QueriesMock) GetOCRCacheEntry(ctx context.Context, arg database.GetOCRCacheEntryParams) ([]byte, error) {
+	q.ocrCacheMu.Lock()
+	defer q.ocrCacheMu.Unlock()
+
+	data, ok := q.ocrCache[arg.Key]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	return data, nil
+}
+
+func (q *QueriesMock) SetOCRCacheEntry(ctx context.Context, arg database.SetOCRCacheEntryParams) error {
+	q.ocrCacheMu.Lock()
+	defer q.ocrCacheMu.Unlock()
+
+	if q.ocrCache == nil {
+		q.ocrCache = make(map[string][]byte)
+	}
+	q.ocrCache[arg.Key] = arg.Recognition
+
+	return nil
 }
 
 func (q *QueriesMock) ListWords(ctx context.Context, arg database.ListWordsParams) ([]database.ListWordsRow, error) {
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/kndrad/piccrack/internal/database"
+)
+
+// listNgramsHandler lists stored n-grams of batches, the most significant
+// collocations first. Query value "batch" selects n-grams of a batch of
+// this name, "n" (2 or 3) bigrams or trigrams only and "min_total" ones
+// occurring in a batch at least this many times.
+func listNgramsHandler(svc Service, l *slog.Logger) http.HandlerFunc {
+	type response struct {
+		Rows []database.ListNgramsRow `json:"rows"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		limit, err := limitValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get limit query value", err, http.StatusBadRequest)
+
+			return
+		}
+		offset, err := offsetValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get offset query value", err, http.StatusBadRequest)
+
+			return
+		}
+		n, err := ngramSizeValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get n query value", err, http.StatusBadRequest)
+
+			return
+		}
+		minTotal, err := minTotalValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get min_total query value", err, http.StatusBadRequest)
+
+			return
+		}
+		batch := query.Get("batch")
+
+		l.Info("Listing ngrams", "batch", batch, "n", n, "min_total", minTotal)
+
+		rows, err := svc.ListNgrams(r.Context(), batch, n, minTotal, limit, offset)
+		if err != nil {
+			respondJSON(w, "Failed to list ngrams", err, http.StatusInternalServerError)
+
+			return
+		}
+		if err := encode(w, r, http.StatusOK, response{Rows: rows}); err != nil {
+			respondJSON(w, "Failed to encode response", err, http.StatusInternalServerError)
+
+			return
+		}
+	}
+}
+
+// ngramSizeValue returns number of words of n-grams from query, zero if
+// it's not set.
+func ngramSizeValue(values url.Values) (int32, error) {
+	v := values.Get("n")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("parse int: %w", err)
+	}
+	if n != 2 && n != 3 {
+		return 0, fmt.Errorf("n must be 2 or 3, got %d", n)
+	}
+
+	return int32(n), nil
+}
+
+// minTotalValue returns minimum number of occurrences of n-grams in a batch
+// from query.
+func minTotalValue(values url.Values) (int32, error) {
+	v := values.Get("min_total")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("parse uint: %w", err)
+	}
+
+	return int32(n), nil
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListNgramsHandler(t *testing.T) {
+	t.Parallel()
+
+	l := testLogger()
+
+	q := NewQueriesMock()
+	svc := NewService(q, l)
+
+	const text = `We build distributed systems. Experience with distributed systems
+and infrastructure as code. Design patterns, design patterns in Golang.`
+	_, n, err := svc.CreateWordsBatch(context.Background(), "offer", []string{"we"}, nil, text)
+	require.NoError(t, err)
+	require.Equal(t, 1, q.txs)
+	require.Equal(t, len(q.ngrams.Ngrams), n)
+	require.Equal(t, int64(1), q.ngrams.BatchID)
+	// Words are canonicalized
+	require.Contains(t, q.ngrams.Ngrams, "patterns in go")
+
+	testCases := []struct {
+		desc string
+
+		query      string
+		want       []string
+		wantStatus int
+	}{
+		{
+			desc: "lists_ngrams_occurring_at_least_min_total_times",
+
+			query: "?min_total=2",
+			want:  []string{"distributed systems", "design patterns"},
+		},
+		{
+			desc: "filters_by_size",
+
+			query: "?n=3",
+			want: []string{
+				"build distributed systems",
+				"experience with distributed",
+				"infrastructure as code",
+				"patterns in go",
+			},
+		},
+		{
+			desc: "filters_by_batch",
+
+			query: "?batch=other",
+			want:  []string{},
+		},
+		{
+			desc: "invalid_size_err",
+
+			query:      "?n=4",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			desc: "invalid_min_total_err",
+
+			query:      "?min_total=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/"+tC.query, nil)
+
+			rr := httptest.NewRecorder()
+			listNgramsHandler(svc, l)(rr, req)
+
+			res := rr.Result()
+			if tC.wantStatus != 0 {
+				require.Equal(t, tC.wantStatus, res.StatusCode)
+
+				return
+			}
+			require.Equal(t, http.StatusOK, res.StatusCode)
+
+			var body struct {
+				Rows []database.ListNgramsRow `json:"rows"`
+			}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+
+			got := make([]string, 0)
+			for _, row := range body.Rows {
+				got = append(got, row.Value)
+			}
+			require.ElementsMatch(t, tC.want, got)
+		})
+	}
+}
@@ -1,18 +1,35 @@
 package v1
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/kndrad/piccrack/internal/database"
 	"github.com/kndrad/piccrack/pkg/ocr"
 	"github.com/kndrad/piccrack/pkg/picphrase"
 )
 
-func uploadImagePhrasesHandler(svc Service, l *slog.Logger) http.HandlerFunc {
+func uploadImagePhrasesHandler(svc Service, e ocr.Engine, l *slog.Logger) http.HandlerFunc {
 	const maxSize int64 = 1024 * 1024 * 50 // 50 MB
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		minConfidence, err := minConfidenceValue(r.URL.Query())
+		if err != nil {
+			respondJSON(w, "Failed to get min_confidence query value", err, http.StatusBadRequest)
+
+			return
+		}
+
+		mode, err := picphrase.ParseMode(r.URL.Query().Get("mode"))
+		if err != nil {
+			respondJSON(w, "Failed to get mode query value", err, http.StatusBadRequest)
+
+			return
+		}
+
 		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
 
 		if err := r.ParseMultipartForm(maxSize); err != nil {
@@ -29,7 +46,22 @@ func uploadImagePhrasesHandler(svc Service, l *slog.Logger) http.HandlerFunc {
 		}
 		defer f.Close()
 
-		l.Info("Received form", slog.String("header_filename", fh.Filename))
+		format, ok, err := sniffImage(f)
+		if err != nil {
+			respondJSON(w, "Failed to detect image format", err, http.StatusInternalServerError)
+
+			return
+		}
+		if !ok {
+			respondJSON(w, "Unsupported file format. Upload an image or a pdf", nil, http.StatusBadRequest)
+
+			return
+		}
+
+		l.Info("Received form",
+			slog.String("header_filename", fh.Filename),
+			slog.String("format", format.Name),
+		)
 
 		img, err := fh.Open()
 		if err != nil {
@@ -38,19 +70,30 @@ func uploadImagePhrasesHandler(svc Service, l *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		tc := ocr.NewClient()
-		defer tc.Close()
-
-		phrases, err := picphrase.ScanReader(r.Context(), img)
+		opts, err := ocrOptionsValue(r.Form)
 		if err != nil {
-			respondJSON(w, "Failed to ocr", err, http.StatusInternalServerError)
+			respondJSON(w, "Invalid ocr options", err, http.StatusBadRequest)
 
 			return
 		}
+		ctx := ocr.ContextWithOptions(r.Context(), opts)
+
+		scanner := &picphrase.Scanner{Engine: e, MinConfidence: minConfidence, Mode: mode}
 
 		values := make([]string, 0)
-		for phrase := range phrases {
+		pages := make([]int32, 0)
+		categories := make([]string, 0)
+		confidences := make([]float32, 0)
+		for phrase, err := range scanner.PhrasesFrom(ctx, img) {
+			if err != nil {
+				respondJSON(w, "Failed to ocr", err, scanErrStatus(err))
+
+				return
+			}
 			values = append(values, phrase.String())
+			pages = append(pages, int32(phrase.Page()))
+			categories = append(categories, string(phrase.Category()))
+			confidences = append(confidences, float32(phrase.Confidence()))
 		}
 
 		name := r.URL.Query().Get("name")
@@ -58,7 +101,7 @@ func uploadImagePhrasesHandler(svc Service, l *slog.Logger) http.HandlerFunc {
 			name = fh.Filename
 		}
 
-		row, err := svc.CreatePhrasesBatch(r.Context(), name, values)
+		row, err := svc.CreatePhrasesBatch(r.Context(), name, values, pages, categories, confidences)
 		if err != nil {
 			respondJSON(w, "Failed to create phrases batch", err, http.StatusInternalServerError)
 
@@ -81,3 +124,89 @@ func uploadImagePhrasesHandler(svc Service, l *slog.Logger) http.HandlerFunc {
 		}
 	}
 }
+
+// listPhrasesHandler lists stored phrases. Query values "category" and
+// "min_category_confidence" (0-1) filter them by classification, "tag" by
+// category of technology terms found in them.
+func listPhrasesHandler(svc Service, l *slog.Logger) http.HandlerFunc {
+	type response struct {
+		Rows []database.ListPhrasesRow `json:"rows"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		limit, err := limitValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get limit query value", err, http.StatusBadRequest)
+
+			return
+		}
+		offset, err := offsetValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get offset query value", err, http.StatusBadRequest)
+
+			return
+		}
+		category, err := categoryValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get category query value", err, http.StatusBadRequest)
+
+			return
+		}
+		minConfidence, err := categoryConfidenceValue(query)
+		if err != nil {
+			respondJSON(w, "Failed to get min_category_confidence query value", err, http.StatusBadRequest)
+
+			return
+		}
+
+		tag := query.Get("tag")
+
+		l.Info("Listing phrases", "category", category, "tag", tag, "min_category_confidence", minConfidence)
+
+		rows, err := svc.ListPhrases(r.Context(), string(category), tag, minConfidence, limit, offset)
+		if err != nil {
+			respondJSON(w, "Failed to list phrases", err, http.StatusInternalServerError)
+
+			return
+		}
+		if err := encode(w, r, http.StatusOK, response{Rows: rows}); err != nil {
+			respondJSON(w, "Failed to encode response", err, http.StatusInternalServerError)
+
+			return
+		}
+	}
+}
+
+// categoryValue returns phrase category from query, empty if it's not set.
+func categoryValue(values url.Values) (picphrase.Category, error) {
+	v := values.Get("category")
+	if v == "" {
+		return "", nil
+	}
+	c, err := picphrase.ParseCategory(v)
+	if err != nil {
+		return "", fmt.Errorf("parse category: %w", err)
+	}
+
+	return c, nil
+}
+
+// categoryConfidenceValue returns minimum confidence (0-1) of phrase
+// categories from query.
+func categoryConfidenceValue(values url.Values) (float32, error) {
+	v := values.Get("min_category_confidence")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(v, 32)
+	if err != nil {
+		return 0, fmt.Errorf("parse float: %w", err)
+	}
+	if n < 0 || n > 1 {
+		return 0, fmt.Errorf("min category confidence %v out of range 0-1", n)
+	}
+
+	return float32(n), nil
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -11,6 +12,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/picphrase"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,8 +25,10 @@ func TestUploadImagePhrasesHandler(t *testing.T) {
 	testCases := []struct {
 		desc string
 
-		path string
-		svc  Service
+		path       string
+		query      string
+		svc        Service
+		wantStatus int
 	}{
 		{
 			desc: "uploads_phrases_from_an_image",
@@ -31,6 +36,27 @@ func TestUploadImagePhrasesHandler(t *testing.T) {
 
 			svc: NewService(NewQueriesMock(NewWordsMock()...), l),
 		},
+		{
+			desc: "uploads_phrases_from_a_pdf",
+			path: filepath.Join("testdata", "offer.pdf"),
+
+			svc: NewService(NewQueriesMock(NewWordsMock()...), l),
+		},
+		{
+			desc:  "uploads_sentences",
+			path:  filepath.Join("testdata", "offer.pdf"),
+			query: "&mode=sentences",
+
+			svc: NewService(NewQueriesMock(NewWordsMock()...), l),
+		},
+		{
+			desc:  "unknown_mode_err",
+			path:  filepath.Join("testdata", "0.png"),
+			query: "&mode=paragraphs",
+
+			svc:        NewService(NewQueriesMock(NewWordsMock()...), l),
+			wantStatus: http.StatusBadRequest,
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
@@ -57,12 +83,12 @@ func TestUploadImagePhrasesHandler(t *testing.T) {
 			req := httptest.NewRequestWithContext(
 				ctx,
 				http.MethodPost,
-				"/?name=testbatch",
+				"/?name=testbatch"+tC.query,
 				buf,
 			)
 			req.Header.Set("Content-Type", w.FormDataContentType())
 
-			handler := uploadImagePhrasesHandler(tC.svc, l)
+			handler := uploadImagePhrasesHandler(tC.svc, testEngine(t), l)
 
 			rr := httptest.NewRecorder()
 			handler(rr, req)
@@ -72,6 +98,134 @@ func TestUploadImagePhrasesHandler(t *testing.T) {
 
 			require.NoError(t, err)
 			require.NotEmpty(t, data)
+			want := tC.wantStatus
+			if want == 0 {
+				want = http.StatusOK
+			}
+			require.Equal(t, want, res.StatusCode, string(data))
 		})
 	}
 }
+
+func TestListPhrasesHandler(t *testing.T) {
+	t.Parallel()
+
+	l := testLogger()
+
+	q := NewQueriesMock()
+	q.phrasesBatch = database.CreatePhrasesBatchParams{
+		Name:    "offer",
+		Column2: []string{"requirements", "3+ years of go", "docker is a plus", "multisport card"},
+		Column3: []int32{1, 1, 1, 2},
+		Column4: []string{"boilerplate", "requirement", "nice_to_have", "benefit"},
+		Column5: []float32{0.9, 1, 0.6, 0.8},
+		Column6: []string{"", "languages", "containers", ""},
+	}
+	svc := NewService(q, l)
+
+	testCases := []struct {
+		desc string
+
+		query      string
+		want       []string
+		wantStatus int
+	}{
+		{
+			desc: "lists_every_phrase",
+
+			query: "",
+			want:  []string{"requirements", "3+ years of go", "docker is a plus", "multisport card"},
+		},
+		{
+			desc: "filters_by_category",
+
+			query: "?category=requirement",
+			want:  []string{"3+ years of go"},
+		},
+		{
+			desc: "filters_by_confidence",
+
+			query: "?min_category_confidence=0.85",
+			want:  []string{"requirements", "3+ years of go"},
+		},
+		{
+			desc: "filters_by_tag",
+
+			query: "?tag=containers",
+			want:  []string{"docker is a plus"},
+		},
+		{
+			desc: "unknown_category_err",
+
+			query:      "?category=salary",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			desc: "confidence_out_of_range_err",
+
+			query:      "?min_category_confidence=50",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/"+tC.query, nil)
+
+			rr := httptest.NewRecorder()
+			listPhrasesHandler(svc, l)(rr, req)
+
+			res := rr.Result()
+			if tC.wantStatus != 0 {
+				require.Equal(t, tC.wantStatus, res.StatusCode)
+
+				return
+			}
+			require.Equal(t, http.StatusOK, res.StatusCode)
+
+			var body struct {
+				Rows []database.ListPhrasesRow `json:"rows"`
+			}
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+
+			got := make([]string, 0)
+			for _, row := range body.Rows {
+				got = append(got, row.Value)
+			}
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestUploadImagePhrasesHandlerClassifies(t *testing.T) {
+	t.Parallel()
+
+	l := testLogger()
+	q := NewQueriesMock()
+
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	f, err := w.CreateFormFile("image", "offer.pdf")
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join("testdata", "offer.pdf"))
+	require.NoError(t, err)
+	_, err = f.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/?name=offer", buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	rr := httptest.NewRecorder()
+	uploadImagePhrasesHandler(NewService(q, l), testEngine(t), l)(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	batch := q.phrasesBatch
+	require.NotEmpty(t, batch.Column2)
+	require.Len(t, batch.Column4, len(batch.Column2))
+	require.Len(t, batch.Column5, len(batch.Column2))
+	require.Len(t, batch.Column6, len(batch.Column2))
+	for _, c := range batch.Column4 {
+		_, err := picphrase.ParseCategory(c)
+		require.NoError(t, err)
+	}
+}
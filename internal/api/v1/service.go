@@ -5,29 +5,44 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kndrad/piccrack/internal/database"
+	"github.com/kndrad/piccrack/pkg/textproc"
 )
 
 type Service interface {
 	ListWords(ctx context.Context, limit, offset int32) ([]database.ListWordsRow, error)
 	CreateWord(ctx context.Context, value string) (database.CreateWordRow, error)
 	ListWordBatches(ctx context.Context, limit, offset int32) ([]database.ListWordBatchesRow, error)
-	CreateWordsBatch(ctx context.Context, name string, values []string) (database.CreateWordsBatchRow, error)
+	CreateWordsBatch(ctx context.Context, name string, values []string, pages []int32, text string) (database.CreateWordsBatchRow, int, error)
 	ListWordsByBatchName(ctx context.Context, name string) ([]database.ListWordsByBatchNameRow, error)
-	CreatePhrasesBatch(ctx context.Context, name string, values []string) (database.CreatePhrasesBatchRow, error)
+	CreatePhrasesBatch(ctx context.Context, name string, values []string, pages []int32, categories []string, confidences []float32) (database.CreatePhrasesBatchRow, error)
+	ListPhrases(ctx context.Context, category, tag string, minConfidence float32, limit, offset int32) ([]database.ListPhrasesRow, error)
+	ListNgrams(ctx context.Context, batchName string, n, minTotal, limit, offset int32) ([]database.ListNgramsRow, error)
 }
 
 type service struct {
-	q      database.Querier
-	logger *slog.Logger
+	q        database.TxQuerier
+	logger   *slog.Logger
+	synonyms textproc.Synonyms
+	batches  *database.Batches
 }
 
 var _ Service = (*service)(nil)
 
-func NewService(q database.Querier, l *slog.Logger) Service {
+func NewService(q database.TxQuerier, l *slog.Logger) Service {
+	return NewServiceWithTaxonomy(q, l, textproc.DefaultSynonyms(), textproc.DefaultTaxonomy())
+}
+
+// NewServiceWithTaxonomy returns service storing words along with their
+// canonical forms of synonyms, and tagging words and phrases with
+// categories of terms of taxonomy found in them.
+func NewServiceWithTaxonomy(q database.TxQuerier, l *slog.Logger, synonyms textproc.Synonyms, taxonomy textproc.Taxonomy) Service {
 	return &service{
-		q:      q,
-		logger: l,
+		q:        q,
+		logger:   l,
+		synonyms: synonyms,
+		batches:  database.NewBatches(synonyms, taxonomy),
 	}
 }
 
@@ -47,7 +62,10 @@ func (svc *service) CreateWord(ctx context.Context, value string) (database.Crea
 	if value == "" {
 		panic("value cannot be empty")
 	}
-	row, err := svc.q.CreateWord(ctx, value)
+	row, err := svc.q.CreateWord(ctx, database.CreateWordParams{
+		Value:     value,
+		Canonical: svc.synonyms.Canonical(value),
+	})
 	if err != nil {
 		return row, fmt.Errorf("insert word: %w", err)
 	}
@@ -67,16 +85,29 @@ func (svc *service) ListWordBatches(ctx context.Context, limit, offset int32) ([
 	return rows, nil
 }
 
-func (svc *service) CreateWordsBatch(ctx context.Context, name string, values []string) (database.CreateWordsBatchRow, error) {
-	row, err := svc.q.CreateWordsBatch(ctx, database.CreateWordsBatchParams{
-		Name:    name,
-		Column2: values,
+// CreateWordsBatch stores values as a named batch along with n-grams of
+// text, in one transaction. Pages hold image page numbers of values, they
+// may be nil if unknown. It returns the number of stored n-grams, see
+// database.Batches.
+func (svc *service) CreateWordsBatch(ctx context.Context, name string, values []string, pages []int32, text string) (database.CreateWordsBatchRow, int, error) {
+	var (
+		row    database.CreateWordsBatchRow
+		ngrams int
+	)
+	err := svc.q.InTx(ctx, func(q database.Querier) error {
+		var err error
+		if row, err = svc.batches.CreateWordsBatch(ctx, q, name, values, pages); err != nil {
+			return err
+		}
+		ngrams, err = svc.batches.CreateNgrams(ctx, q, row.BatchID.Int64, text)
+
+		return err
 	})
 	if err != nil {
-		return row, fmt.Errorf("create word batch: %w", err)
+		return database.CreateWordsBatchRow{}, 0, fmt.Errorf("in tx: %w", err)
 	}
 
-	return row, nil
+	return row, ngrams, nil
 }
 
 func (svc *service) ListWordsByBatchName(ctx context.Context, name string) ([]database.ListWordsByBatchNameRow, error) {
@@ -88,14 +119,52 @@ func (svc *service) ListWordsByBatchName(ctx context.Context, name string) ([]da
 	return rows, nil
 }
 
-func (svc *service) CreatePhrasesBatch(ctx context.Context, name string, values []string) (database.CreatePhrasesBatchRow, error) {
-	row, err := svc.q.CreatePhrasesBatch(ctx, database.CreatePhrasesBatchParams{
+// CreatePhrasesBatch stores values as a named batch. Pages hold image page
+// numbers of values, they may be nil if unknown. Categories and confidences
+// hold classification of values, see picphrase.Classifier. Values are
+// tagged with categories of terms of the taxonomy found in them.
+func (svc *service) CreatePhrasesBatch(ctx context.Context, name string, values []string, pages []int32, categories []string, confidences []float32) (database.CreatePhrasesBatchRow, error) {
+	return svc.batches.CreatePhrasesBatch(ctx, svc.q, database.CreatePhrasesBatchParams{
 		Name:    name,
 		Column2: values,
+		Column3: pages,
+		Column4: categories,
+		Column5: confidences,
+	})
+}
+
+// ListPhrases lists phrases of every batch classified with at least
+// minConfidence. Empty category lists phrases of every category, empty tag
+// lists phrases with any tags.
+func (svc *service) ListPhrases(ctx context.Context, category, tag string, minConfidence float32, limit, offset int32) ([]database.ListPhrasesRow, error) {
+	rows, err := svc.q.ListPhrases(ctx, database.ListPhrasesParams{
+		Category:      pgtype.Text{String: category, Valid: category != ""},
+		Tag:           pgtype.Text{String: tag, Valid: tag != ""},
+		MinConfidence: minConfidence,
+		RowLimit:      limit,
+		RowOffset:     offset,
 	})
 	if err != nil {
-		return row, fmt.Errorf("create word batch: %w", err)
+		return nil, fmt.Errorf("list phrases: %w", err)
 	}
 
-	return row, nil
+	return rows, nil
+}
+
+// ListNgrams lists n-grams of batches, the most significant collocations
+// first. Empty batchName lists n-grams of every batch, zero n lists both
+// bigrams and trigrams.
+func (svc *service) ListNgrams(ctx context.Context, batchName string, n, minTotal, limit, offset int32) ([]database.ListNgramsRow, error) {
+	rows, err := svc.q.ListNgrams(ctx, database.ListNgramsParams{
+		BatchName: pgtype.Text{String: batchName, Valid: batchName != ""},
+		N:         pgtype.Int4{Int32: n, Valid: n != 0},
+		MinTotal:  minTotal,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list ngrams: %w", err)
+	}
+
+	return rows, nil
 }
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kndrad/piccrack/pkg/textproc"
+)
+
+// ErrBatchExists is returned by CreatePhrasesBatch if a batch of the same
+// name is stored already.
+var ErrBatchExists = errors.New("batch exists")
+
+// Batches stores batches of words and phrases along with what's derived
+// from them: canonical forms of synonyms, terms of the taxonomy, n-grams and
+// tags of phrases. It's shared by the API and the scan watch command, queries run
+// with q passed to every method, e.g. one of a transaction.
+type Batches struct {
+	synonyms textproc.Synonyms
+	matcher  *textproc.Matcher
+}
+
+func NewBatches(synonyms textproc.Synonyms, taxonomy textproc.Taxonomy) *Batches {
+	return &Batches{
+		synonyms: synonyms,
+		matcher:  textproc.NewMatcher(taxonomy, synonyms),
+	}
+}
+
+// CreateWordsBatch stores values as a named batch. Pages hold image page
+// numbers of values, they may be nil if unknown. Values are stored along
+// with their canonical forms, terms of the taxonomy found in them are
+// stored as terms of the batch.
+func (b *Batches) CreateWordsBatch(ctx context.Context, q Querier, name string, values []string, pages []int32) (CreateWordsBatchRow, error) {
+	row, err := q.CreateWordsBatch(ctx, CreateWordsBatchParams{
+		Name:    name,
+		Column2: values,
+		Column3: pages,
+		Column4: b.synonyms.CanonicalWords(values),
+	})
+	if err != nil {
+		return row, fmt.Errorf("create words batch: %w", err)
+	}
+
+	matches := b.matcher.Match(values)
+	if len(matches) == 0 {
+		return row, nil
+	}
+	params := CreateTermsParams{BatchID: row.BatchID.Int64}
+	for _, m := range matches {
+		params.Terms = append(params.Terms, m.Term)
+		params.Categories = append(params.Categories, m.Category)
+		if pages != nil {
+			params.Pages = append(params.Pages, pages[m.Start])
+		}
+	}
+	if err := q.CreateTerms(ctx, params); err != nil {
+		return row, fmt.Errorf("create terms: %w", err)
+	}
+
+	return row, nil
+}
+
+// canonicalizeChunk is the number of words updated by a single query.
+const canonicalizeChunk = 1000
+
+// Canonicalize updates canonical forms of every stored word with the
+// synonyms, e.g. of words stored before the dictionary or its overrides
+// changed. It returns the number of updated words.
+func (b *Batches) Canonicalize(ctx context.Context, q Querier) (int64, error) {
+	values, err := q.ListWordValues(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list word values: %w", err)
+	}
+
+	var updated int64
+	for chunk := range slices.Chunk(values, canonicalizeChunk) {
+		n, err := q.UpdateWordCanonicals(ctx, UpdateWordCanonicalsParams{
+			Values:     chunk,
+			Canonicals: b.synonyms.CanonicalWords(chunk),
+		})
+		if err != nil {
+			return updated, fmt.Errorf("update word canonicals: %w", err)
+		}
+		updated += n
+	}
+
+	return updated, nil
+}
+
+// CreateNgrams stores bigrams and trigrams of text, see textproc.Ngrams, as
+// n-grams of words batch of batchID. Words of text are canonicalized first.
+// It returns the number of stored n-grams.
+func (b *Batches) CreateNgrams(ctx context.Context, q Querier, batchID int64, text string) (int, error) {
+	segments := textproc.Segments(text)
+	for i, words := range segments {
+		segments[i] = b.synonyms.CanonicalWords(words)
+	}
+	ngrams := textproc.Ngrams(segments, textproc.NgramOptions{})
+	if len(ngrams) == 0 {
+		return 0, nil
+	}
+
+	params := CreateNgramsParams{BatchID: batchID}
+	for _, ng := range ngrams {
+		params.Ngrams = append(params.Ngrams, ng.Text)
+		params.Ns = append(params.Ns, int32(ng.N))
+		params.Totals = append(params.Totals, int32(ng.Count))
+		params.Pmis = append(params.Pmis, float32(ng.PMI))
+		params.Llrs = append(params.Llrs, float32(ng.LLR))
+	}
+	if err := q.CreateNgrams(ctx, params); err != nil {
+		return 0, fmt.Errorf("create ngrams: %w", err)
+	}
+
+	return len(ngrams), nil
+}
+
+// CreatePhrasesBatch stores phrases of arg as a named batch, tagged with
+// categories of terms of the taxonomy found in them. Tags of arg are
+// ignored. Names of phrases batches are unique, ErrBatchExists is returned
+// for a name which is taken.
+func (b *Batches) CreatePhrasesBatch(ctx context.Context, q Querier, arg CreatePhrasesBatchParams) (CreatePhrasesBatchRow, error) {
+	arg.Column6 = b.tags(arg.Column2)
+
+	row, err := q.CreatePhrasesBatch(ctx, arg)
+	if pgErr := (*pgconn.PgError)(nil); errors.As(err, &pgErr) && pgErr.ConstraintName == "phrase_batches_name_unique" {
+		return row, fmt.Errorf("create phrases batch %q: %w", arg.Name, ErrBatchExists)
+	}
+	if err != nil {
+		return row, fmt.Errorf("create phrases batch: %w", err)
+	}
+
+	return row, nil
+}
+
+// tags returns categories of terms found in values, joined with commas.
+func (b *Batches) tags(values []string) []string {
+	tags := make([]string, len(values))
+	for i, v := range values {
+		tags[i] = strings.Join(b.matcher.Categories(v), ",")
+	}
+
+	return tags
+}
@@ -6,6 +6,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -18,7 +19,10 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kndrad/piccrack/config"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/textproc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -119,8 +123,9 @@ func TestDatabaseQueries(t *testing.T) {
 		defer conn.Close(ctx)
 
 		q := New(conn)
+		synonyms := textproc.DefaultSynonyms()
 		for _, w := range testWords(t) {
-			row, err := q.CreateWord(ctx, w)
+			row, err := q.CreateWord(ctx, CreateWordParams{Value: w, Canonical: synonyms.Canonical(w)})
 			require.NoError(t, err)
 			assert.Equal(t, w, row.Value)
 		}
@@ -144,7 +149,7 @@ func TestDatabaseQueries(t *testing.T) {
 		defer conn.Close(ctx)
 
 		q := New(conn)
-		row, err := q.CreateWord(ctx, "test1")
+		row, err := q.CreateWord(ctx, CreateWordParams{Value: "test1", Canonical: "test1"})
 		require.NoError(t, err)
 		require.Equal(t, "test1", row.Value)
 	})
@@ -195,6 +200,150 @@ func TestDatabaseQueries(t *testing.T) {
 		}
 	})
 
+	t.Run("word_frequencies_and_rankings_aggregate_by_canonical_term", func(t *testing.T) {
+		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
+		require.NoError(t, err)
+		defer conn.Close(ctx)
+
+		q := New(conn)
+		frequency := func() int64 {
+			rows, err := q.ListWordFrequencies(ctx, ListWordFrequenciesParams{Limit: math.MaxInt32})
+			require.NoError(t, err)
+			for _, row := range rows {
+				require.NotEqual(t, "k8s", row.Value)
+				if row.Value == "kubernetes" {
+					return row.Total
+				}
+			}
+
+			return 0
+		}
+		before := frequency()
+
+		words := []string{"k8s", "kube", "kubernetes"}
+		_, err = q.CreateWordsBatch(ctx, CreateWordsBatchParams{
+			Name:    "test_canonical_batch",
+			Column2: words,
+			Column3: []int32{1, 1, 1},
+			Column4: textproc.DefaultSynonyms().CanonicalWords(words),
+		})
+		require.NoError(t, err)
+		require.Equal(t, before+3, frequency())
+
+		rows, err := q.ListWords(ctx, ListWordsParams{Limit: math.MaxInt32})
+		require.NoError(t, err)
+		for _, row := range rows {
+			if row.Value == "k8s" {
+				require.Equal(t, "kubernetes", row.Canonical)
+			}
+		}
+
+		rankings, err := q.ListWordRankings(ctx, ListWordRankingsParams{Limit: math.MaxInt32})
+		require.NoError(t, err)
+		for _, row := range rankings {
+			require.NotEqual(t, "kube", row.Value)
+		}
+	})
+
+	t.Run("terms_are_counted_per_category_and_batch", func(t *testing.T) {
+		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
+		require.NoError(t, err)
+		defer conn.Close(ctx)
+
+		q := New(conn)
+		words := textproc.Words("Kafka, RabbitMQ and Prometheus on AWS")
+		pages := make([]int32, len(words))
+		for i := range pages {
+			pages[i] = 1
+		}
+		row, err := q.CreateWordsBatch(ctx, CreateWordsBatchParams{
+			Name:    "test_terms_batch",
+			Column2: words,
+			Column3: pages,
+			Column4: words,
+		})
+		require.NoError(t, err)
+
+		params := CreateTermsParams{BatchID: row.BatchID.Int64}
+		for _, m := range textproc.NewMatcher(textproc.DefaultTaxonomy(), textproc.DefaultSynonyms()).Match(words) {
+			params.Terms = append(params.Terms, m.Term)
+			params.Categories = append(params.Categories, m.Category)
+		}
+		require.NoError(t, q.CreateTerms(ctx, params))
+
+		categories, err := q.CountTermsByCategory(ctx, CountTermsByCategoryParams{RowLimit: DefaultQueryLimit})
+		require.NoError(t, err)
+		require.Equal(t, []CountTermsByCategoryRow{
+			{Category: "messaging", Total: 2, Terms: 2},
+			{Category: "cloud", Total: 1, Terms: 1},
+			{Category: "observability", Total: 1, Terms: 1},
+		}, categories)
+
+		batches, err := q.CountTermsByBatch(ctx, CountTermsByBatchParams{
+			BatchName: pgtype.Text{String: "test_terms_batch", Valid: true},
+			RowLimit:  DefaultQueryLimit,
+		})
+		require.NoError(t, err)
+		require.Len(t, batches, 3)
+		require.Equal(t, "test_terms_batch", batches[0].BatchName)
+
+		terms, err := q.ListTermFrequencies(ctx, ListTermFrequenciesParams{
+			Category: pgtype.Text{String: "messaging", Valid: true},
+			RowLimit: DefaultQueryLimit,
+		})
+		require.NoError(t, err)
+		require.Len(t, terms, 2)
+	})
+
+	t.Run("ngrams_are_listed_per_batch", func(t *testing.T) {
+		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
+		require.NoError(t, err)
+		defer conn.Close(ctx)
+
+		q := New(conn)
+		text := "Distributed systems. Infrastructure as code, distributed systems."
+		words := textproc.Words(text)
+		pages := make([]int32, len(words))
+		for i := range pages {
+			pages[i] = 1
+		}
+		row, err := q.CreateWordsBatch(ctx, CreateWordsBatchParams{
+			Name:    "test_ngrams_batch",
+			Column2: words,
+			Column3: pages,
+			Column4: words,
+		})
+		require.NoError(t, err)
+
+		params := CreateNgramsParams{BatchID: row.BatchID.Int64}
+		for _, ng := range textproc.Ngrams(textproc.Segments(text), textproc.NgramOptions{}) {
+			params.Ngrams = append(params.Ngrams, ng.Text)
+			params.Ns = append(params.Ns, int32(ng.N))
+			params.Totals = append(params.Totals, int32(ng.Count))
+			params.Pmis = append(params.Pmis, float32(ng.PMI))
+			params.Llrs = append(params.Llrs, float32(ng.LLR))
+		}
+		require.NoError(t, q.CreateNgrams(ctx, params))
+
+		ngrams, err := q.ListNgrams(ctx, ListNgramsParams{
+			BatchName: pgtype.Text{String: "test_ngrams_batch", Valid: true},
+			MinTotal:  2,
+			RowLimit:  DefaultQueryLimit,
+		})
+		require.NoError(t, err)
+		require.Len(t, ngrams, 1)
+		require.Equal(t, "distributed systems", ngrams[0].Value)
+		require.Equal(t, int32(2), ngrams[0].Total)
+
+		trigrams, err := q.ListNgramFrequencies(ctx, ListNgramFrequenciesParams{
+			N:        pgtype.Int4{Int32: 3, Valid: true},
+			RowLimit: DefaultQueryLimit,
+		})
+		require.NoError(t, err)
+		require.Len(t, trigrams, 1)
+		require.Equal(t, "infrastructure as code", trigrams[0].Value)
+	})
+
 	t.Run("list_word_batches", func(t *testing.T) {
 		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
 		require.NoError(t, err)
@@ -227,6 +376,121 @@ func TestDatabaseQueries(t *testing.T) {
 		}
 	})
 
+	t.Run("list_phrases_filters_by_category_and_confidence", func(t *testing.T) {
+		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
+		require.NoError(t, err)
+		defer conn.Close(ctx)
+
+		q := New(conn)
+		_, err = q.CreatePhrasesBatch(ctx, CreatePhrasesBatchParams{
+			Name:    "test_phrases_batch",
+			Column2: []string{"3+ years of go", "docker is a plus", "multisport card"},
+			Column3: []int32{1, 1, 2},
+			Column4: []string{"requirement", "nice_to_have", "benefit"},
+			Column5: []float32{1, 0.9, 0.5},
+		})
+		require.NoError(t, err)
+
+		rows, err := q.ListPhrases(ctx, ListPhrasesParams{
+			Category: pgtype.Text{String: "requirement", Valid: true},
+			RowLimit: DefaultQueryLimit,
+		})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		require.Equal(t, "3+ years of go", rows[0].Value)
+		require.Equal(t, "test_phrases_batch", rows[0].BatchName)
+
+		rows, err = q.ListPhrases(ctx, ListPhrasesParams{MinConfidence: 0.8, RowLimit: DefaultQueryLimit})
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+	})
+
+	t.Run("canonicalize_applies_synonyms_to_stored_words", func(t *testing.T) {
+		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
+		require.NoError(t, err)
+		defer conn.Close(ctx)
+
+		q := New(conn)
+		// Stored before the words were canonicalized
+		for _, w := range []string{"K8S", "kube", "Golang"} {
+			_, err := q.CreateWord(ctx, CreateWordParams{Value: w, Canonical: w})
+			require.NoError(t, err)
+		}
+
+		batches := NewBatches(textproc.DefaultSynonyms(), textproc.DefaultTaxonomy())
+		updated, err := batches.Canonicalize(ctx, q)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, updated, int64(3))
+
+		rows, err := q.ListWordFrequencies(ctx, ListWordFrequenciesParams{Limit: DefaultQueryLimit})
+		require.NoError(t, err)
+		for _, row := range rows {
+			require.NotContains(t, []string{"K8S", "kube", "Golang"}, row.Value)
+		}
+
+		// Nothing changes on the next run
+		updated, err = batches.Canonicalize(ctx, q)
+		require.NoError(t, err)
+		require.Zero(t, updated)
+	})
+
+	t.Run("in_tx_commits_or_rolls_back_batches", func(t *testing.T) {
+		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
+		require.NoError(t, err)
+		defer conn.Close(ctx)
+
+		q := New(conn)
+		batches := NewBatches(textproc.DefaultSynonyms(), textproc.DefaultTaxonomy())
+		store := func(name string, fail error) error {
+			return q.InTx(ctx, func(q Querier) error {
+				row, err := batches.CreateWordsBatch(ctx, q, name, []string{"golang", "developer"}, nil)
+				if err != nil {
+					return err
+				}
+				if _, err := batches.CreateNgrams(ctx, q, row.BatchID.Int64, "golang developer"); err != nil {
+					return err
+				}
+
+				return fail
+			})
+		}
+
+		errFail := errors.New("store: failed")
+		require.ErrorIs(t, store("test_tx_rolled_back", errFail), errFail)
+		rows, err := q.ListWordsByBatchName(ctx, "test_tx_rolled_back")
+		require.NoError(t, err)
+		require.Empty(t, rows)
+
+		require.NoError(t, store("test_tx_committed", nil))
+		rows, err = q.ListWordsByBatchName(ctx, "test_tx_committed")
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+	})
+
+	t.Run("ocr_cache_returns_stored_recognitions_and_evicts_above_max_size", func(t *testing.T) {
+		conn, err := pgx.Connect(ctx, fx.ContainerConnStr(t))
+		require.NoError(t, err)
+		defer conn.Close(ctx)
+
+		cache := NewOCRCache(New(conn), ocr.CacheOptions{MaxSize: 250})
+
+		_, err = cache.Get(ctx, "missing")
+		require.ErrorIs(t, err, ocr.ErrCacheMiss)
+
+		rec := &ocr.Recognition{Text: "golang developer"}
+		require.NoError(t, cache.Set(ctx, "a", rec))
+		got, err := cache.Get(ctx, "a")
+		require.NoError(t, err)
+		require.Equal(t, rec.Text, got.Text)
+
+		// Entries don't fit together, least recently used one is evicted
+		require.NoError(t, cache.Set(ctx, "b", &ocr.Recognition{Text: strings.Repeat("x", 150)}))
+		_, err = cache.Get(ctx, "a")
+		require.ErrorIs(t, err, ocr.ErrCacheMiss)
+		_, err = cache.Get(ctx, "b")
+		require.NoError(t, err)
+	})
+
 	fx.RunCleanup(t)
 }
 
@@ -8,14 +8,38 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Phrase struct {
+type Ngram struct {
 	ID        int64              `json:"id"`
 	Value     string             `json:"value"`
-	BatchID   pgtype.Int8        `json:"batch_id"`
+	N         int32              `json:"n"`
+	Total     int32              `json:"total"`
+	Pmi       float32            `json:"pmi"`
+	Llr       float32            `json:"llr"`
+	BatchID   int64              `json:"batch_id"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
 }
 
+type OcrCache struct {
+	Key         string             `json:"key"`
+	Recognition []byte             `json:"recognition"`
+	Size        int32              `json:"size"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	AccessedAt  pgtype.Timestamptz `json:"accessed_at"`
+}
+
+type Phrase struct {
+	ID         int64              `json:"id"`
+	Value      string             `json:"value"`
+	BatchID    pgtype.Int8        `json:"batch_id"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	DeletedAt  pgtype.Timestamptz `json:"deleted_at"`
+	Page       pgtype.Int4        `json:"page"`
+	Category   string             `json:"category"`
+	Confidence float32            `json:"confidence"`
+	Tags       []string           `json:"tags"`
+}
+
 type PhraseBatch struct {
 	ID        int64              `json:"id"`
 	Name      string             `json:"name"`
@@ -23,12 +47,24 @@ type PhraseBatch struct {
 	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
 }
 
+type Term struct {
+	ID        int64              `json:"id"`
+	Value     string             `json:"value"`
+	Category  string             `json:"category"`
+	BatchID   int64              `json:"batch_id"`
+	Page      pgtype.Int4        `json:"page"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+}
+
 type Word struct {
 	ID        int64              `json:"id"`
 	Value     string             `json:"value"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
 	BatchID   pgtype.Int8        `json:"batch_id"`
+	Page      pgtype.Int4        `json:"page"`
+	Canonical string             `json:"canonical"`
 }
 
 type WordBatch struct {
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kndrad/piccrack/pkg/ocr"
+)
+
+// OCRCache is an ocr.Cache keeping recognitions in the ocr_cache table,
+// so they're shared by every API instance. Use it with a connection pool,
+// it's used concurrently.
+type OCRCache struct {
+	q    Querier
+	opts ocr.CacheOptions
+}
+
+var _ ocr.Cache = (*OCRCache)(nil)
+
+func NewOCRCache(q Querier, opts ocr.CacheOptions) *OCRCache {
+	if q == nil {
+		panic("querier cannot be nil")
+	}
+
+	return &OCRCache{q: q, opts: opts}
+}
+
+// createdAfter returns creation time of the oldest valid entries.
+func (c *OCRCache) createdAfter() pgtype.Timestamptz {
+	if c.opts.TTL <= 0 {
+		return pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+	}
+
+	return pgtype.Timestamptz{Time: time.Now().Add(-c.opts.TTL), Valid: true}
+}
+
+func (c *OCRCache) Get(ctx context.Context, key string) (*ocr.Recognition, error) {
+	data, err := c.q.GetOCRCacheEntry(ctx, GetOCRCacheEntryParams{
+		Key:       key,
+		CreatedAt: c.createdAfter(),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ocr.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get ocr cache entry: %w", err)
+	}
+
+	rec := new(ocr.Recognition)
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	return rec, nil
+}
+
+// Set stores rec and evicts least recently used entries above MaxSize.
+func (c *OCRCache) Set(ctx context.Context, key string, rec *ocr.Recognition) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if len(data) > math.MaxInt32 {
+		return fmt.Errorf("recognition of %d bytes too big", len(data))
+	}
+
+	if err := c.q.SetOCRCacheEntry(ctx, SetOCRCacheEntryParams{
+		Key:         key,
+		Recognition: data,
+		Size:        int32(len(data)),
+	}); err != nil {
+		return fmt.Errorf("set ocr cache entry: %w", err)
+	}
+	if c.opts.MaxSize > 0 {
+		if _, err := c.q.EvictOCRCacheEntries(ctx, c.opts.MaxSize); err != nil {
+			return fmt.Errorf("evict ocr cache entries: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Prune deletes expired entries and evicts least recently used ones until
+// entries fit in MaxSize.
+func (c *OCRCache) Prune(ctx context.Context) error {
+	if c.opts.TTL > 0 {
+		if _, err := c.q.DeleteExpiredOCRCacheEntries(ctx, c.createdAfter()); err != nil {
+			return fmt.Errorf("delete expired ocr cache entries: %w", err)
+		}
+	}
+	if c.opts.MaxSize > 0 {
+		if _, err := c.q.EvictOCRCacheEntries(ctx, c.opts.MaxSize); err != nil {
+			return fmt.Errorf("evict ocr cache entries: %w", err)
+		}
+	}
+
+	return nil
+}
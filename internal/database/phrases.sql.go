@@ -18,28 +18,117 @@ WITH batch AS (
     RETURNING id
 )
 
-INSERT INTO phrases (value, batch_id)
+INSERT INTO phrases (value, batch_id, page, category, confidence, tags)
 SELECT
-    phrase_value,
-    (SELECT id FROM batch)
-FROM UNNEST($2::text []) AS phrase_value
-RETURNING id, value, batch_id
+    phrase.value,
+    (SELECT id FROM batch),
+    phrase.page,
+    phrase.category,
+    phrase.confidence,
+    STRING_TO_ARRAY(phrase.tags, ',')
+FROM UNNEST(
+    $2::text [], $3::int [], $4::text [], $5::real [], $6::text []
+) AS phrase (value, page, category, confidence, tags)
+RETURNING id, value, batch_id, page, category, confidence, tags
 `
 
 type CreatePhrasesBatchParams struct {
-	Name    string   `json:"name"`
-	Column2 []string `json:"column_2"`
+	Name    string    `json:"name"`
+	Column2 []string  `json:"column_2"`
+	Column3 []int32   `json:"column_3"`
+	Column4 []string  `json:"column_4"`
+	Column5 []float32 `json:"column_5"`
+	Column6 []string  `json:"column_6"`
 }
 
 type CreatePhrasesBatchRow struct {
-	ID      int64       `json:"id"`
-	Value   string      `json:"value"`
-	BatchID pgtype.Int8 `json:"batch_id"`
+	ID         int64       `json:"id"`
+	Value      string      `json:"value"`
+	BatchID    pgtype.Int8 `json:"batch_id"`
+	Page       pgtype.Int4 `json:"page"`
+	Category   string      `json:"category"`
+	Confidence float32     `json:"confidence"`
+	Tags       []string    `json:"tags"`
 }
 
 func (q *Queries) CreatePhrasesBatch(ctx context.Context, arg CreatePhrasesBatchParams) (CreatePhrasesBatchRow, error) {
-	row := q.db.QueryRow(ctx, createPhrasesBatch, arg.Name, arg.Column2)
+	row := q.db.QueryRow(ctx, createPhrasesBatch, arg.Name, arg.Column2, arg.Column3, arg.Column4, arg.Column5, arg.Column6)
 	var i CreatePhrasesBatchRow
-	err := row.Scan(&i.ID, &i.Value, &i.BatchID)
+	err := row.Scan(
+		&i.ID,
+		&i.Value,
+		&i.BatchID,
+		&i.Page,
+		&i.Category,
+		&i.Confidence,
+		&i.Tags,
+	)
 	return i, err
 }
+
+const listPhrases = `-- name: ListPhrases :many
+SELECT
+    p.id,
+    p.value,
+    p.page,
+    p.category,
+    p.confidence,
+    p.tags,
+    pb.name AS batch_name
+FROM phrases AS p
+INNER JOIN phrase_batches AS pb ON p.batch_id = pb.id
+WHERE
+    p.deleted_at IS NULL
+    AND pb.deleted_at IS NULL
+    AND ($1::text IS NULL OR p.category = $1)
+    AND ($2::text IS NULL OR $2 = ANY(p.tags))
+    AND p.confidence >= $3::real
+ORDER BY p.id ASC
+LIMIT $4 OFFSET $5
+`
+
+type ListPhrasesParams struct {
+	Category      pgtype.Text `json:"category"`
+	Tag           pgtype.Text `json:"tag"`
+	MinConfidence float32     `json:"min_confidence"`
+	RowLimit      int32       `json:"row_limit"`
+	RowOffset     int32       `json:"row_offset"`
+}
+
+type ListPhrasesRow struct {
+	ID         int64       `json:"id"`
+	Value      string      `json:"value"`
+	Page       pgtype.Int4 `json:"page"`
+	Category   string      `json:"category"`
+	Confidence float32     `json:"confidence"`
+	Tags       []string    `json:"tags"`
+	BatchName  string      `json:"batch_name"`
+}
+
+func (q *Queries) ListPhrases(ctx context.Context, arg ListPhrasesParams) ([]ListPhrasesRow, error) {
+	rows, err := q.db.Query(ctx, listPhrases, arg.Category, arg.Tag, arg.MinConfidence, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPhrasesRow
+	for rows.Next() {
+		var i ListPhrasesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Value,
+			&i.Page,
+			&i.Category,
+			&i.Confidence,
+			&i.Tags,
+			&i.BatchName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
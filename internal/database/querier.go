@@ -6,17 +6,33 @@ package database
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	CountTermsByBatch(ctx context.Context, arg CountTermsByBatchParams) ([]CountTermsByBatchRow, error)
+	CountTermsByCategory(ctx context.Context, arg CountTermsByCategoryParams) ([]CountTermsByCategoryRow, error)
+	CreateNgrams(ctx context.Context, arg CreateNgramsParams) error
 	CreatePhrasesBatch(ctx context.Context, arg CreatePhrasesBatchParams) (CreatePhrasesBatchRow, error)
-	CreateWord(ctx context.Context, value string) (CreateWordRow, error)
+	CreateTerms(ctx context.Context, arg CreateTermsParams) error
+	CreateWord(ctx context.Context, arg CreateWordParams) (CreateWordRow, error)
 	CreateWordsBatch(ctx context.Context, arg CreateWordsBatchParams) (CreateWordsBatchRow, error)
+	DeleteExpiredOCRCacheEntries(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	EvictOCRCacheEntries(ctx context.Context, maxSize int64) (int64, error)
+	GetOCRCacheEntry(ctx context.Context, arg GetOCRCacheEntryParams) ([]byte, error)
+	ListNgramFrequencies(ctx context.Context, arg ListNgramFrequenciesParams) ([]ListNgramFrequenciesRow, error)
+	ListNgrams(ctx context.Context, arg ListNgramsParams) ([]ListNgramsRow, error)
+	ListPhrases(ctx context.Context, arg ListPhrasesParams) ([]ListPhrasesRow, error)
+	ListTermFrequencies(ctx context.Context, arg ListTermFrequenciesParams) ([]ListTermFrequenciesRow, error)
 	ListWordBatches(ctx context.Context, arg ListWordBatchesParams) ([]ListWordBatchesRow, error)
 	ListWordFrequencies(ctx context.Context, arg ListWordFrequenciesParams) ([]ListWordFrequenciesRow, error)
 	ListWordRankings(ctx context.Context, arg ListWordRankingsParams) ([]ListWordRankingsRow, error)
+	ListWordValues(ctx context.Context) ([]string, error)
 	ListWords(ctx context.Context, arg ListWordsParams) ([]ListWordsRow, error)
 	ListWordsByBatchName(ctx context.Context, name string) ([]ListWordsByBatchNameRow, error)
+	SetOCRCacheEntry(ctx context.Context, arg SetOCRCacheEntryParams) error
+	UpdateWordCanonicals(ctx context.Context, arg UpdateWordCanonicalsParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
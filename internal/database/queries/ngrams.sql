@@ -0,0 +1,52 @@
+-- name: CreateNgrams :exec
+INSERT INTO ngrams (value, n, total, pmi, llr, batch_id)
+SELECT
+    ngram.value,
+    ngram.n,
+    ngram.total,
+    ngram.pmi,
+    ngram.llr,
+    sqlc.arg(batch_id)::bigint
+FROM UNNEST(
+    sqlc.arg(ngrams)::text [],
+    sqlc.arg(ns)::int [],
+    sqlc.arg(totals)::int [],
+    sqlc.arg(pmis)::real [],
+    sqlc.arg(llrs)::real []
+) AS ngram (value, n, total, pmi, llr);
+
+-- name: ListNgrams :many
+SELECT
+    ng.id,
+    ng.value,
+    ng.n,
+    ng.total,
+    ng.pmi,
+    ng.llr,
+    wb.name AS batch_name
+FROM ngrams AS ng
+INNER JOIN word_batches AS wb ON ng.batch_id = wb.id
+WHERE
+    ng.deleted_at IS NULL
+    AND wb.deleted_at IS NULL
+    AND (sqlc.narg(batch_name)::text IS NULL OR wb.name = sqlc.narg(batch_name))
+    AND (sqlc.narg(n)::int IS NULL OR ng.n = sqlc.narg(n))
+    AND ng.total >= sqlc.arg(min_total)::int
+ORDER BY ng.llr DESC, ng.id ASC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: ListNgramFrequencies :many
+SELECT
+    ng.value,
+    ng.n,
+    SUM(ng.total)::bigint AS total,
+    COUNT(DISTINCT ng.batch_id) AS batches
+FROM ngrams AS ng
+INNER JOIN word_batches AS wb ON ng.batch_id = wb.id
+WHERE
+    ng.deleted_at IS NULL
+    AND wb.deleted_at IS NULL
+    AND (sqlc.narg(n)::int IS NULL OR ng.n = sqlc.narg(n))
+GROUP BY ng.value, ng.n
+ORDER BY total DESC, ng.value ASC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
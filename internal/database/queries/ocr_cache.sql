@@ -0,0 +1,32 @@
+-- name: GetOCRCacheEntry :one
+UPDATE ocr_cache
+SET accessed_at = CURRENT_TIMESTAMP
+WHERE key = $1 AND created_at > $2
+RETURNING recognition;
+
+-- name: SetOCRCacheEntry :exec
+INSERT INTO ocr_cache (key, recognition, size)
+VALUES ($1, $2, $3)
+ON CONFLICT (key) DO UPDATE
+SET
+    recognition = excluded.recognition,
+    size = excluded.size,
+    created_at = CURRENT_TIMESTAMP,
+    accessed_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteExpiredOCRCacheEntries :execrows
+DELETE FROM ocr_cache
+WHERE created_at <= $1;
+
+-- name: EvictOCRCacheEntries :execrows
+DELETE FROM ocr_cache
+WHERE key IN (
+    SELECT ranked.key
+    FROM (
+        SELECT
+            c.key,
+            SUM(c.size) OVER (ORDER BY c.accessed_at DESC, c.key) AS total
+        FROM ocr_cache AS c
+    ) AS ranked
+    WHERE ranked.total > sqlc.arg(max_size)::bigint
+);
@@ -5,9 +5,35 @@ WITH batch AS (
     RETURNING id
 )
 
-INSERT INTO phrases (value, batch_id)
+INSERT INTO phrases (value, batch_id, page, category, confidence, tags)
 SELECT
-    phrase_value,
-    (SELECT id FROM batch)
-FROM UNNEST($2::text []) AS phrase_value
-RETURNING id, value, batch_id;
+    phrase.value,
+    (SELECT id FROM batch),
+    phrase.page,
+    phrase.category,
+    phrase.confidence,
+    STRING_TO_ARRAY(phrase.tags, ',')
+FROM UNNEST(
+    $2::text [], $3::int [], $4::text [], $5::real [], $6::text []
+) AS phrase (value, page, category, confidence, tags)
+RETURNING id, value, batch_id, page, category, confidence, tags;
+
+-- name: ListPhrases :many
+SELECT
+    p.id,
+    p.value,
+    p.page,
+    p.category,
+    p.confidence,
+    p.tags,
+    pb.name AS batch_name
+FROM phrases AS p
+INNER JOIN phrase_batches AS pb ON p.batch_id = pb.id
+WHERE
+    p.deleted_at IS NULL
+    AND pb.deleted_at IS NULL
+    AND (sqlc.narg(category)::text IS NULL OR p.category = sqlc.narg(category))
+    AND (sqlc.narg(tag)::text IS NULL OR sqlc.narg(tag) = ANY(p.tags))
+    AND p.confidence >= sqlc.arg(min_confidence)::real
+ORDER BY p.id ASC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
@@ -0,0 +1,52 @@
+-- name: CreateTerms :exec
+INSERT INTO terms (value, category, batch_id, page)
+SELECT
+    term.value,
+    term.category,
+    sqlc.arg(batch_id)::bigint,
+    term.page
+FROM UNNEST(
+    sqlc.arg(terms)::text [], sqlc.arg(categories)::text [], sqlc.arg(pages)::int []
+) AS term (value, category, page);
+
+-- name: CountTermsByCategory :many
+SELECT
+    t.category,
+    COUNT(*) AS total,
+    COUNT(DISTINCT t.value) AS terms
+FROM terms AS t
+INNER JOIN word_batches AS wb ON t.batch_id = wb.id
+WHERE t.deleted_at IS NULL AND wb.deleted_at IS NULL
+GROUP BY t.category
+ORDER BY total DESC, t.category ASC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountTermsByBatch :many
+SELECT
+    wb.name AS batch_name,
+    t.category,
+    COUNT(*) AS total
+FROM terms AS t
+INNER JOIN word_batches AS wb ON t.batch_id = wb.id
+WHERE
+    t.deleted_at IS NULL
+    AND wb.deleted_at IS NULL
+    AND (sqlc.narg(batch_name)::text IS NULL OR wb.name = sqlc.narg(batch_name))
+GROUP BY wb.id, wb.name, t.category
+ORDER BY wb.id ASC, total DESC, t.category ASC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: ListTermFrequencies :many
+SELECT
+    t.value,
+    t.category,
+    COUNT(*) AS total
+FROM terms AS t
+INNER JOIN word_batches AS wb ON t.batch_id = wb.id
+WHERE
+    t.deleted_at IS NULL
+    AND wb.deleted_at IS NULL
+    AND (sqlc.narg(category)::text IS NULL OR t.category = sqlc.narg(category))
+GROUP BY t.value, t.category
+ORDER BY total DESC, t.value ASC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
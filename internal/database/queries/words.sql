@@ -2,6 +2,7 @@
 SELECT
     id,
     value,
+    canonical,
     created_at
 FROM words
 WHERE deleted_at IS NULL
@@ -9,27 +10,27 @@ ORDER BY value ASC
 LIMIT $1 OFFSET $2;
 
 -- name: CreateWord :one
-INSERT INTO words (value, created_at)
-VALUES ($1, CURRENT_TIMESTAMP)
-RETURNING id, value, created_at;
+INSERT INTO words (value, canonical, created_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+RETURNING id, value, canonical, created_at;
 
 -- name: ListWordFrequencies :many
 SELECT
-    words.value,
+    words.canonical AS value,
     COUNT(*) AS total
 FROM words
 WHERE words.deleted_at IS NULL
-GROUP BY words.value
+GROUP BY words.canonical
 ORDER BY total ASC
 LIMIT $1 OFFSET $2;
 
 -- name: ListWordRankings :many
 SELECT
-    words.value,
+    words.canonical AS value,
     ROW_NUMBER() OVER (ORDER BY COUNT(*) DESC) AS ranking
 FROM words
 WHERE words.deleted_at IS NULL
-GROUP BY words.value
+GROUP BY words.canonical
 ORDER BY ranking ASC
 LIMIT $1 OFFSET $2;
 
@@ -50,18 +51,35 @@ WITH new_batch AS (
     RETURNING id
 )
 
-INSERT INTO words (value, batch_id)
+INSERT INTO words (value, batch_id, page, canonical)
 SELECT
-    word_value,
-    (SELECT id FROM new_batch)
-FROM UNNEST($2::text []) AS word_value
-RETURNING id, value, batch_id;
+    word.value,
+    (SELECT id FROM new_batch),
+    word.page,
+    word.canonical
+FROM UNNEST($2::text [], $3::int [], $4::text []) AS word (value, page, canonical)
+RETURNING id, value, batch_id, page, canonical;
 
 -- name: ListWordsByBatchName :many
 SELECT
     wb.name AS batch_name,
-    w.value AS word_value
+    w.value AS word_value,
+    w.page AS word_page
 FROM word_batches AS wb
-INNER JOIN words AS w ON wb.id = w.id
+INNER JOIN words AS w ON wb.id = w.batch_id
 WHERE wb.name = $1 AND wb.deleted_at IS NULL
 ORDER BY wb.created_at DESC;
+
+-- name: ListWordValues :many
+SELECT DISTINCT value
+FROM words
+WHERE deleted_at IS NULL
+ORDER BY value ASC;
+
+-- name: UpdateWordCanonicals :execrows
+UPDATE words
+SET canonical = word.canonical
+FROM UNNEST(
+    sqlc.arg(values)::text [], sqlc.arg(canonicals)::text []
+) AS word (value, canonical)
+WHERE words.value = word.value AND words.canonical <> word.canonical;
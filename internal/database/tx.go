@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TxQuerier is a Querier which runs queries in transactions too, e.g. to
+// store a batch with everything derived from it at once.
+type TxQuerier interface {
+	Querier
+
+	// InTx runs fn with queries of a transaction, which is committed if fn
+	// succeeds and rolled back otherwise. Queries used concurrently must be
+	// made with a pool, a single connection runs one transaction at a time.
+	InTx(ctx context.Context, fn func(q Querier) error) error
+}
+
+var _ TxQuerier = (*Queries)(nil)
+
+// beginner begins transactions, e.g. a pool, a connection or a transaction,
+// which begins a nested one with a savepoint.
+type beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+func (q *Queries) InTx(ctx context.Context, fn func(q Querier) error) error {
+	db, ok := q.db.(beginner)
+	if !ok {
+		return errors.New("db cannot begin transactions")
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		// Rollback of a committed transaction does nothing
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(q.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
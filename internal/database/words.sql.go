@@ -12,21 +12,32 @@ import (
 )
 
 const createWord = `-- name: CreateWord :one
-INSERT INTO words (value, created_at)
-VALUES ($1, CURRENT_TIMESTAMP)
-RETURNING id, value, created_at
+INSERT INTO words (value, canonical, created_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+RETURNING id, value, canonical, created_at
 `
 
+type CreateWordParams struct {
+	Value     string `json:"value"`
+	Canonical string `json:"canonical"`
+}
+
 type CreateWordRow struct {
 	ID        int64              `json:"id"`
 	Value     string             `json:"value"`
+	Canonical string             `json:"canonical"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
-func (q *Queries) CreateWord(ctx context.Context, value string) (CreateWordRow, error) {
-	row := q.db.QueryRow(ctx, createWord, value)
+func (q *Queries) CreateWord(ctx context.Context, arg CreateWordParams) (CreateWordRow, error) {
+	row := q.db.QueryRow(ctx, createWord, arg.Value, arg.Canonical)
 	var i CreateWordRow
-	err := row.Scan(&i.ID, &i.Value, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.Value,
+		&i.Canonical,
+		&i.CreatedAt,
+	)
 	return i, err
 }
 
@@ -37,29 +48,41 @@ WITH new_batch AS (
     RETURNING id
 )
 
-INSERT INTO words (value, batch_id)
+INSERT INTO words (value, batch_id, page, canonical)
 SELECT
-    word_value,
-    (SELECT id FROM new_batch)
-FROM UNNEST($2::text []) AS word_value
-RETURNING id, value, batch_id
+    word.value,
+    (SELECT id FROM new_batch),
+    word.page,
+    word.canonical
+FROM UNNEST($2::text [], $3::int [], $4::text []) AS word (value, page, canonical)
+RETURNING id, value, batch_id, page, canonical
 `
 
 type CreateWordsBatchParams struct {
 	Name    string   `json:"name"`
 	Column2 []string `json:"column_2"`
+	Column3 []int32  `json:"column_3"`
+	Column4 []string `json:"column_4"`
 }
 
 type CreateWordsBatchRow struct {
-	ID      int64       `json:"id"`
-	Value   string      `json:"value"`
-	BatchID pgtype.Int8 `json:"batch_id"`
+	ID        int64       `json:"id"`
+	Value     string      `json:"value"`
+	BatchID   pgtype.Int8 `json:"batch_id"`
+	Page      pgtype.Int4 `json:"page"`
+	Canonical string      `json:"canonical"`
 }
 
 func (q *Queries) CreateWordsBatch(ctx context.Context, arg CreateWordsBatchParams) (CreateWordsBatchRow, error) {
-	row := q.db.QueryRow(ctx, createWordsBatch, arg.Name, arg.Column2)
+	row := q.db.QueryRow(ctx, createWordsBatch, arg.Name, arg.Column2, arg.Column3, arg.Column4)
 	var i CreateWordsBatchRow
-	err := row.Scan(&i.ID, &i.Value, &i.BatchID)
+	err := row.Scan(
+		&i.ID,
+		&i.Value,
+		&i.BatchID,
+		&i.Page,
+		&i.Canonical,
+	)
 	return i, err
 }
 
@@ -107,11 +130,11 @@ func (q *Queries) ListWordBatches(ctx context.Context, arg ListWordBatchesParams
 
 const listWordFrequencies = `-- name: ListWordFrequencies :many
 SELECT
-    words.value,
+    words.canonical AS value,
     COUNT(*) AS total
 FROM words
 WHERE words.deleted_at IS NULL
-GROUP BY words.value
+GROUP BY words.canonical
 ORDER BY total ASC
 LIMIT $1 OFFSET $2
 `
@@ -148,11 +171,11 @@ func (q *Queries) ListWordFrequencies(ctx context.Context, arg ListWordFrequenci
 
 const listWordRankings = `-- name: ListWordRankings :many
 SELECT
-    words.value,
+    words.canonical AS value,
     ROW_NUMBER() OVER (ORDER BY COUNT(*) DESC) AS ranking
 FROM words
 WHERE words.deleted_at IS NULL
-GROUP BY words.value
+GROUP BY words.canonical
 ORDER BY ranking ASC
 LIMIT $1 OFFSET $2
 `
@@ -187,10 +210,38 @@ func (q *Queries) ListWordRankings(ctx context.Context, arg ListWordRankingsPara
 	return items, nil
 }
 
+const listWordValues = `-- name: ListWordValues :many
+SELECT DISTINCT value
+FROM words
+WHERE deleted_at IS NULL
+ORDER BY value ASC
+`
+
+func (q *Queries) ListWordValues(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listWordValues)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listWords = `-- name: ListWords :many
 SELECT
     id,
     value,
+    canonical,
     created_at
 FROM words
 WHERE deleted_at IS NULL
@@ -206,6 +257,7 @@ type ListWordsParams struct {
 type ListWordsRow struct {
 	ID        int64              `json:"id"`
 	Value     string             `json:"value"`
+	Canonical string             `json:"canonical"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
@@ -218,7 +270,12 @@ func (q *Queries) ListWords(ctx context.Context, arg ListWordsParams) ([]ListWor
 	var items []ListWordsRow
 	for rows.Next() {
 		var i ListWordsRow
-		if err := rows.Scan(&i.ID, &i.Value, &i.CreatedAt); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Value,
+			&i.Canonical,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -232,16 +289,18 @@ func (q *Queries) ListWords(ctx context.Context, arg ListWordsParams) ([]ListWor
 const listWordsByBatchName = `-- name: ListWordsByBatchName :many
 SELECT
     wb.name AS batch_name,
-    w.value AS word_value
+    w.value AS word_value,
+    w.page AS word_page
 FROM word_batches AS wb
-INNER JOIN words AS w ON wb.id = w.id
+INNER JOIN words AS w ON wb.id = w.batch_id
 WHERE wb.name = $1 AND wb.deleted_at IS NULL
 ORDER BY wb.created_at DESC
 `
 
 type ListWordsByBatchNameRow struct {
-	BatchName string `json:"batch_name"`
-	WordValue string `json:"word_value"`
+	BatchName string      `json:"batch_name"`
+	WordValue string      `json:"word_value"`
+	WordPage  pgtype.Int4 `json:"word_page"`
 }
 
 func (q *Queries) ListWordsByBatchName(ctx context.Context, name string) ([]ListWordsByBatchNameRow, error) {
@@ -253,7 +312,7 @@ func (q *Queries) ListWordsByBatchName(ctx context.Context, name string) ([]List
 	var items []ListWordsByBatchNameRow
 	for rows.Next() {
 		var i ListWordsByBatchNameRow
-		if err := rows.Scan(&i.BatchName, &i.WordValue); err != nil {
+		if err := rows.Scan(&i.BatchName, &i.WordValue, &i.WordPage); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -263,3 +322,25 @@ func (q *Queries) ListWordsByBatchName(ctx context.Context, name string) ([]List
 	}
 	return items, nil
 }
+
+const updateWordCanonicals = `-- name: UpdateWordCanonicals :execrows
+UPDATE words
+SET canonical = word.canonical
+FROM UNNEST(
+    $1::text [], $2::text []
+) AS word (value, canonical)
+WHERE words.value = word.value AND words.canonical <> word.canonical
+`
+
+type UpdateWordCanonicalsParams struct {
+	Values     []string `json:"values"`
+	Canonicals []string `json:"canonicals"`
+}
+
+func (q *Queries) UpdateWordCanonicals(ctx context.Context, arg UpdateWordCanonicalsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateWordCanonicals, arg.Values, arg.Canonicals)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,254 @@
+// Package dirwatch reports files created or written in a directory tree once
+// writing them is over.
+package dirwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kndrad/piccrack/pkg/pproc"
+)
+
+// DefaultDebounce is the time a file must stay unchanged before it's reported,
+// long enough for screenshot tools to finish writing it.
+const DefaultDebounce = 500 * time.Millisecond
+
+// Event is a file which was created or written, or an error of watching.
+type Event struct {
+	Path string
+	Err  error
+}
+
+// Watch watches dir and its subdirectories allowed by opts, including ones
+// created later, and sends paths of files allowed by opts once they weren't
+// written for debounce. Contents of files aren't checked, see
+// pproc.WalkOptions.ReadFile.
+//
+// Files existing before Watch was called aren't reported, except ones in
+// directories created while watching. The channel is closed once ctx is
+// done.
+func Watch(ctx context.Context, dir string, opts pproc.WalkOptions, debounce time.Duration) (<-chan Event, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("walk options: %w", err)
+	}
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	dir = filepath.Clean(dir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, errors.New("path must be dir")
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("new watcher: %w", err)
+	}
+	d := &watcher{
+		dir:      dir,
+		opts:     opts,
+		debounce: debounce,
+		w:        w,
+		pending:  make(map[string]time.Time),
+		out:      make(chan Event),
+	}
+	if _, err := d.add(dir); err != nil {
+		w.Close()
+
+		return nil, err
+	}
+
+	go d.run(ctx)
+
+	return d.out, nil
+}
+
+type watcher struct {
+	dir      string
+	opts     pproc.WalkOptions
+	debounce time.Duration
+
+	w *fsnotify.Watcher
+
+	// pending maps paths of written files to time they're due to be sent
+	pending map[string]time.Time
+	timer   *time.Timer
+
+	out chan Event
+}
+
+func (d *watcher) run(ctx context.Context) {
+	defer close(d.out)
+	defer d.w.Close()
+
+	d.timer = time.NewTimer(d.debounce)
+	d.timer.Stop()
+	defer d.timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-d.w.Events:
+			if !ok {
+				return
+			}
+			if err := d.handle(event); err != nil && !d.send(ctx, Event{Err: err}) {
+				return
+			}
+		case err, ok := <-d.w.Errors:
+			if !ok {
+				return
+			}
+			if !d.send(ctx, Event{Err: fmt.Errorf("watch: %w", err)}) {
+				return
+			}
+		case now := <-d.timer.C:
+			if !d.flush(ctx, now) {
+				return
+			}
+		}
+	}
+}
+
+func (d *watcher) handle(event fsnotify.Event) error {
+	switch {
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		// Renamed files come back with a create event of their new name
+		delete(d.pending, event.Name)
+	case event.Has(fsnotify.Create):
+		info, err := os.Lstat(event.Name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stat: %w", err)
+		}
+		if !info.IsDir() {
+			d.schedule(event.Name)
+
+			return nil
+		}
+		// Files could have been created before the directory was watched
+		files, err := d.add(event.Name)
+		for _, name := range files {
+			d.schedule(name)
+		}
+
+		return err
+	case event.Has(fsnotify.Write):
+		d.schedule(event.Name)
+	}
+
+	return nil
+}
+
+// add watches directory name and its subdirectories allowed by options
+// and returns files found in them.
+func (d *watcher) add(name string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(name, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			files = append(files, path)
+
+			return nil
+		}
+		if !d.opts.AllowsDir(d.rel(path)) {
+			return fs.SkipDir
+		}
+		if err := d.w.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return files, fmt.Errorf("walk dir: %w", err)
+	}
+
+	return files, nil
+}
+
+// schedule sends file name once it isn't written for debounce.
+func (d *watcher) schedule(name string) {
+	d.pending[name] = time.Now().Add(d.debounce)
+	d.arm()
+}
+
+// arm sets the timer to fire when the earliest pending file is due.
+func (d *watcher) arm() {
+	var next time.Time
+	for _, due := range d.pending {
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	if next.IsZero() {
+		d.timer.Stop()
+
+		return
+	}
+	d.timer.Reset(time.Until(next))
+}
+
+// flush sends files which are due at now and reports whether ctx isn't done.
+func (d *watcher) flush(ctx context.Context, now time.Time) bool {
+	for name, due := range d.pending {
+		if due.After(now) {
+			continue
+		}
+		delete(d.pending, name)
+
+		info, err := os.Stat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			if !d.send(ctx, Event{Path: name, Err: fmt.Errorf("stat: %w", err)}) {
+				return false
+			}
+
+			continue
+		}
+		if !info.Mode().IsRegular() || !d.opts.AllowsFile(d.rel(name), info.Size()) {
+			continue
+		}
+		if !d.send(ctx, Event{Path: name}) {
+			return false
+		}
+	}
+	d.arm()
+
+	return true
+}
+
+func (d *watcher) send(ctx context.Context, e Event) bool {
+	select {
+	case d.out <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// rel returns slash separated path of name relative to the watched dir.
+func (d *watcher) rel(name string) string {
+	rel, err := filepath.Rel(d.dir, name)
+	if err != nil {
+		return name
+	}
+
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,150 @@
+package dirwatch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kndrad/piccrack/pkg/dirwatch"
+	"github.com/kndrad/piccrack/pkg/pproc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+const debounce = 50 * time.Millisecond
+
+// collect returns events received until none came for a while.
+func collect(t *testing.T, events <-chan dirwatch.Event) []string {
+	t.Helper()
+
+	var paths []string
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return paths
+			}
+			require.NoError(t, e.Err)
+			paths = append(paths, e.Path)
+		case <-time.After(10 * debounce):
+			return paths
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		opts pproc.WalkOptions
+		// write creates files in dir after watching started
+		write func(t *testing.T, dir string)
+		want  []string
+	}{
+		{
+			desc: "file_written_in_chunks_is_sent_once",
+
+			write: func(t *testing.T, dir string) {
+				f, err := os.Create(filepath.Join(dir, "offer.png"))
+				require.NoError(t, err)
+				defer f.Close()
+				for range 5 {
+					_, err := f.WriteString("chunk")
+					require.NoError(t, err)
+					time.Sleep(debounce / 5)
+				}
+			},
+			want: []string{"offer.png"},
+		},
+		{
+			desc: "filtered_files_are_skipped",
+
+			opts: pproc.WalkOptions{SkipHidden: true, Exclude: []string{"*.tmp"}},
+			write: func(t *testing.T, dir string) {
+				for _, name := range []string{".offer.png", "offer.png.tmp", "offer.png"} {
+					require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("png"), 0o600))
+				}
+			},
+			want: []string{"offer.png"},
+		},
+		{
+			desc: "files_of_new_directories_are_sent",
+
+			write: func(t *testing.T, dir string) {
+				sub := filepath.Join(dir, "2024", "12")
+				require.NoError(t, os.MkdirAll(sub, 0o700))
+				require.NoError(t, os.WriteFile(filepath.Join(sub, "offer.png"), []byte("png"), 0o600))
+			},
+			want: []string{"2024/12/offer.png"},
+		},
+		{
+			desc: "removed_files_are_skipped",
+
+			write: func(t *testing.T, dir string) {
+				name := filepath.Join(dir, "offer.png")
+				require.NoError(t, os.WriteFile(name, []byte("png"), 0o600))
+				require.NoError(t, os.Remove(name))
+			},
+			want: nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "old.png"), []byte("png"), 0o600))
+
+			events, err := dirwatch.Watch(ctx, dir, tC.opts, debounce)
+			require.NoError(t, err)
+			tC.write(t, dir)
+
+			var got []string
+			for _, path := range collect(t, events) {
+				rel, err := filepath.Rel(dir, path)
+				require.NoError(t, err)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestWatchInvalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "offer.png")
+	require.NoError(t, os.WriteFile(name, []byte("png"), 0o600))
+
+	_, err := dirwatch.Watch(context.Background(), name, pproc.WalkOptions{}, debounce)
+	require.Error(t, err)
+
+	_, err = dirwatch.Watch(context.Background(), dir, pproc.WalkOptions{Include: []string{"["}}, debounce)
+	require.Error(t, err)
+}
+
+func TestWatchClosedOnCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	dir := t.TempDir()
+	events, err := dirwatch.Watch(ctx, dir, pproc.WalkOptions{}, debounce)
+	require.NoError(t, err)
+
+	// Pending file isn't received before the channel is closed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "offer.png"), []byte("png"), 0o600))
+	time.Sleep(2 * debounce)
+	cancel()
+
+	for range events {
+	}
+}
@@ -0,0 +1,372 @@
+package imgproc
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// toGray returns img as gray image with bounds starting at zero point.
+func toGray(img image.Image) *image.Gray {
+	b := img.Bounds()
+	if g, ok := img.(*image.Gray); ok && b.Min == (image.Point{}) {
+		return g
+	}
+	g := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(g, g.Bounds(), img, b.Min, draw.Src)
+
+	return g
+}
+
+// Grayscale converts img to 8-bit gray.
+func Grayscale(img image.Image) image.Image {
+	g := toGray(img)
+	if g == img {
+		out := image.NewGray(g.Rect)
+		copy(out.Pix, g.Pix)
+
+		return out
+	}
+
+	return g
+}
+
+func histogram(g *image.Gray) [256]int {
+	var h [256]int
+	for y := range g.Rect.Dy() {
+		row := g.Pix[y*g.Stride : y*g.Stride+g.Rect.Dx()]
+		for _, v := range row {
+			h[v]++
+		}
+	}
+
+	return h
+}
+
+// mapGray returns a copy of g with every pixel value mapped by lut.
+func mapGray(g *image.Gray, lut *[256]uint8) *image.Gray {
+	out := image.NewGray(g.Rect)
+	for y := range g.Rect.Dy() {
+		src := g.Pix[y*g.Stride : y*g.Stride+g.Rect.Dx()]
+		dst := out.Pix[y*out.Stride : y*out.Stride+out.Rect.Dx()]
+		for x, v := range src {
+			dst[x] = lut[v]
+		}
+	}
+
+	return out
+}
+
+// Contrast stretches gray levels so the darkest and brightest 1% of pixels
+// become black and white.
+func Contrast(img image.Image) image.Image {
+	g := toGray(img)
+	h := histogram(g)
+	total := g.Rect.Dx() * g.Rect.Dy()
+	clip := total / 100
+
+	lo, hi := 0, 255
+	for n := 0; lo < 255; lo++ {
+		if n += h[lo]; n > clip {
+			break
+		}
+	}
+	for n := 0; hi > 0; hi-- {
+		if n += h[hi]; n > clip {
+			break
+		}
+	}
+	if hi <= lo {
+		return mapGray(g, identity())
+	}
+
+	var lut [256]uint8
+	for i := range lut {
+		v := (i - lo) * 255 / (hi - lo)
+		lut[i] = uint8(min(max(v, 0), 255))
+	}
+
+	return mapGray(g, &lut)
+}
+
+func identity() *[256]uint8 {
+	var lut [256]uint8
+	for i := range lut {
+		lut[i] = uint8(i)
+	}
+
+	return &lut
+}
+
+// OtsuThreshold returns the gray level separating g into dark and bright
+// pixels with maximal between class variance.
+func OtsuThreshold(g *image.Gray) uint8 {
+	h := histogram(g)
+	total := g.Rect.Dx() * g.Rect.Dy()
+	if total == 0 {
+		return 128
+	}
+
+	var sum float64
+	for i, n := range h {
+		sum += float64(i * n)
+	}
+
+	var (
+		sumB, best float64
+		wB         int
+		threshold  int
+	)
+	for i, n := range h {
+		wB += n
+		if wB == 0 {
+			continue
+		}
+		wF := total - wB
+		if wF == 0 {
+			break
+		}
+		sumB += float64(i * n)
+		mB := sumB / float64(wB)
+		mF := (sum - sumB) / float64(wF)
+		between := float64(wB) * float64(wF) * (mB - mF) * (mB - mF)
+		if between > best {
+			best = between
+			threshold = i
+		}
+	}
+
+	return uint8(threshold)
+}
+
+// Otsu binarizes img to black and white using OtsuThreshold.
+func Otsu(img image.Image) image.Image {
+	g := toGray(img)
+	t := OtsuThreshold(g)
+
+	var lut [256]uint8
+	for i := range lut {
+		if i > int(t) {
+			lut[i] = 255
+		}
+	}
+
+	return mapGray(g, &lut)
+}
+
+func mean(g *image.Gray) float64 {
+	total := g.Rect.Dx() * g.Rect.Dy()
+	if total == 0 {
+		return 0
+	}
+	var sum int
+	for i, n := range histogram(g) {
+		sum += i * n
+	}
+
+	return float64(sum) / float64(total)
+}
+
+// InvertDark inverts images which are mostly dark, e.g. dark mode
+// screenshots, so text ends up dark on bright background. Bright images
+// are returned as gray unchanged.
+func InvertDark(img image.Image) image.Image {
+	g := toGray(img)
+	if mean(g) >= 128 {
+		return mapGray(g, identity())
+	}
+
+	var lut [256]uint8
+	for i := range lut {
+		lut[i] = uint8(255 - i)
+	}
+
+	return mapGray(g, &lut)
+}
+
+// UpscaleMinWidth is the width below which Upscale enlarges images.
+// Tesseract works best with x-height of roughly 20px or more, which small
+// screenshot fonts don't reach.
+var UpscaleMinWidth = 1200
+
+// Upscale enlarges images narrower than UpscaleMinWidth by an integer factor
+// of up to 4 using bilinear interpolation.
+func Upscale(img image.Image) image.Image {
+	g := toGray(img)
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	if w == 0 || w >= UpscaleMinWidth {
+		return mapGray(g, identity())
+	}
+	factor := min((UpscaleMinWidth+w-1)/w, 4)
+
+	out := image.NewGray(image.Rect(0, 0, w*factor, h*factor))
+	for y := range out.Rect.Dy() {
+		sy := (float64(y)+0.5)/float64(factor) - 0.5
+		for x := range out.Rect.Dx() {
+			sx := (float64(x)+0.5)/float64(factor) - 0.5
+			out.Pix[y*out.Stride+x] = bilinear(g, sx, sy, 255)
+		}
+	}
+
+	return out
+}
+
+// bilinear samples g at a fractional point. Points outside g are clamped,
+// unless they are further than a pixel away, then bg is returned.
+func bilinear(g *image.Gray, x, y float64, bg uint8) uint8 {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	if x < -1 || y < -1 || x > float64(w) || y > float64(h) {
+		return bg
+	}
+	x = math.Max(0, math.Min(x, float64(w-1)))
+	y = math.Max(0, math.Min(y, float64(h-1)))
+
+	x0, y0 := int(x), int(y)
+	x1, y1 := min(x0+1, w-1), min(y0+1, h-1)
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	at := func(x, y int) float64 { return float64(g.Pix[y*g.Stride+x]) }
+	top := at(x0, y0)*(1-fx) + at(x1, y0)*fx
+	bottom := at(x0, y1)*(1-fx) + at(x1, y1)*fx
+
+	return uint8(math.Round(top*(1-fy) + bottom*fy))
+}
+
+// TrimTolerance is the difference from the border color under which pixels
+// are considered part of the border.
+var TrimTolerance uint8 = 32
+
+// Trim crops uniform borders, taking the top left pixel as border color.
+// A small margin is kept around content.
+func Trim(img image.Image) image.Image {
+	const margin = 8
+
+	g := toGray(img)
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	if w == 0 || h == 0 {
+		return mapGray(g, identity())
+	}
+	bg := g.Pix[0]
+
+	content := image.Rectangle{}
+	for y := range h {
+		for x := range w {
+			v := g.Pix[y*g.Stride+x]
+			if absDiff(v, bg) > TrimTolerance {
+				content = content.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if content.Empty() {
+		return mapGray(g, identity())
+	}
+	content = content.Inset(-margin).Intersect(g.Rect)
+
+	out := image.NewGray(image.Rect(0, 0, content.Dx(), content.Dy()))
+	draw.Draw(out, out.Rect, g, content.Min, draw.Src)
+
+	return out
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
+
+// MaxSkew is the largest angle in degrees Deskew corrects.
+var MaxSkew = 10.0
+
+// Deskew rotates img so lines of text are horizontal. Text is assumed to be
+// darker than background, see InvertDark.
+func Deskew(img image.Image) image.Image {
+	g := toGray(img)
+	angle := SkewAngle(g)
+	if math.Abs(angle) < 0.25 {
+		return mapGray(g, identity())
+	}
+
+	return rotate(g, -angle, 255)
+}
+
+// SkewAngle estimates rotation of text lines in degrees, by finding the
+// angle at which the horizontal projection of dark pixels is the sharpest.
+func SkewAngle(g *image.Gray) float64 {
+	t := OtsuThreshold(g)
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	cx, cy := float64(w)/2, float64(h)/2
+
+	// Sample dark pixels, large images don't need every one of them
+	step := max(1, int(math.Sqrt(float64(w*h)/250_000)))
+	var points [][2]float64
+	for y := 0; y < h; y += step {
+		for x := 0; x < w; x += step {
+			if g.Pix[y*g.Stride+x] <= t {
+				points = append(points, [2]float64{float64(x) - cx, float64(y) - cy})
+			}
+		}
+	}
+	if len(points) == 0 {
+		return 0
+	}
+
+	diag := int(math.Hypot(float64(w), float64(h))) + 2
+	rows := make([]int, diag)
+
+	score := func(deg float64) float64 {
+		clear(rows)
+		sin, cos := math.Sincos(deg * math.Pi / 180)
+		for _, p := range points {
+			r := int(p[0]*sin+p[1]*cos) + diag/2
+			if r >= 0 && r < diag {
+				rows[r]++
+			}
+		}
+		var s float64
+		for _, n := range rows {
+			s += float64(n * n)
+		}
+
+		return s
+	}
+
+	best, bestScore := 0.0, score(0)
+	for deg := -MaxSkew; deg <= MaxSkew; deg += 0.5 {
+		if s := score(deg); s > bestScore {
+			best, bestScore = deg, s
+		}
+	}
+	// Refine around the best coarse angle
+	for deg := best - 0.5; deg <= best+0.5; deg += 0.1 {
+		if s := score(deg); s > bestScore {
+			best, bestScore = deg, s
+		}
+	}
+
+	// Rotating content by best makes lines horizontal, so it's skewed by -best
+	return -best
+}
+
+// rotate turns content of g by deg degrees around its center, filling
+// uncovered area with bg.
+func rotate(g *image.Gray, deg float64, bg uint8) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	cx, cy := float64(w)/2, float64(h)/2
+	sin, cos := math.Sincos(deg * math.Pi / 180)
+
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := range h {
+		dy := float64(y) - cy
+		for x := range w {
+			dx := float64(x) - cx
+			// Inverse rotation finds the source pixel
+			sx := dx*cos + dy*sin + cx
+			sy := -dx*sin + dy*cos + cy
+			out.Pix[y*out.Stride+x] = bilinear(g, sx, sy, bg)
+		}
+	}
+
+	return out
+}
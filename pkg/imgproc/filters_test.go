@@ -0,0 +1,136 @@
+package imgproc
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stripes returns white image with horizontal black lines resembling text.
+func stripes(w, h int) *image.Gray {
+	g := image.NewGray(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			v := uint8(255)
+			if y%20 < 6 && x > w/8 && x < w*7/8 {
+				v = 0
+			}
+			g.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+
+	return g
+}
+
+func uniform(w, h int, v uint8) *image.Gray {
+	g := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range g.Pix {
+		g.Pix[i] = v
+	}
+
+	return g
+}
+
+func TestOtsu(t *testing.T) {
+	t.Parallel()
+
+	g := uniform(10, 10, 40)
+	for i := range 50 {
+		g.Pix[i] = 200
+	}
+
+	threshold := OtsuThreshold(g)
+	require.GreaterOrEqual(t, threshold, uint8(40))
+	require.Less(t, threshold, uint8(200))
+
+	out := toGray(Otsu(g))
+	require.Equal(t, uint8(255), out.Pix[0])
+	require.Equal(t, uint8(0), out.Pix[99])
+	require.Equal(t, uint8(200), g.Pix[0], "input must not be modified")
+}
+
+func TestContrast(t *testing.T) {
+	t.Parallel()
+
+	g := uniform(10, 10, 100)
+	for i := range 50 {
+		g.Pix[i] = 150
+	}
+
+	out := toGray(Contrast(g))
+	require.Equal(t, uint8(255), out.Pix[0])
+	require.Equal(t, uint8(0), out.Pix[99])
+}
+
+func TestInvertDark(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		in   uint8
+		want uint8
+	}{
+		{
+			desc: "inverts_dark_image",
+			in:   30,
+			want: 225,
+		},
+		{
+			desc: "keeps_bright_image",
+			in:   230,
+			want: 230,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			out := toGray(InvertDark(uniform(4, 4, tC.in)))
+			require.Equal(t, tC.want, out.Pix[0])
+		})
+	}
+}
+
+func TestUpscale(t *testing.T) {
+	t.Parallel()
+
+	out := Upscale(uniform(400, 100, 90))
+	require.Equal(t, image.Rect(0, 0, 1200, 300), out.Bounds())
+	require.Equal(t, uint8(90), toGray(out).Pix[600])
+
+	big := Upscale(uniform(UpscaleMinWidth, 10, 90))
+	require.Equal(t, image.Rect(0, 0, UpscaleMinWidth, 10), big.Bounds())
+}
+
+func TestTrim(t *testing.T) {
+	t.Parallel()
+
+	g := uniform(100, 100, 255)
+	for y := 40; y < 60; y++ {
+		for x := 30; x < 50; x++ {
+			g.Pix[y*g.Stride+x] = 0
+		}
+	}
+
+	out := Trim(g)
+	require.Equal(t, image.Rect(0, 0, 36, 36), out.Bounds())
+
+	blank := Trim(uniform(10, 10, 255))
+	require.Equal(t, image.Rect(0, 0, 10, 10), blank.Bounds())
+}
+
+func TestDeskew(t *testing.T) {
+	t.Parallel()
+
+	straight := stripes(400, 300)
+	require.InDelta(t, 0, SkewAngle(straight), 0.3)
+
+	skewed := rotate(straight, 3, 255)
+	angle := SkewAngle(skewed)
+	require.InDelta(t, 3, angle, 0.3)
+
+	fixed := toGray(Deskew(skewed))
+	require.Less(t, math.Abs(SkewAngle(fixed)), 0.3)
+}
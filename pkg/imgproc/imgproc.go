@@ -0,0 +1,132 @@
+// Package imgproc prepares screenshots for ocr. Steps are pure Go image
+// filters which can be chained, e.g. to turn a dark themed, low resolution
+// screenshot into upscaled black text on white background.
+package imgproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register decoder
+	_ "image/jpeg" // Register decoder
+	"image/png"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	// Register decoders of formats detected by imgsniff
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// StepFunc transforms an image. Steps must not modify their input.
+type StepFunc func(img image.Image) image.Image
+
+// Step is a named StepFunc.
+type Step struct {
+	Name string
+	Fn   StepFunc
+}
+
+var steps = map[string]StepFunc{
+	"grayscale": Grayscale,
+	"contrast":  Contrast,
+	"otsu":      Otsu,
+	"invert":    InvertDark,
+	"upscale":   Upscale,
+	"trim":      Trim,
+	"deskew":    Deskew,
+}
+
+// StepNames returns names accepted by ParseChain.
+func StepNames() []string {
+	return slices.Sorted(maps.Keys(steps))
+}
+
+var ErrUnknownStep = errors.New("unknown preprocessing step")
+
+// Chain is an ordered list of steps.
+type Chain []Step
+
+// ParseChain creates a chain from step names, see StepNames.
+func ParseChain(names []string) (Chain, error) {
+	chain := make(Chain, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		fn, ok := steps[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownStep, name)
+		}
+		chain = append(chain, Step{Name: name, Fn: fn})
+	}
+
+	return chain, nil
+}
+
+// DumpFunc receives intermediate images, index 0 being the input.
+type DumpFunc func(i int, name string, img image.Image) error
+
+// Apply runs steps in order. If dump isn't nil it's called with the input
+// and after every step.
+func (c Chain) Apply(img image.Image, dump DumpFunc) (image.Image, error) {
+	if dump != nil {
+		if err := dump(0, "input", img); err != nil {
+			return nil, fmt.Errorf("dump input: %w", err)
+		}
+	}
+	for i, step := range c {
+		img = step.Fn(img)
+		if dump != nil {
+			if err := dump(i+1, step.Name, img); err != nil {
+				return nil, fmt.Errorf("dump %s: %w", step.Name, err)
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// Process decodes content, runs the chain and encodes the output as png.
+func (c Chain) Process(content []byte, dump DumpFunc) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	img, err = c.Apply(img, dump)
+	if err != nil {
+		return nil, fmt.Errorf("apply: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DirDump returns DumpFunc writing png files named
+// "<prefix>-<index>-<step>.png" into dir.
+func DirDump(dir, prefix string) DumpFunc {
+	return func(i int, name string, img image.Image) error {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("mkdir: %w", err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s-%02d-%s.png", prefix, i, name))
+		f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("open file: %w", err)
+		}
+		defer f.Close()
+
+		if err := png.Encode(f, img); err != nil {
+			return fmt.Errorf("encode: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package imgproc
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChain(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			desc:  "parses_names",
+			names: []string{"grayscale", " Invert ", "otsu"},
+			want:  []string{"grayscale", "invert", "otsu"},
+		},
+		{
+			desc:  "empty_chain",
+			names: nil,
+			want:  []string{},
+		},
+		{
+			desc:    "unknown_step_err",
+			names:   []string{"grayscale", "sharpen"},
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			chain, err := ParseChain(tC.names)
+			if tC.wantErr {
+				require.ErrorIs(t, err, ErrUnknownStep)
+
+				return
+			}
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(chain))
+			for _, step := range chain {
+				names = append(names, step.Name)
+			}
+			require.Equal(t, tC.want, names)
+		})
+	}
+}
+
+func TestChainProcess(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile(filepath.Join("..", "ocr", "testdata", "golang_0.png"))
+	require.NoError(t, err)
+
+	chain, err := ParseChain(StepNames())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	out, err := chain.Process(content, DirDump(dir, "golang_0"))
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	require.IsType(t, &image.Gray{}, img)
+
+	dumps, err := filepath.Glob(filepath.Join(dir, "golang_0-*.png"))
+	require.NoError(t, err)
+	require.Len(t, dumps, len(chain)+1)
+	require.FileExists(t, filepath.Join(dir, "golang_0-00-input.png"))
+
+	_, err = chain.Process([]byte("not an image"), nil)
+	require.Error(t, err)
+}
+
+func TestChainProcessFormats(t *testing.T) {
+	t.Parallel()
+
+	chain, err := ParseChain([]string{"grayscale"})
+	require.NoError(t, err)
+
+	for _, name := range []string{"golang.jpg", "golang.gif", "golang.bmp", "golang.tiff"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := os.ReadFile(filepath.Join("..", "imgsniff", "testdata", name))
+			require.NoError(t, err)
+
+			out, err := chain.Process(content, nil)
+			require.NoError(t, err)
+
+			img, err := png.Decode(bytes.NewReader(out))
+			require.NoError(t, err)
+			require.Equal(t, image.Rect(0, 0, 160, 60), img.Bounds())
+		})
+	}
+}
@@ -1,3 +1,5 @@
+// Package imgsniff detects image formats by their magic numbers. PDF
+// documents, which are scanned like images, are detected as well.
 package imgsniff
 
 import (
@@ -6,71 +8,140 @@ import (
 	"unicode"
 )
 
-type signature []byte
+// Format is a detected image format.
+type Format struct {
+	Name string
+	MIME string
+}
 
-type signatures struct {
-	m  map[string]signature
-	mu sync.Mutex
+func (f Format) String() string {
+	return f.Name
 }
 
-func Signatures() *signatures {
-	return &signatures{
-		m: map[string]signature{
-			"png": {137, 80, 78, 71, 13, 10, 26, 10},
-			"jpg": {0xFF, 0x4F, 0xFF, 0x51},
-		},
-	}
+var (
+	PNG      = Format{Name: "png", MIME: "image/png"}
+	JPEG     = Format{Name: "jpeg", MIME: "image/jpeg"}
+	GIF      = Format{Name: "gif", MIME: "image/gif"}
+	WebP     = Format{Name: "webp", MIME: "image/webp"}
+	BMP      = Format{Name: "bmp", MIME: "image/bmp"}
+	TIFF     = Format{Name: "tiff", MIME: "image/tiff"}
+	JPEG2000 = Format{Name: "jpeg2000", MIME: "image/jp2"}
+	PDF      = Format{Name: "pdf", MIME: "application/pdf"}
+)
+
+// Magic is a byte sequence expected at an offset.
+type Magic struct {
+	Offset int
+	Bytes  []byte
 }
 
-func (s *signatures) png() signature {
-	if s == nil {
-		s = Signatures()
+// match reports whether data holds m. Short data never matches.
+func (m Magic) match(data []byte) bool {
+	end := m.Offset + len(m.Bytes)
+	if m.Offset < 0 || end > len(data) {
+		return false
 	}
-	s.mu.Lock()
-	defer s.mu.Unlock()
 
-	return s.m["png"]
+	return bytes.Equal(data[m.Offset:end], m.Bytes)
 }
 
-func IsPNG(data []byte) bool {
-	if data == nil {
-		return false
+// Signature is a set of magic numbers which must all match.
+type Signature []Magic
+
+func (s Signature) match(data []byte) bool {
+	for _, m := range s {
+		if !m.match(data) {
+			return false
+		}
 	}
 
-	idx := firstNonWSIndex(data)
-	sigs := Signatures()
+	return len(s) > 0
+}
 
-	return bytes.Equal(data[idx:idx+8], sigs.png())
+func sig(offset int, b string) Signature {
+	return Signature{{Offset: offset, Bytes: []byte(b)}}
 }
 
-func firstNonWSIndex(data []byte) int {
-	if data == nil {
-		return 0
-	}
-	idx := 0 // Index of first non-whitespace byte in data
-	for ; idx < len(data) && unicode.IsSpace(rune(data[idx])); idx++ {
+type entry struct {
+	format Format
+	sigs   []Signature
+}
+
+var (
+	mu       sync.RWMutex
+	registry = []entry{
+		{PNG, []Signature{sig(0, "\x89PNG\r\n\x1a\n")}},
+		{JPEG, []Signature{sig(0, "\xFF\xD8\xFF")}},
+		{GIF, []Signature{sig(0, "GIF87a"), sig(0, "GIF89a")}},
+		{WebP, []Signature{{{0, []byte("RIFF")}, {8, []byte("WEBP")}}}},
+		// Reserved header fields are zero, "BM" alone is too common in text
+		{BMP, []Signature{{{0, []byte("BM")}, {6, []byte{0, 0, 0, 0}}}}},
+		{TIFF, []Signature{sig(0, "II*\x00"), sig(0, "MM\x00*")}},
+		{JPEG2000, []Signature{
+			sig(0, "\xFF\x4F\xFF\x51"),               // Codestream
+			sig(0, "\x00\x00\x00\x0CjP  \r\n\x87\n"), // JP2 signature box
+		}},
+		{PDF, []Signature{sig(0, "%PDF-")}},
 	}
+)
 
-	return idx
+// Register adds format detected by any of sigs. Formats registered later
+// are checked first, so they can refine built-in ones.
+func Register(f Format, sigs ...Signature) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registry = append(registry, entry{format: f, sigs: sigs})
 }
 
-func (s *signatures) jpg() signature {
-	if s == nil {
-		s = Signatures()
+// Detect returns format of data. Leading whitespace is skipped.
+func Detect(data []byte) (Format, bool) {
+	data = data[firstNonWSIndex(data):]
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for i := len(registry) - 1; i >= 0; i-- {
+		e := registry[i]
+		for _, s := range e.sigs {
+			if s.match(data) {
+				return e.format, true
+			}
+		}
 	}
-	s.mu.Lock()
-	defer s.mu.Unlock()
 
-	return s.m["jpg"]
+	return Format{}, false
+}
+
+// Is reports whether data is in format f.
+func Is(data []byte, f Format) bool {
+	got, ok := Detect(data)
+
+	return ok && got == f
+}
+
+// IsImage reports whether data is in any registered format other than PDF.
+func IsImage(data []byte) bool {
+	f, ok := Detect(data)
+
+	return ok && f != PDF
+}
+
+func IsPNG(data []byte) bool {
+	return Is(data, PNG)
 }
 
 func IsJPG(data []byte) bool {
+	return Is(data, JPEG)
+}
+
+func firstNonWSIndex(data []byte) int {
 	if data == nil {
-		return false
+		return 0
+	}
+	idx := 0 // Index of first non-whitespace byte in data
+	for ; idx < len(data) && unicode.IsSpace(rune(data[idx])); idx++ {
 	}
 
-	idx := firstNonWSIndex(data)
-	sigs := Signatures()
-
-	return bytes.Equal(data[idx:idx+4], sigs.jpg())
+	return idx
 }
@@ -1,6 +1,8 @@
 package imgsniff
 
 import (
+	"os"
+	"path/filepath"
 	"slices"
 	"testing"
 
@@ -89,7 +91,7 @@ func TestFirstNonWSIdx(t *testing.T) {
 func TestIsJPG(t *testing.T) {
 	t.Parallel()
 
-	signature := []byte{0xFF, 0x4F, 0xFF, 0x51}
+	signature := []byte{0xFF, 0xD8, 0xFF, 0xE0}
 
 	testCases := []struct {
 		desc string
@@ -100,9 +102,21 @@ func TestIsJPG(t *testing.T) {
 		{
 			desc: "normal_jpg",
 
-			data: []byte{0xFF, 0x4F, 0xFF, 0x51},
+			data: []byte{0xFF, 0xD8, 0xFF, 0xE0},
 			want: true,
 		},
+		{
+			desc: "exif_jpg",
+
+			data: []byte{0xFF, 0xD8, 0xFF, 0xE1},
+			want: true,
+		},
+		{
+			desc: "jpeg2000_codestream_is_not_jpg",
+
+			data: []byte{0xFF, 0x4F, 0xFF, 0x51},
+			want: false,
+		},
 		{
 			desc: "first_is_whitespace_still_jpg",
 
@@ -131,3 +145,191 @@ func TestIsJPG(t *testing.T) {
 		})
 	}
 }
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		data   []byte
+		want   Format
+		wantOK bool
+	}{
+		{
+			desc: "png",
+
+			data:   []byte("\x89PNG\r\n\x1a\n\x00\x00"),
+			want:   PNG,
+			wantOK: true,
+		},
+		{
+			desc: "jpeg",
+
+			data:   []byte{0xFF, 0xD8, 0xFF, 0xDB},
+			want:   JPEG,
+			wantOK: true,
+		},
+		{
+			desc: "gif87a",
+
+			data:   []byte("GIF87a\x01\x00"),
+			want:   GIF,
+			wantOK: true,
+		},
+		{
+			desc: "gif89a",
+
+			data:   []byte("GIF89a\x01\x00"),
+			want:   GIF,
+			wantOK: true,
+		},
+		{
+			desc: "webp",
+
+			data:   []byte("RIFF\x24\x00\x00\x00WEBPVP8 "),
+			want:   WebP,
+			wantOK: true,
+		},
+		{
+			desc: "riff_wave_is_not_webp",
+
+			data:   []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+			wantOK: false,
+		},
+		{
+			desc: "bmp",
+
+			data:   []byte("BM\x36\x00\x0C\x00\x00\x00\x00\x00\x36\x00"),
+			want:   BMP,
+			wantOK: true,
+		},
+		{
+			desc: "text_starting_with_bm_is_not_bmp",
+
+			data:   []byte("BMW is a car"),
+			wantOK: false,
+		},
+		{
+			desc: "tiff_little_endian",
+
+			data:   []byte("II*\x00\x08\x00\x00\x00"),
+			want:   TIFF,
+			wantOK: true,
+		},
+		{
+			desc: "tiff_big_endian",
+
+			data:   []byte("MM\x00*\x00\x00\x00\x08"),
+			want:   TIFF,
+			wantOK: true,
+		},
+		{
+			desc: "jpeg2000_codestream",
+
+			data:   []byte{0xFF, 0x4F, 0xFF, 0x51, 0x00},
+			want:   JPEG2000,
+			wantOK: true,
+		},
+		{
+			desc: "jpeg2000_jp2_box",
+
+			data:   []byte("\x00\x00\x00\x0CjP  \r\n\x87\n\x00\x00"),
+			want:   JPEG2000,
+			wantOK: true,
+		},
+		{
+			desc: "short_input",
+
+			data:   []byte{0x89, 'P'},
+			wantOK: false,
+		},
+		{
+			desc: "short_webp_input",
+
+			data:   []byte("RIFF\x24\x00"),
+			wantOK: false,
+		},
+		{
+			desc: "empty_input",
+
+			data:   []byte{},
+			wantOK: false,
+		},
+		{
+			desc: "nil_input",
+
+			data:   nil,
+			wantOK: false,
+		},
+		{
+			desc: "only_whitespace",
+
+			data:   []byte("   "),
+			wantOK: false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			f, ok := Detect(tC.data)
+			require.Equal(t, tC.wantOK, ok)
+			require.Equal(t, tC.want, f)
+			require.Equal(t, tC.wantOK, IsImage(tC.data))
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	qoi := Format{Name: "qoi", MIME: "image/qoi"}
+	Register(qoi, Signature{{Offset: 0, Bytes: []byte("qoif")}})
+
+	f, ok := Detect([]byte("qoif\x00\x00\x01\x00"))
+	require.True(t, ok)
+	require.Equal(t, qoi, f)
+	require.Equal(t, "image/qoi", f.MIME)
+}
+
+func TestDetectFiles(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		name string
+		want Format
+	}{
+		{desc: "png", name: "golang.png", want: PNG},
+		{desc: "jpeg", name: "golang.jpg", want: JPEG},
+		{desc: "gif", name: "golang.gif", want: GIF},
+		{desc: "bmp", name: "golang.bmp", want: BMP},
+		{desc: "tiff", name: "golang.tiff", want: TIFF},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(filepath.Join("testdata", tC.name))
+			require.NoError(t, err)
+
+			f, ok := Detect(data)
+			require.True(t, ok)
+			require.Equal(t, tC.want, f)
+		})
+	}
+}
+
+func TestDetectPDF(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("\n%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	f, ok := Detect(data)
+	require.True(t, ok)
+	require.Equal(t, PDF, f)
+	require.True(t, Is(data, PDF))
+	require.False(t, IsImage(data), "pdf is a document, not an image")
+}
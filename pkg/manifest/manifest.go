@@ -0,0 +1,171 @@
+// Package manifest records files which were already processed, so that
+// scanning a growing directory again processes new and changed files only.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Record describes a processed file.
+type Record struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash"`
+
+	// BatchID is id of the batch file contents were stored in, zero if
+	// they weren't stored.
+	BatchID     int64     `json:"batch_id,omitempty"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// Hash returns hex encoded sha256 checksum of content.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Status is state of a file compared to its record.
+type Status string
+
+const (
+	// StatusAdded is a file without a record.
+	StatusAdded Status = "added"
+	// StatusChanged is a file whose content differs from its record.
+	StatusChanged Status = "changed"
+	// StatusUnchanged is a file with the content it was recorded with, its
+	// size or modification time may differ, e.g. after it was copied.
+	StatusUnchanged Status = "unchanged"
+)
+
+// File is a manifest kept in a JSON file. It's safe for concurrent use.
+type File struct {
+	path string
+
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// Open reads manifest kept in file at path. Missing file is an empty
+// manifest, it's created once a record is put.
+func Open(path string) (*File, error) {
+	f := &File{path: path, records: make(map[string]Record)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	for _, r := range records {
+		f.records[r.Path] = r
+	}
+
+	return f, nil
+}
+
+// Path returns path of the manifest file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Get returns record of file at path.
+func (f *File) Get(path string) (Record, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, ok := f.records[path]
+
+	return r, ok
+}
+
+// Status returns status of file at path whose content has hash.
+func (f *File) Status(path, hash string) Status {
+	r, ok := f.Get(path)
+	switch {
+	case !ok:
+		return StatusAdded
+	case r.Hash != hash:
+		return StatusChanged
+	default:
+		return StatusUnchanged
+	}
+}
+
+// Put records r, replacing record of the same path, and saves the
+// manifest.
+func (f *File) Put(r Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.records[r.Path] = r
+
+	return f.save()
+}
+
+// Records returns every record ordered by path.
+func (f *File) Records() []Record {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sorted()
+}
+
+func (f *File) sorted() []Record {
+	records := make([]Record, 0, len(f.records))
+	for _, path := range slices.Sorted(maps.Keys(f.records)) {
+		records = append(records, f.records[path])
+	}
+
+	return records
+}
+
+// save writes records to a temporary file renamed over the manifest, so
+// that it's never left half written.
+func (f *File) save() error {
+	data, err := json.MarshalIndent(f.sorted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+
+	return nil
+}
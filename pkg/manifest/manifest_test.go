@@ -0,0 +1,104 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "ledger", "manifest.json")
+
+	f, err := Open(path)
+	require.NoError(t, err)
+	require.Empty(t, f.Records())
+	_, ok := f.Get("offer.png")
+	require.False(t, ok)
+
+	modTime := time.Date(2024, 12, 15, 10, 0, 0, 0, time.UTC)
+	want := []Record{
+		{Path: "a.png", Size: 3, ModTime: modTime, Hash: Hash([]byte("abc")), BatchID: 7, ProcessedAt: modTime},
+		{Path: "b.pdf", Size: 0, ModTime: modTime, Hash: Hash(nil), ProcessedAt: modTime},
+	}
+	var wg sync.WaitGroup
+	for _, r := range want {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, f.Put(r))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, want, f.Records())
+
+	// Manifest is read back from its file
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	require.Equal(t, want, reopened.Records())
+	got, ok := reopened.Get("a.png")
+	require.True(t, ok)
+	require.Equal(t, want[0], got)
+
+	// Records are replaced by path
+	changed := want[0]
+	changed.Hash = Hash([]byte("abcd"))
+	require.NoError(t, reopened.Put(changed))
+	got, _ = reopened.Get("a.png")
+	require.Equal(t, changed.Hash, got.Hash)
+	require.Len(t, reopened.Records(), 2)
+
+	// No temporary files are left
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestFileStatus(t *testing.T) {
+	t.Parallel()
+
+	f, err := Open(filepath.Join(t.TempDir(), "manifest.json"))
+	require.NoError(t, err)
+	require.NoError(t, f.Put(Record{Path: "a.png", Size: 3, Hash: Hash([]byte("abc"))}))
+
+	testCases := []struct {
+		desc string
+
+		path    string
+		content string
+		want    Status
+	}{
+		{desc: "added", path: "b.png", content: "abc", want: StatusAdded},
+		{desc: "changed", path: "a.png", content: "abcd", want: StatusChanged},
+		{desc: "unchanged", path: "a.png", content: "abc", want: StatusUnchanged},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, f.Status(tC.path, Hash([]byte(tC.content))))
+		})
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	_, err := Open(path)
+	require.Error(t, err)
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Hash(nil))
+	require.NotEqual(t, Hash([]byte("a")), Hash([]byte("b")))
+}
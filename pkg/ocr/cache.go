@@ -0,0 +1,134 @@
+package ocr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var ErrCacheMiss = errors.New("ocr cache miss")
+
+// Cache stores recognitions by key, see CacheKey. Get returns ErrCacheMiss
+// for missing and expired entries.
+type Cache interface {
+	Get(ctx context.Context, key string) (*Recognition, error)
+	Set(ctx context.Context, key string, rec *Recognition) error
+}
+
+// CacheKey returns hex encoded SHA-256 of content and options affecting
+// recognition. Preprocessing options and regions are left out, engines
+// receive content which was already preprocessed and cropped.
+func CacheKey(content []byte, opts Options) string {
+	opts.Preprocess = nil
+	opts.DebugDir = ""
+	opts.Regions = nil
+	if len(opts.Variables) == 0 {
+		opts.Variables = nil
+	}
+
+	h := sha256.New()
+	h.Write(content)
+	// Map keys are sorted, so equal options encode the same way
+	encoded, _ := json.Marshal(opts)
+	h.Write(encoded)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CachedEngine returns cached recognitions of content it already saw with
+// the same options, so repeated scans and duplicate uploads skip ocr.
+// Cache errors are treated as misses, recognition doesn't depend on them.
+// It's a prometheus.Collector counting hits and misses, metrics of the
+// underlying engine are collected too if it's a collector.
+type CachedEngine struct {
+	engine Engine
+	cache  Cache
+
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+var (
+	_ Engine               = (*CachedEngine)(nil)
+	_ prometheus.Collector = (*CachedEngine)(nil)
+)
+
+func NewCachedEngine(e Engine, c Cache) *CachedEngine {
+	if e == nil {
+		panic("engine cannot be nil")
+	}
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	return &CachedEngine{
+		engine: e,
+		cache:  c,
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ocr_cache_hits_total",
+			Help: "Number of recognitions returned from ocr cache.",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ocr_cache_misses_total",
+			Help: "Number of recognitions missing in ocr cache.",
+		}),
+	}
+}
+
+func (ce *CachedEngine) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	opts, _ := OptionsFromContext(ctx)
+	key := CacheKey(content, opts)
+
+	if rec, err := ce.cache.Get(ctx, key); err == nil {
+		ce.hits.Inc()
+
+		return rec, nil
+	}
+	ce.misses.Inc()
+
+	rec, err := ce.engine.Recognize(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	_ = ce.cache.Set(ctx, key, rec)
+
+	return rec, nil
+}
+
+func (ce *CachedEngine) Close() error {
+	if err := ce.engine.Close(); err != nil {
+		return fmt.Errorf("close engine: %w", err)
+	}
+
+	return nil
+}
+
+// HealthCheck checks the underlying engine if it supports health checks.
+func (ce *CachedEngine) HealthCheck() error {
+	if hc, ok := ce.engine.(HealthChecker); ok {
+		return hc.HealthCheck()
+	}
+
+	return nil
+}
+
+func (ce *CachedEngine) Describe(ch chan<- *prometheus.Desc) {
+	ce.hits.Describe(ch)
+	ce.misses.Describe(ch)
+	if c, ok := ce.engine.(prometheus.Collector); ok {
+		c.Describe(ch)
+	}
+}
+
+func (ce *CachedEngine) Collect(ch chan<- prometheus.Metric) {
+	ce.hits.Collect(ch)
+	ce.misses.Collect(ch)
+	if c, ok := ce.engine.(prometheus.Collector); ok {
+		c.Collect(ch)
+	}
+}
@@ -0,0 +1,128 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// mapCache is an in-memory Cache.
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string]*Recognition
+	err     error
+}
+
+func (c *mapCache) Get(ctx context.Context, key string) (*Recognition, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	rec, ok := c.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	return rec, nil
+}
+
+func (c *mapCache) Set(ctx context.Context, key string, rec *Recognition) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*Recognition)
+	}
+	c.entries[key] = rec
+
+	return nil
+}
+
+// countingEngine counts recognitions.
+type countingEngine struct {
+	mockEngine
+	calls atomic.Int32
+}
+
+func (e *countingEngine) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	e.calls.Add(1)
+
+	return e.mockEngine.Recognize(ctx, content)
+}
+
+func TestCacheKey(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("image")
+	base := Options{Languages: []string{"eng"}, Variables: map[string]string{"a": "1", "b": "2"}}
+	key := CacheKey(content, base)
+
+	require.Len(t, key, 64)
+	require.Equal(t, key, CacheKey(content, Options{
+		Languages:  []string{"eng"},
+		Variables:  map[string]string{"b": "2", "a": "1"},
+		Preprocess: []string{"otsu"},
+		DebugDir:   "debug",
+	}), "preprocessing options don't change the key")
+	require.NotEqual(t, key, CacheKey([]byte("other"), base))
+	require.NotEqual(t, key, CacheKey(content, base.Merge(Options{PSM: PSMSingleBlock})))
+	require.NotEqual(t, key, CacheKey(content, base.Merge(Options{Languages: []string{"pol"}})))
+	require.Equal(t, CacheKey(content, Options{}), CacheKey(content, Options{Variables: map[string]string{}}))
+}
+
+func TestCachedEngine(t *testing.T) {
+	t.Parallel()
+
+	e := new(countingEngine)
+	cache := new(mapCache)
+	ce := NewCachedEngine(e, cache)
+
+	ctx := context.Background()
+	for range 3 {
+		rec, err := ce.Recognize(ctx, []byte("golang"))
+		require.NoError(t, err)
+		require.Equal(t, "golang", rec.Text)
+	}
+	require.Equal(t, int32(1), e.calls.Load())
+	require.InDelta(t, 2, testutil.ToFloat64(ce.hits), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(ce.misses), 0)
+
+	// Other options are recognized again
+	_, err := ce.Recognize(ContextWithOptions(ctx, Options{Languages: []string{"pol"}}), []byte("golang"))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), e.calls.Load())
+
+	// Failing cache doesn't fail recognition
+	cache.err = errors.New("cache down")
+	rec, err := ce.Recognize(ctx, []byte("golang"))
+	require.NoError(t, err)
+	require.Equal(t, "golang", rec.Text)
+	require.Equal(t, int32(3), e.calls.Load())
+
+	require.NoError(t, ce.Close())
+	require.True(t, e.closed.Load())
+}
+
+func TestScanFileCached(t *testing.T) {
+	t.Parallel()
+
+	e := new(countingEngine)
+	ce := NewCachedEngine(e, new(mapCache))
+
+	for range 2 {
+		res, err := ScanFile(context.Background(), ce, "testdata/golang_0.png")
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Text())
+	}
+	require.Equal(t, int32(1), e.calls.Load())
+}
@@ -0,0 +1,240 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheOptions configure eviction of cached recognitions.
+type CacheOptions struct {
+	// TTL is how long entries are valid. Zero keeps them until evicted.
+	TTL time.Duration
+
+	// MaxSize is the total size of entries in bytes above which least
+	// recently used ones are evicted. Zero disables the limit.
+	MaxSize int64
+}
+
+// DiskCache keeps recognitions as json files in a directory.
+// It's safe for concurrent use within a process.
+type DiskCache struct {
+	dir  string
+	opts CacheOptions
+
+	mu sync.Mutex
+	// size is the total size of entries, -1 until counted
+	size int64
+}
+
+var _ Cache = (*DiskCache)(nil)
+
+type diskEntry struct {
+	CreatedAt   time.Time    `json:"created_at"`
+	Recognition *Recognition `json:"recognition"`
+}
+
+// NewDiskCache creates dir if it doesn't exist.
+func NewDiskCache(dir string, opts CacheOptions) (*DiskCache, error) {
+	if dir == "" {
+		return nil, errors.New("cache dir cannot be empty")
+	}
+	dir = filepath.Clean(dir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+
+	return &DiskCache{dir: dir, opts: opts, size: -1}, nil
+}
+
+// path shards entries by the first byte of key, keys are hex encoded.
+func (c *DiskCache) path(key string) (string, error) {
+	if len(key) < 3 || strings.ContainsAny(key, `/\.`) {
+		return "", fmt.Errorf("invalid cache key %q", key)
+	}
+
+	return filepath.Join(c.dir, key[:2], key+".json"), nil
+}
+
+func (c *DiskCache) Get(ctx context.Context, key string) (*Recognition, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var entry diskEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Recognition == nil {
+		// Corrupted entries, e.g. of an interrupted write, are dropped
+		c.remove(path)
+
+		return nil, ErrCacheMiss
+	}
+	if c.expired(entry.CreatedAt) {
+		c.remove(path)
+
+		return nil, ErrCacheMiss
+	}
+
+	// Modification time tracks last use for eviction
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return entry.Recognition, nil
+}
+
+func (c *DiskCache) expired(createdAt time.Time) bool {
+	return c.opts.TTL > 0 && time.Since(createdAt) > c.opts.TTL
+}
+
+func (c *DiskCache) Set(ctx context.Context, key string, rec *Recognition) error {
+	if rec == nil {
+		return errors.New("recognition cannot be nil")
+	}
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(diskEntry{CreatedAt: time.Now(), Recognition: rec})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	// Written entries are complete, readers never see partial files
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var replaced int64
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if c.size >= 0 {
+		c.size += int64(len(data)) - replaced
+	}
+
+	if c.opts.MaxSize > 0 && (c.size < 0 || c.size > c.opts.MaxSize) {
+		if err := c.prune(); err != nil {
+			return fmt.Errorf("prune: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *DiskCache) remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if os.Remove(path) == nil && c.size >= 0 {
+		c.size -= info.Size()
+	}
+}
+
+// Prune removes expired entries and evicts least recently used ones until
+// entries fit in MaxSize.
+func (c *DiskCache) Prune(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.prune()
+}
+
+type diskFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *DiskCache) prune() error {
+	var files []diskFile
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("info: %w", err)
+		}
+		files = append(files, diskFile{path: path, size: info.Size(), modTime: info.ModTime()})
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk: %w", err)
+	}
+
+	// Oldest first
+	slices.SortFunc(files, func(a, b diskFile) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	for _, f := range files {
+		evict := c.opts.MaxSize > 0 && total > c.opts.MaxSize
+		if !evict && c.opts.TTL > 0 && time.Since(f.modTime) > c.opts.TTL {
+			// Last use is never earlier than creation, entries unused
+			// for longer than TTL are expired
+			evict = true
+		}
+		if !evict {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove: %w", err)
+		}
+		total -= f.size
+	}
+	c.size = total
+
+	return nil
+}
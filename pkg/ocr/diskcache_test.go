@@ -0,0 +1,97 @@
+package ocr
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCache(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewDiskCache(filepath.Join(t.TempDir(), "ocr"), CacheOptions{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := CacheKey([]byte("image"), Options{})
+
+	_, err = c.Get(ctx, key)
+	require.ErrorIs(t, err, ErrCacheMiss)
+
+	want := &Recognition{
+		Text:   "golang",
+		Engine: "tesseract",
+		Words:  []Box{{Text: "golang", Rect: image.Rect(1, 2, 30, 12), Confidence: 91.5, Block: 1, Line: 1}},
+	}
+	require.NoError(t, c.Set(ctx, key, want))
+
+	got, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	// Entries survive reopening
+	reopened, err := NewDiskCache(c.dir, CacheOptions{})
+	require.NoError(t, err)
+	got, err = reopened.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	require.Error(t, c.Set(ctx, "../escape", want))
+}
+
+func TestDiskCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewDiskCache(t.TempDir(), CacheOptions{TTL: time.Hour})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := CacheKey([]byte("image"), Options{})
+	require.NoError(t, c.Set(ctx, key, &Recognition{Text: "golang"}))
+
+	// Backdate the entry
+	path, err := c.path(key)
+	require.NoError(t, err)
+	entry := `{"created_at":"` + time.Now().Add(-2*time.Hour).Format(time.RFC3339Nano) +
+		`","recognition":{"Text":"golang"}}`
+	require.NoError(t, os.WriteFile(path, []byte(entry), 0o600))
+
+	_, err = c.Get(ctx, key)
+	require.ErrorIs(t, err, ErrCacheMiss)
+	require.NoFileExists(t, path)
+}
+
+func TestDiskCacheMaxSize(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewDiskCache(t.TempDir(), CacheOptions{MaxSize: 300})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	keys := make([]string, 0)
+	for i := range 5 {
+		key := CacheKey([]byte{byte(i)}, Options{})
+		keys = append(keys, key)
+		require.NoError(t, c.Set(ctx, key, &Recognition{Text: "kubernetes docker postgres"}))
+
+		// Distinct modification times order entries by use
+		path, err := c.path(key)
+		require.NoError(t, err)
+		at := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(path, at, at))
+	}
+
+	// The oldest entries were evicted, the newest is kept
+	_, err = c.Get(ctx, keys[0])
+	require.ErrorIs(t, err, ErrCacheMiss)
+	_, err = c.Get(ctx, keys[4])
+	require.NoError(t, err)
+
+	require.NoError(t, c.Prune(ctx))
+	require.LessOrEqual(t, c.size, int64(300))
+}
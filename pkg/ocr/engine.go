@@ -0,0 +1,53 @@
+package ocr
+
+import (
+	"context"
+	"image"
+)
+
+// Engine recognizes text in image content.
+//
+// Implementations are not required to be safe for concurrent use,
+// callers sharing an engine between goroutines must synchronize access.
+type Engine interface {
+	Recognize(ctx context.Context, content []byte) (*Recognition, error)
+	Close() error
+}
+
+// NewEngineFunc creates a new Engine, e.g. one per worker or per request.
+type NewEngineFunc func() (Engine, error)
+
+// Recognition is the output of an Engine for a single image.
+type Recognition struct {
+	Text string
+
+	// Engine names the backend which produced the recognition.
+	Engine string
+
+	// Words and Lines are optional layout details, engines which can't
+	// locate text leave them empty.
+	Words []Box
+	Lines []Box
+}
+
+// Box is a piece of recognized text with its position in the image.
+type Box struct {
+	Text string
+	Rect image.Rectangle
+
+	// Confidence of the recognition, in range 0-100.
+	Confidence float64
+
+	// Block, Paragraph and Line locate a word in the page layout.
+	// They are zero for line boxes.
+	Block     int
+	Paragraph int
+	Line      int
+
+	// Page is the number of the page the box was found on, set in results.
+	Page int
+
+	// Region names the region the box was found in, if page was recognized
+	// region by region.
+	Region string
+}
@@ -0,0 +1,98 @@
+package ocr
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FixtureExt is appended to an image path to get the path of its sidecar
+// ground truth file, e.g. "offer.png" -> "offer.png.gt". Sidecars aren't
+// text documents scanned along with images of directories, see
+// textdoc.Detect.
+const FixtureExt = ".gt"
+
+const fixtureEngineName = "fixture"
+
+var ErrNoFixture = errors.New("no fixture for image content")
+
+// FixtureEngine is a deterministic Engine which returns ground truth text
+// read from sidecar files stored next to images.
+//
+// Images are matched by their content, not by path, so the engine works
+// for readers and uploaded files as well. It is safe for concurrent use.
+type FixtureEngine struct {
+	texts map[[sha256.Size]byte]string
+}
+
+var _ Engine = (*FixtureEngine)(nil)
+
+// NewFixtureEngine walks dir and loads every image which has a sidecar file.
+// Images without a sidecar are ignored.
+func NewFixtureEngine(dir string) (*FixtureEngine, error) {
+	e := &FixtureEngine{
+		texts: make(map[[sha256.Size]byte]string),
+	}
+
+	err := filepath.WalkDir(filepath.Clean(dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk: %w", err)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		text, err := os.ReadFile(path + FixtureExt)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return fmt.Errorf("read fixture: %w", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read image: %w", err)
+		}
+		if !IsImage(content) {
+			return nil
+		}
+		e.texts[sha256.Sum256(content)] = string(text)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load fixtures: %w", err)
+	}
+
+	return e, nil
+}
+
+// Len returns the number of loaded fixtures.
+func (e *FixtureEngine) Len() int {
+	return len(e.texts)
+}
+
+func (e *FixtureEngine) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	text, ok := e.texts[sha256.Sum256(content)]
+	if !ok {
+		return nil, ErrNoFixture
+	}
+
+	return &Recognition{
+		Text:   text,
+		Engine: fixtureEngineName,
+	}, nil
+}
+
+func (e *FixtureEngine) Close() error {
+	return nil
+}
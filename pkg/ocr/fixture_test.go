@@ -0,0 +1,60 @@
+package ocr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtureEngineRecognize(t *testing.T) {
+	t.Parallel()
+
+	e := testEngine(t)
+	require.Equal(t, 5, e.Len())
+
+	testCases := []struct {
+		desc string
+
+		content []byte
+		want    string
+		wantErr error
+	}{
+		{
+			desc: "returns_sidecar_text_for_known_image",
+
+			content: readTestFile(t, "golang_1.png"),
+			want:    string(readTestFile(t, "golang_1.png"+FixtureExt)),
+		},
+		{
+			desc: "unknown_image_err",
+
+			content: []byte{137, 80, 78, 71, 13, 10, 26, 10, 0},
+			wantErr: ErrNoFixture,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rec, err := e.Recognize(context.Background(), tC.content)
+			if tC.wantErr != nil {
+				require.ErrorIs(t, err, tC.wantErr)
+
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.want, rec.Text)
+			require.Equal(t, fixtureEngineName, rec.Engine)
+		})
+	}
+}
+
+func readTestFile(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	return data
+}
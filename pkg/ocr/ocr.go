@@ -2,63 +2,147 @@ package ocr
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"image"
 	"io"
+	"iter"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 
+	"github.com/kndrad/piccrack/pkg/imgproc"
 	"github.com/kndrad/piccrack/pkg/imgsniff"
 	"github.com/kndrad/piccrack/pkg/pproc"
-	"github.com/otiai10/gosseract/v2"
+	"github.com/kndrad/piccrack/pkg/textproc"
 )
 
 var MaxImageSize int = 10 * 1024 * 1024 // 10MB
 
-func NewClient() *gosseract.Client {
-	client := gosseract.NewClient()
-	client.Trim = true
-	client.SetWhitelist(
-		"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789 \n",
-	)
+var ErrNotAnImage = errors.New("not an image")
 
-	return client
-}
+// MaxContentSize limits images and documents read by ScanFrom, it matches
+// the default size limit of uploads and scanned files.
+var MaxContentSize int64 = 50 * 1024 * 1024 // 50MB
 
-var ErrNotAnImage = errors.New("not an image")
+var ErrContentTooLarge = errors.New("content too large")
 
-// scan is a wrapper around tesseract client with additional content validation
-// performed before returning text.
-func scan(tc *gosseract.Client, content []byte) (string, error) {
-	if tc == nil {
-		panic("tesseract client cannot be nil")
+// scan is a wrapper around ocr engine with additional content validation
+// performed before returning text. Multi-page images are split and every
+// page is recognized separately, pdf documents are read page by page.
+// Images are preprocessed and cropped into regions according to options
+// found in ctx.
+func scan(ctx context.Context, e Engine, content []byte) ([]Page, error) {
+	if e == nil {
+		panic("engine cannot be nil")
 	}
 
 	if content == nil {
 		panic("content cannot be nil")
 	}
+
+	opts, _ := OptionsFromContext(ctx)
+
+	if IsPDF(content) {
+		if len(opts.Regions) > 0 {
+			return nil, fmt.Errorf("%w: regions of pdf documents", ErrInvalidOptions)
+		}
+		recs, err := scanPDF(ctx, e, content)
+		if err != nil {
+			return nil, err
+		}
+
+		return recognitionPages(recs), nil
+	}
 	if !IsImage(content) {
-		return "", ErrNotAnImage
+		return nil, ErrNotAnImage
+	}
+
+	images, err := splitPages(content)
+	if err != nil {
+		return nil, fmt.Errorf("split pages: %w", err)
+	}
+
+	pages := make([]Page, 0, len(images))
+	for i, img := range images {
+		page, err := recognizePage(ctx, e, i+1, img, opts)
+		if err != nil {
+			return nil, fmt.Errorf("recognize page %d: %w", i+1, err)
+		}
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+// recognizePage recognizes a single image as page n, region by region if
+// options have any.
+func recognizePage(ctx context.Context, e Engine, n int, content []byte, opts Options) (Page, error) {
+	if len(opts.Regions) > 0 {
+		return recognizeRegions(ctx, e, n, content, opts)
+	}
+
+	rec, err := recognize(ctx, e, content, opts)
+	if err != nil {
+		return Page{}, err
+	}
+
+	return recognitionPage(n, rec), nil
+}
+
+// recognize preprocesses a single image and runs the engine on it.
+func recognize(ctx context.Context, e Engine, content []byte, opts Options) (*Recognition, error) {
+	if len(opts.Preprocess) > 0 {
+		var err error
+		content, err = preprocess(content, opts)
+		if err != nil {
+			return nil, fmt.Errorf("preprocess: %w", err)
+		}
+	}
+
+	rec, err := e.Recognize(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("engine: %w", err)
 	}
 
-	if err := tc.SetImageFromBytes(content); err != nil {
-		return "", fmt.Errorf("set image: %w", err)
+	return rec, nil
+}
+
+// preprocess runs imgproc chain configured in opts on content. Intermediate
+// images are named after content checksum when dumped.
+func preprocess(content []byte, opts Options) ([]byte, error) {
+	chain, err := imgproc.ParseChain(opts.Preprocess)
+	if err != nil {
+		return nil, fmt.Errorf("parse chain: %w", err)
+	}
+
+	var dump imgproc.DumpFunc
+	if opts.DebugDir != "" {
+		sum := sha256.Sum256(content)
+		dump = imgproc.DirDump(opts.DebugDir, hex.EncodeToString(sum[:6]))
+	}
+
+	processed, err := chain.Process(content, dump)
+	if errors.Is(err, image.ErrFormat) {
+		// No pure Go decoder, e.g. jpeg 2000, engine gets the original
+		return content, nil
 	}
-	text, err := tc.Text()
 	if err != nil {
-		return "", fmt.Errorf("text: %w", err)
+		return nil, fmt.Errorf("process: %w", err)
 	}
 
-	return text, nil
+	return processed, nil
 }
 
-// ScanFile performs OCR on an image file.
+// ScanFile performs OCR on an image or pdf file.
 // Image content validation is performed before ocr.
-func ScanFile(tc *gosseract.Client, path string) (*Result, error) {
-	if tc == nil {
-		panic("tesseract client can't be nil")
+func ScanFile(ctx context.Context, e Engine, path string) (*Result, error) {
+	if e == nil {
+		panic("engine can't be nil")
 	}
 	if path == "" {
 		panic("path can't be empty")
@@ -70,18 +154,113 @@ func ScanFile(tc *gosseract.Client, path string) (*Result, error) {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
 
-	text, err := scan(tc, content)
+	pages, err := scan(ctx, e, content)
 	if err != nil {
 		return nil, fmt.Errorf("scan: %w", err)
 	}
 
-	return &Result{path: path, content: content, text: text}, nil
+	return pagedResult(path, content, pages), nil
 }
 
 type Result struct {
 	path    string
 	content []byte
 	text    string
+	words   []Box
+	lines   []Box
+	pages   []Page
+}
+
+// recognitionPages creates pages from recognitions of consecutive pages.
+func recognitionPages(recs []*Recognition) []Page {
+	pages := make([]Page, 0, len(recs))
+	for i, rec := range recs {
+		pages = append(pages, recognitionPage(i+1, rec))
+	}
+
+	return pages
+}
+
+func recognitionPage(n int, rec *Recognition) Page {
+	return Page{
+		Number: n,
+		Text:   rec.Text,
+		Words:  onPage(rec.Words, n),
+		Lines:  onPage(rec.Lines, n),
+	}
+}
+
+// pagedResult joins text and boxes of pages. Non empty page texts are
+// separated by an empty line.
+func pagedResult(path string, content []byte, pages []Page) *Result {
+	res := &Result{
+		path:    path,
+		content: content,
+		pages:   pages,
+	}
+	texts := make([]string, 0, len(pages))
+	for _, p := range pages {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+		res.words = append(res.words, p.Words...)
+		res.lines = append(res.lines, p.Lines...)
+	}
+	res.text = strings.Join(texts, "\n\n")
+
+	return res
+}
+
+func onPage(boxes []Box, n int) []Box {
+	if boxes == nil {
+		return nil
+	}
+	out := make([]Box, len(boxes))
+	for i, b := range boxes {
+		b.Page = n
+		out[i] = b
+	}
+
+	return out
+}
+
+// Pages returns recognized text of every page. Single page images have
+// a single page numbered 1.
+func (res *Result) Pages() []Page {
+	if res == nil {
+		return nil
+	}
+	if len(res.pages) > 0 {
+		return res.pages
+	}
+
+	return []Page{{Number: 1, Text: res.text, Words: res.words, Lines: res.lines}}
+}
+
+// Regions returns recognized text of regions of every page, in order.
+// It's empty if the scan had no regions.
+func (res *Result) Regions() []RegionText {
+	var regions []RegionText
+	for _, p := range res.Pages() {
+		regions = append(regions, p.Regions...)
+	}
+
+	return regions
+}
+
+// PageWords yields words in order, with number of the page they were found
+// on. Words are tokenized with textproc.Words, numbers, URLs and email
+// addresses are skipped.
+func (res *Result) PageWords() iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		for _, p := range res.Pages() {
+			for _, w := range textproc.Words(p.Text) {
+				if !yield(p.Number, w) {
+					return
+				}
+			}
+		}
+	}
 }
 
 func (res *Result) String() string {
@@ -96,53 +275,163 @@ func (res *Result) Text() string {
 	return res.text
 }
 
-func (res *Result) Words() <-chan string {
-	var wg sync.WaitGroup
+// WordBoxes returns recognized words with their positions and confidence.
+// It's empty if the engine doesn't report layout details.
+func (res *Result) WordBoxes() []Box {
+	if res == nil {
+		return nil
+	}
 
-	out := make(chan string)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	return res.words
+}
 
-		for _, v := range strings.Fields(res.text) {
-			wg.Add(1)
+// LineBoxes returns recognized lines with their positions and confidence.
+// It's empty if the engine doesn't report layout details.
+func (res *Result) LineBoxes() []Box {
+	if res == nil {
+		return nil
+	}
 
-			go func() {
-				defer wg.Done()
+	return res.lines
+}
 
-				out <- strings.ToLower(v)
-			}()
+// LowConfidence returns word boxes recognized with confidence below min,
+// so they can be flagged for review.
+func (res *Result) LowConfidence(min float64) []Box {
+	low := make([]Box, 0)
+	for _, w := range res.WordBoxes() {
+		if w.Confidence < min {
+			low = append(low, w)
 		}
-	}()
+	}
+
+	return low
+}
+
+// WithMinConfidence returns a copy of the result which keeps only words
+// recognized with confidence of at least min. Text is rebuilt from the kept
+// words, preserving lines and separating paragraphs with an empty line,
+// so both Words and line based consumers see filtered content.
+//
+// Results without word boxes are returned unchanged.
+func (res *Result) WithMinConfidence(min float64) *Result {
+	if res == nil || len(res.words) == 0 || min <= 0 {
+		return res
+	}
+
+	pages := make([]Page, 0, len(res.Pages()))
+	for _, p := range res.Pages() {
+		page := Page{Number: p.Number, Text: p.Text}
+		page.Words, page.Lines = confident(p.Words, min), confident(p.Lines, min)
+		if len(p.Words) > 0 {
+			page.Text = joinWords(page.Words)
+		}
+		if len(p.Regions) > 0 {
+			texts := make([]string, 0, len(p.Regions))
+			for _, r := range p.Regions {
+				region := RegionText{Name: r.Name, Page: r.Page, Text: r.Text}
+				region.Words, region.Lines = confident(r.Words, min), confident(r.Lines, min)
+				if len(r.Words) > 0 {
+					region.Text = joinWords(region.Words)
+				}
+				if region.Text != "" {
+					texts = append(texts, region.Text)
+				}
+				page.Regions = append(page.Regions, region)
+			}
+			page.Text = strings.Join(texts, "\n\n")
+		}
+		pages = append(pages, page)
+	}
+
+	return pagedResult(res.path, res.content, pages)
+}
+
+// confident returns boxes recognized with confidence of at least min.
+func confident(boxes []Box, min float64) []Box {
+	kept := make([]Box, 0, len(boxes))
+	for _, b := range boxes {
+		if b.Confidence >= min {
+			kept = append(kept, b)
+		}
+	}
+
+	return kept
+}
+
+// joinWords rebuilds text from word boxes ordered as recognized.
+func joinWords(words []Box) string {
+	b := new(strings.Builder)
+	for i, w := range words {
+		if i > 0 {
+			prev := words[i-1]
+			switch {
+			case prev.Block != w.Block || prev.Paragraph != w.Paragraph:
+				b.WriteString("\n\n")
+			case prev.Line != w.Line:
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(w.Text)
+	}
+
+	return b.String()
+}
+
+// Words sends words of the text in order, see PageWords. The channel must
+// be drained.
+func (res *Result) Words() <-chan string {
+	out := make(chan string)
 	go func() {
-		wg.Wait()
-		close(out)
+		defer close(out)
+
+		for _, w := range textproc.Words(res.Text()) {
+			out <- w
+		}
 	}()
 
 	return out
 }
 
-// IsImage checks content (sniffs) if it's jpg or png.
+// IsImage checks content (sniffs) if it's in one of image formats detected
+// by imgsniff: png, jpeg, gif, webp, bmp, tiff or jpeg 2000.
 func IsImage(content []byte) bool {
-	return imgsniff.IsJPG(content) || imgsniff.IsPNG(content)
+	return imgsniff.IsImage(content)
+}
+
+// IsScannable reports whether content is an image or a pdf document.
+func IsScannable(content []byte) bool {
+	return IsImage(content) || IsPDF(content)
 }
 
 // ScanDir performs ocr on every image found in a directory.
-func ScanDir(ctx context.Context, tc *gosseract.Client, root string) ([]*Result, error) {
+func ScanDir(ctx context.Context, e Engine, root string) ([]*Result, error) {
 	images := make([]*pproc.Entry, 0)
 
-	entries, err := pproc.Walk(ctx, root, IsImage)
+	// Walk stops if entries aren't drained because of an error
+	walkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	entries, err := pproc.WalkWithOptions(walkCtx, root, pproc.WalkOptions{Sniff: IsScannable})
 	if err != nil {
 		return nil, fmt.Errorf("error during walk: %w", err)
 	}
 	for entry := range entries {
+		if err := entry.Err(); err != nil {
+			return nil, err
+		}
 		images = append(images, entry)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Drain entries and run ocr
 	results := make([]*Result, 0)
 	for _, img := range images {
-		res, err := ScanFile(tc, img.Path())
+		res, err := ScanFile(ctx, e, img.Path())
 		if err != nil {
 			return nil, fmt.Errorf("do: %w", err)
 		}
@@ -152,44 +441,194 @@ func ScanDir(ctx context.Context, tc *gosseract.Client, root string) ([]*Result,
 	return results, nil
 }
 
-func ScanFrom(tc *gosseract.Client, r io.Reader) (*Result, error) {
-	if tc == nil {
-		return nil, errors.New("tesseract client cannot be nil")
+// ScanFrom performs ocr on image or pdf read from r, see ScanContent. Reading
+// fails with ErrContentTooLarge once more than MaxContentSize bytes were
+// read.
+func ScanFrom(ctx context.Context, e Engine, r io.Reader) (*Result, error) {
+	if e == nil {
+		return nil, errors.New("engine cannot be nil")
 	}
 	if r == nil {
 		return nil, errors.New("reader is nil")
 	}
 
-	content, err := readFull(r)
+	content, err := readContent(r)
 	if err != nil {
-		return nil, fmt.Errorf("read full: %w", err)
+		return nil, fmt.Errorf("read content: %w", err)
 	}
 
-	text, err := scan(tc, content)
+	return ScanContent(ctx, e, content)
+}
+
+// ScanContent performs ocr on image or pdf content held in memory already,
+// e.g. read by a directory walk.
+func ScanContent(ctx context.Context, e Engine, content []byte) (*Result, error) {
+	if e == nil {
+		return nil, errors.New("engine cannot be nil")
+	}
+	if content == nil {
+		return nil, errors.New("content is nil")
+	}
+
+	pages, err := scan(ctx, e, content)
 	if err != nil {
 		return nil, fmt.Errorf("scan: %w", err)
 	}
 
-	return &Result{
-		path:    "",
-		text:    text,
-		content: content,
-	}, nil
+	return pagedResult("", content, pages), nil
 }
 
-func readFull(r io.Reader) ([]byte, error) {
-	b := make([]byte, 0, 500*1024) // Cap is 500KB (512000 bytes)
+// readContent reads r whole, up to MaxContentSize bytes.
+func readContent(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxContentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read all: %w", err)
+	}
+	if int64(len(content)) > MaxContentSize {
+		return nil, fmt.Errorf("more than %d bytes: %w", MaxContentSize, ErrContentTooLarge)
+	}
 
-	for {
-		n, err := r.Read(b[len(b):cap(b)])
-		if err != nil {
-			if !errors.Is(err, io.EOF) {
-				return nil, fmt.Errorf("unexpected error: %w", err)
+	return content, nil
+}
+
+// FileResult is the outcome of scanning a single file.
+// It carries either a result or an error.
+type FileResult struct {
+	Path   string
+	Result *Result
+	Err    error
+}
+
+// ScanDirConcurrent performs ocr on every image found in root using a fixed
+// number of workers, each owning its own engine created with newEngine.
+// If workers is not positive, the number of CPUs is used.
+//
+// Results are streamed as soon as they finish. A file which fails to scan is
+// reported with its error and doesn't stop the others.
+// The channel is closed after every image is processed or ctx is done.
+func ScanDirConcurrent(ctx context.Context, newEngine NewEngineFunc, root string, workers int) (<-chan *FileResult, error) {
+	if newEngine == nil {
+		panic("new engine func cannot be nil")
+	}
+
+	// Walk stops if entries aren't drained because engines fail
+	walkCtx, cancel := context.WithCancel(ctx)
+
+	entries, err := pproc.WalkWithOptions(walkCtx, root, pproc.WalkOptions{Sniff: IsScannable})
+	if err != nil {
+		cancel()
+
+		return nil, fmt.Errorf("error during walk: %w", err)
+	}
+
+	jobs := make(chan ScanJob)
+	results, err := ScanConcurrent(ctx, newEngine, jobs, workers)
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	go func() {
+		defer close(jobs)
+		defer cancel()
+
+		for entry := range entries {
+			job := ScanJob{
+				Path: entry.Path(),
+				Read: func() ([]byte, error) { return entry.Content(), entry.Err() },
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
 			}
 		}
-		if n <= 0 {
-			return b, nil
+	}()
+
+	return results, nil
+}
+
+// ScanJob is a file scanned by ScanConcurrent. Read returns its content,
+// it's called by the worker which scans the file.
+type ScanJob struct {
+	Path string
+	Read func() ([]byte, error)
+}
+
+// ScanConcurrent performs ocr on files of jobs using a fixed number of
+// workers, each owning its own engine created with newEngine. If workers is
+// not positive, the number of CPUs is used. Engines are closed once jobs is
+// closed or ctx is done, and every worker returned.
+//
+// Results are streamed as soon as they finish, see ScanDirConcurrent.
+// Receivers must drain the channel or cancel ctx.
+func ScanConcurrent(ctx context.Context, newEngine NewEngineFunc, jobs <-chan ScanJob, workers int) (<-chan *FileResult, error) {
+	if newEngine == nil {
+		panic("new engine func cannot be nil")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	engines := make([]Engine, 0, workers)
+	closeEngines := func() {
+		for _, e := range engines {
+			e.Close()
+		}
+	}
+	for range workers {
+		e, err := newEngine()
+		if err != nil {
+			closeEngines()
+
+			return nil, fmt.Errorf("new engine: %w", err)
 		}
-		b = b[:len(b)+n]
+		engines = append(engines, e)
 	}
+
+	out := make(chan *FileResult)
+
+	var wg sync.WaitGroup
+	for _, e := range engines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				var job ScanJob
+				select {
+				case j, ok := <-jobs:
+					if !ok {
+						return
+					}
+					job = j
+				case <-ctx.Done():
+					return
+				}
+
+				fr := &FileResult{Path: job.Path}
+				if content, err := job.Read(); err != nil {
+					fr.Err = err
+				} else if pages, err := scan(ctx, e, content); err != nil {
+					fr.Err = fmt.Errorf("scan: %w", err)
+				} else {
+					fr.Result = pagedResult(job.Path, content, pages)
+				}
+
+				select {
+				case out <- fr:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		closeEngines()
+		close(out)
+	}()
+
+	return out, nil
 }
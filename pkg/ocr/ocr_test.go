@@ -3,14 +3,29 @@ package ocr
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
+func testEngine(t *testing.T) *FixtureEngine {
+	t.Helper()
+
+	e, err := NewFixtureEngine("testdata")
+	require.NoError(t, err)
+
+	return e
+}
+
 func TestIsImage(t *testing.T) {
 	t.Parallel()
 
@@ -32,6 +47,24 @@ func TestIsImage(t *testing.T) {
 			entry: filepath.Join("testdata", "jpg_offer.jpg"),
 			want:  true,
 		},
+		{
+			desc: "real_jpeg_is_fine",
+
+			entry: filepath.Join("..", "imgsniff", "testdata", "golang.jpg"),
+			want:  true,
+		},
+		{
+			desc: "gif_is_fine",
+
+			entry: filepath.Join("..", "imgsniff", "testdata", "golang.gif"),
+			want:  true,
+		},
+		{
+			desc: "tiff_is_fine",
+
+			entry: filepath.Join("..", "imgsniff", "testdata", "golang.tiff"),
+			want:  true,
+		},
 		{
 			desc: "invalid_file_err",
 
@@ -66,10 +99,9 @@ func TestScanFile(t *testing.T) {
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
-			tc := NewClient()
-			defer tc.Close()
+			e := testEngine(t)
 
-			result, err := ScanFile(tc, tC.path)
+			result, err := ScanFile(context.Background(), e, tC.path)
 			require.NoError(t, err)
 
 			require.NotNil(t, result)
@@ -78,6 +110,40 @@ func TestScanFile(t *testing.T) {
 	}
 }
 
+func TestScanFilePreprocess(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join("testdata", "golang_0.png")
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	ctx := ContextWithOptions(context.Background(), Options{
+		Preprocess: []string{"grayscale", "otsu"},
+		DebugDir:   dir,
+	})
+
+	// Mock engine recognizes content as text, which exposes what it received
+	result, err := ScanFile(ctx, new(mockEngine), path)
+	require.NoError(t, err)
+	require.NotEqual(t, string(original), result.Text())
+
+	img, err := png.Decode(strings.NewReader(result.Text()))
+	require.NoError(t, err)
+	require.IsType(t, &image.Gray{}, img)
+
+	dumps, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	require.NoError(t, err)
+	require.Len(t, dumps, 3)
+
+	_, err = ScanFile(
+		ContextWithOptions(context.Background(), Options{Preprocess: []string{"sharpen"}}),
+		new(mockEngine),
+		path,
+	)
+	require.Error(t, err)
+}
+
 func TestResultWords(t *testing.T) {
 	t.Parallel()
 
@@ -112,25 +178,253 @@ func TestResultWords(t *testing.T) {
 	}
 }
 
+func TestResultWordsInOrder(t *testing.T) {
+	t.Parallel()
+
+	res := &Result{text: "Kubernetes, (kubernetes) C++ and Node.js\nCI/CD: 3+ years"}
+
+	words := make([]string, 0)
+	for w := range res.Words() {
+		words = append(words, w)
+	}
+	require.Equal(t, []string{"kubernetes", "kubernetes", "c++", "and", "node.js", "ci/cd", "years"}, words)
+}
+
+func TestResultWithMinConfidence(t *testing.T) {
+	t.Parallel()
+
+	res := &Result{
+		text: "kubernetes x7$ docker\nhelm\n\ngo",
+		words: []Box{
+			{Text: "kubernetes", Confidence: 96, Block: 1, Paragraph: 1, Line: 1},
+			{Text: "x7$", Confidence: 21, Block: 1, Paragraph: 1, Line: 1},
+			{Text: "docker", Confidence: 91, Block: 1, Paragraph: 1, Line: 1},
+			{Text: "helm", Confidence: 88, Block: 1, Paragraph: 1, Line: 2},
+			{Text: "go", Confidence: 75, Block: 2, Paragraph: 1, Line: 1},
+		},
+		lines: []Box{
+			{Text: "kubernetes x7$ docker", Confidence: 70},
+			{Text: "helm", Confidence: 88},
+			{Text: "go", Confidence: 75},
+		},
+	}
+
+	testCases := []struct {
+		desc string
+
+		min       float64
+		wantText  string
+		wantLines int
+		wantLow   int
+	}{
+		{
+			desc: "zero_keeps_everything",
+
+			min:       0,
+			wantText:  res.text,
+			wantLines: 3,
+			wantLow:   0,
+		},
+		{
+			desc: "drops_low_confidence_words",
+
+			min:       50,
+			wantText:  "kubernetes docker\nhelm\n\ngo",
+			wantLines: 3,
+			wantLow:   1,
+		},
+		{
+			desc: "drops_lines_as_well",
+
+			min:       80,
+			wantText:  "kubernetes docker\nhelm",
+			wantLines: 1,
+			wantLow:   2,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filtered := res.WithMinConfidence(tC.min)
+
+			require.Equal(t, tC.wantText, filtered.Text())
+			require.Len(t, filtered.LineBoxes(), tC.wantLines)
+			require.Len(t, res.LowConfidence(tC.min), tC.wantLow)
+
+			words := make([]string, 0)
+			for w := range filtered.Words() {
+				words = append(words, w)
+			}
+			require.Len(t, words, len(strings.Fields(tC.wantText)))
+		})
+	}
+}
+
+func TestResultWithMinConfidenceWithoutBoxes(t *testing.T) {
+	t.Parallel()
+
+	res := &Result{text: "no layout details"}
+	require.Same(t, res, res.WithMinConfidence(90))
+}
+
 func TestScanDir(t *testing.T) {
 	t.Parallel()
 
-	tc := NewClient()
-	defer tc.Close()
+	e := testEngine(t)
 
-	results, err := ScanDir(context.Background(), tc, "testdata")
+	results, err := ScanDir(context.Background(), e, "testdata")
 	require.NoError(t, err)
+	require.Len(t, results, e.Len())
 
 	for _, res := range results {
 		fmt.Printf("got result: len of text: %d\n", len(res.Text()))
 	}
 }
 
-func TestScanFrom(t *testing.T) {
+func TestScanDirConcurrent(t *testing.T) {
+	t.Parallel()
+
+	// Directory with one known image and one which can't be recognized
+	dir := t.TempDir()
+	for _, name := range []string{"golang_1.png", "golang_1.png" + FixtureExt} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+	corrupt := []byte{137, 80, 78, 71, 13, 10, 26, 10, 1, 2, 3}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "corrupt.png"), corrupt, 0o600))
+
+	testCases := []struct {
+		desc string
+
+		root    string
+		workers int
+
+		wantResults int
+		wantErrs    int
+	}{
+		{
+			desc: "scans_every_image_with_many_workers",
+
+			root:        "testdata",
+			workers:     3,
+			wantResults: 5,
+		},
+		{
+			desc: "defaults_workers_to_num_cpu",
+
+			root:        "testdata",
+			workers:     0,
+			wantResults: 5,
+		},
+		{
+			desc: "reports_per_file_errors_and_keeps_going",
+
+			root:        dir,
+			workers:     2,
+			wantResults: 1,
+			wantErrs:    1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			e, err := NewFixtureEngine(tC.root)
+			require.NoError(t, err)
+			newEngine := func() (Engine, error) { return e, nil }
+
+			results, err := ScanDirConcurrent(context.Background(), newEngine, tC.root, tC.workers)
+			require.NoError(t, err)
+
+			var gotResults, gotErrs int
+			for fr := range results {
+				require.NotEmpty(t, fr.Path)
+				if fr.Err != nil {
+					require.ErrorIs(t, fr.Err, ErrNoFixture)
+					gotErrs++
+
+					continue
+				}
+				require.NotEmpty(t, fr.Result.Text())
+				gotResults++
+			}
+			require.Equal(t, tC.wantResults, gotResults)
+			require.Equal(t, tC.wantErrs, gotErrs)
+		})
+	}
+}
+
+func TestScanDirConcurrentNewEngineErr(t *testing.T) {
+	t.Parallel()
+
+	errEngine := errors.New("engine: failed")
+	newEngine := func() (Engine, error) { return nil, errEngine }
+
+	_, err := ScanDirConcurrent(context.Background(), newEngine, "testdata", 2)
+	require.ErrorIs(t, err, errEngine)
+}
+
+func TestScanConcurrent(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "golang_1.png"))
+	require.NoError(t, err)
+	errRead := errors.New("read: failed")
+
+	engines := new(mockEngines)
+	jobs := make(chan ScanJob)
+	results, err := ScanConcurrent(context.Background(), engines.New, jobs, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, engines.Len())
+
+	go func() {
+		defer close(jobs)
+
+		for i := range 10 {
+			job := ScanJob{Path: fmt.Sprintf("%d.png", i), Read: func() ([]byte, error) { return content, nil }}
+			if i == 5 {
+				job.Read = func() ([]byte, error) { return nil, errRead }
+			}
+			jobs <- job
+		}
+	}()
+
+	paths := make(map[string]bool)
+	for fr := range results {
+		paths[fr.Path] = true
+		if fr.Path == "5.png" {
+			require.ErrorIs(t, fr.Err, errRead)
+
+			continue
+		}
+		require.NoError(t, fr.Err)
+		require.Equal(t, fr.Path, fr.Result.path)
+	}
+	require.Len(t, paths, 10)
+
+	// Engines are closed once results are drained
+	for _, e := range engines.engines {
+		require.True(t, e.closed.Load())
+	}
+}
+
+func TestScanConcurrentCanceled(t *testing.T) {
 	t.Parallel()
 
-	tc := NewClient()
-	defer tc.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	engines := new(mockEngines)
+	results, err := ScanConcurrent(ctx, engines.New, make(chan ScanJob), 2)
+	require.NoError(t, err)
+
+	cancel()
+	for range results {
+	}
+	for _, e := range engines.engines {
+		require.True(t, e.closed.Load())
+	}
+}
+
+func TestScanFrom(t *testing.T) {
+	t.Parallel()
 
 	testCases := []struct {
 		desc string
@@ -148,10 +442,9 @@ func TestScanFrom(t *testing.T) {
 			require.NoError(t, err)
 			defer f.Close()
 
-			tc := NewClient()
-			defer tc.Close()
+			e := testEngine(t)
 
-			res, err := ScanFrom(tc, f)
+			res, err := ScanFrom(context.Background(), e, f)
 			require.NoError(t, err)
 
 			fmt.Printf("RESULT: %#v\n", res)
@@ -159,43 +452,102 @@ func TestScanFrom(t *testing.T) {
 	}
 }
 
-func TestReadFull(t *testing.T) {
+func TestReadContent(t *testing.T) {
 	t.Parallel()
 
 	testCases := []struct {
 		desc string
 
-		data    []byte
-		wantBuf []byte
-		wantLen int
+		size    int64
+		wantErr error
 	}{
 		{
 			desc: "small_image",
 
-			data:    make([]byte, 1024*5000), // 5000 KB
-			wantLen: 1024 * 5000,
+			size: 1024 * 5000, // 5000 KB
 		},
 		{
 			desc: "huge_image",
 
-			data:    make([]byte, 10*1024*1024+1), // More than 10MB
-			wantLen: 10*1024*1024 + 1,
+			size: 10*1024*1024 + 1, // More than 10MB
+		},
+		{
+			desc: "max_size",
+
+			size: MaxContentSize,
+		},
+		{
+			desc: "too_large_err",
+
+			size:    MaxContentSize + 1,
+			wantErr: ErrContentTooLarge,
 		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
-			for i := 0; i < len(tC.data); i++ {
-				tC.data[i] = 1
-			}
+			r := io.LimitReader(repeatReader(1), tC.size)
 
-			r := bytes.NewBuffer(tC.data)
+			buf, err := readContent(r)
+			if tC.wantErr != nil {
+				require.ErrorIs(t, err, tC.wantErr)
 
-			buf, err := readFull(r)
+				return
+			}
 			require.NoError(t, err)
-
-			t.Logf("Len: %d\n", len(buf))
+			require.Len(t, buf, int(tC.size))
 		})
 	}
 }
 
-// TODO: ScanFrom tests
+// repeatReader reads the same byte forever.
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+
+	return len(p), nil
+}
+
+// noisePNG encodes random pixels of a square image, which barely compress,
+// so the image is bigger than its side squared.
+func noisePNG(t *testing.T, side int) []byte {
+	t.Helper()
+
+	r := rand.New(rand.NewPCG(1, 2))
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.IntN(256))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+// decodeEngine recognizes images as their size once they're decoded whole,
+// so it fails on truncated images.
+type decodeEngine struct{}
+
+func (decodeEngine) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recognition{Text: fmt.Sprintf("%dx%d", img.Bounds().Dx(), img.Bounds().Dy())}, nil
+}
+
+func (decodeEngine) Close() error { return nil }
+
+func TestScanFromLargeImage(t *testing.T) {
+	t.Parallel()
+
+	content := noisePNG(t, 1000)
+	require.Greater(t, len(content), 512*1024)
+
+	res, err := ScanFrom(context.Background(), decodeEngine{}, bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, "1000x1000", res.Text())
+}
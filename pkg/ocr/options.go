@@ -0,0 +1,168 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kndrad/piccrack/pkg/imgproc"
+)
+
+// PSM is a Tesseract page segmentation mode, numbered as in Tesseract.
+// Mode 0 (orientation detection only) yields no text, so the zero value
+// leaves the choice to the engine instead.
+type PSM int
+
+const (
+	PSMDefault PSM = iota
+	PSMAutoOSD
+	PSMAutoOnly
+	PSMAuto
+	PSMSingleColumn
+	PSMSingleBlockVertText
+	PSMSingleBlock
+	PSMSingleLine
+	PSMSingleWord
+	PSMCircleWord
+	PSMSingleChar
+	PSMSparseText
+	PSMSparseTextOSD
+	PSMRawLine
+)
+
+// Options tune recognition. Zero values leave engine defaults in place.
+type Options struct {
+	// Languages are Tesseract language codes, e.g. "eng" or "pol".
+	Languages []string
+
+	PSM PSM
+
+	// Whitelist and Blacklist restrict recognized characters.
+	Whitelist string
+	Blacklist string
+
+	// DPI hints image resolution for images without resolution metadata.
+	DPI int
+
+	// Variables are passed to the engine as is.
+	Variables map[string]string
+
+	// Preprocess names imgproc steps run on images before recognition.
+	Preprocess []string
+
+	// DebugDir, if set, receives intermediate images of preprocessing.
+	DebugDir string
+
+	// Regions, if set, are cropped out of images and recognized
+	// separately, in order. The rest of an image is left out.
+	Regions []Region
+}
+
+var ErrInvalidOptions = errors.New("invalid ocr options")
+
+func (o Options) Validate() error {
+	for _, lang := range o.Languages {
+		if lang == "" || strings.ContainsAny(lang, "+/\\ ") {
+			return fmt.Errorf("%w: language %q", ErrInvalidOptions, lang)
+		}
+	}
+	if o.PSM < PSMDefault || o.PSM > PSMRawLine {
+		return fmt.Errorf("%w: page segmentation mode %d", ErrInvalidOptions, o.PSM)
+	}
+	if o.DPI < 0 {
+		return fmt.Errorf("%w: dpi %d", ErrInvalidOptions, o.DPI)
+	}
+	for k := range o.Variables {
+		if k == "" {
+			return fmt.Errorf("%w: empty variable name", ErrInvalidOptions)
+		}
+	}
+	if _, err := imgproc.ParseChain(o.Preprocess); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+	}
+	names := make(map[string]bool, len(o.Regions))
+	for _, r := range o.Regions {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		if names[r.Name] {
+			return fmt.Errorf("%w: duplicate region %q", ErrInvalidOptions, r.Name)
+		}
+		names[r.Name] = true
+	}
+
+	return nil
+}
+
+// Merge returns o overridden by non-zero fields of override.
+// Variables are merged key by key.
+func (o Options) Merge(override Options) Options {
+	merged := o
+	if len(override.Languages) > 0 {
+		merged.Languages = override.Languages
+	}
+	if override.PSM != PSMDefault {
+		merged.PSM = override.PSM
+	}
+	if override.Whitelist != "" {
+		merged.Whitelist = override.Whitelist
+	}
+	if override.Blacklist != "" {
+		merged.Blacklist = override.Blacklist
+	}
+	if override.DPI != 0 {
+		merged.DPI = override.DPI
+	}
+	if len(override.Variables) > 0 {
+		merged.Variables = make(map[string]string, len(o.Variables)+len(override.Variables))
+		maps.Copy(merged.Variables, o.Variables)
+		maps.Copy(merged.Variables, override.Variables)
+	}
+	if len(override.Preprocess) > 0 {
+		merged.Preprocess = override.Preprocess
+	}
+	if override.DebugDir != "" {
+		merged.DebugDir = override.DebugDir
+	}
+	if len(override.Regions) > 0 {
+		merged.Regions = override.Regions
+	}
+
+	return merged
+}
+
+// Equal reports whether both options configure an engine the same way.
+func (o Options) Equal(other Options) bool {
+	return slices.Equal(o.Languages, other.Languages) &&
+		o.PSM == other.PSM &&
+		o.Whitelist == other.Whitelist &&
+		o.Blacklist == other.Blacklist &&
+		o.DPI == other.DPI &&
+		maps.Equal(o.Variables, other.Variables) &&
+		slices.Equal(o.Preprocess, other.Preprocess) &&
+		o.DebugDir == other.DebugDir &&
+		slices.Equal(o.Regions, other.Regions)
+}
+
+type optionsKey struct{}
+
+// ContextWithOptions returns ctx carrying opts merged over options already
+// in ctx. Engines merge them over their own, e.g. to override languages for
+// a single request, and scan uses them to preprocess images.
+func ContextWithOptions(ctx context.Context, opts Options) context.Context {
+	if parent, ok := OptionsFromContext(ctx); ok {
+		opts = parent.Merge(opts)
+	}
+
+	return context.WithValue(ctx, optionsKey{}, opts)
+}
+
+// OptionsFromContext returns options set with ContextWithOptions.
+func OptionsFromContext(ctx context.Context) (Options, bool) {
+	opts, ok := ctx.Value(optionsKey{}).(Options)
+
+	return opts, ok
+}
@@ -0,0 +1,147 @@
+package ocr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		opts    Options
+		wantErr bool
+	}{
+		{
+			desc: "zero_value_is_valid",
+			opts: Options{},
+		},
+		{
+			desc: "valid_options",
+			opts: Options{
+				Languages: []string{"eng", "pol"},
+				PSM:       PSMSingleBlock,
+				Whitelist: "ąćęłńóśźżĄĆĘŁŃÓŚŹŻ",
+				DPI:       300,
+				Variables: map[string]string{"preserve_interword_spaces": "1"},
+			},
+		},
+		{
+			desc:    "empty_language_err",
+			opts:    Options{Languages: []string{""}},
+			wantErr: true,
+		},
+		{
+			desc:    "joined_languages_err",
+			opts:    Options{Languages: []string{"eng+pol"}},
+			wantErr: true,
+		},
+		{
+			desc:    "psm_out_of_range_err",
+			opts:    Options{PSM: 14},
+			wantErr: true,
+		},
+		{
+			desc:    "negative_dpi_err",
+			opts:    Options{DPI: -1},
+			wantErr: true,
+		},
+		{
+			desc:    "unknown_preprocess_step_err",
+			opts:    Options{Preprocess: []string{"grayscale", "sharpen"}},
+			wantErr: true,
+		},
+		{
+			desc:    "empty_variable_name_err",
+			opts:    Options{Variables: map[string]string{"": "1"}},
+			wantErr: true,
+		},
+		{
+			desc: "regions",
+			opts: Options{Regions: []Region{
+				{Name: "title", Width: 100, Height: 10, Percent: true},
+				{Name: "description", Y: 200, Width: 800, Height: 600},
+			}},
+		},
+		{
+			desc:    "invalid_region_err",
+			opts:    Options{Regions: []Region{{Name: "title"}}},
+			wantErr: true,
+		},
+		{
+			desc: "duplicate_region_err",
+			opts: Options{Regions: []Region{
+				{Name: "title", Width: 10, Height: 10},
+				{Name: "title", Y: 10, Width: 10, Height: 10},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := tC.opts.Validate()
+			if tC.wantErr {
+				require.ErrorIs(t, err, ErrInvalidOptions)
+
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOptionsMerge(t *testing.T) {
+	t.Parallel()
+
+	base := Options{
+		Languages: []string{"eng"},
+		PSM:       PSMAuto,
+		Whitelist: "abc",
+		Variables: map[string]string{"a": "1", "b": "2"},
+	}
+	override := Options{
+		Languages: []string{"pol"},
+		DPI:       300,
+		Variables: map[string]string{"b": "3"},
+	}
+
+	merged := base.Merge(override)
+	require.Equal(t, Options{
+		Languages: []string{"pol"},
+		PSM:       PSMAuto,
+		Whitelist: "abc",
+		DPI:       300,
+		Variables: map[string]string{"a": "1", "b": "3"},
+	}, merged)
+
+	// Base is left untouched
+	require.Equal(t, "2", base.Variables["b"])
+	require.True(t, base.Merge(Options{}).Equal(base))
+	require.False(t, merged.Equal(base))
+
+	regions := []Region{{Name: "title", Width: 10, Height: 10}}
+	require.Equal(t, regions, base.Merge(Options{Regions: regions}).Regions)
+	require.False(t, base.Merge(Options{Regions: regions}).Equal(base))
+}
+
+func TestContextWithOptions(t *testing.T) {
+	t.Parallel()
+
+	_, ok := OptionsFromContext(context.Background())
+	require.False(t, ok)
+
+	opts := Options{Languages: []string{"pol"}}
+	ctx := ContextWithOptions(context.Background(), opts)
+	got, ok := OptionsFromContext(ctx)
+	require.True(t, ok)
+	require.True(t, opts.Equal(got))
+
+	// Nested options are merged over parent ones
+	got, _ = OptionsFromContext(ContextWithOptions(ctx, Options{Preprocess: []string{"otsu"}}))
+	require.Equal(t, []string{"pol"}, got.Languages)
+	require.Equal(t, []string{"otsu"}, got.Preprocess)
+}
@@ -0,0 +1,173 @@
+package ocr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+
+	"github.com/kndrad/piccrack/pkg/imgsniff"
+)
+
+// MaxPages limits the number of pages recognized in a single image.
+var MaxPages = 100
+
+// Page is recognized text of a single page or frame of an image.
+// Pages are numbered from 1.
+type Page struct {
+	Number int
+	Text   string
+	Words  []Box
+	Lines  []Box
+
+	// Regions are set if the page was recognized region by region, Text
+	// joins their texts then.
+	Regions []RegionText
+}
+
+// splitPages splits multi-page tiffs and animated gifs into single page
+// images. Other content is returned as the only page.
+func splitPages(content []byte) ([][]byte, error) {
+	format, _ := imgsniff.Detect(content)
+
+	var (
+		pages [][]byte
+		err   error
+	)
+	switch format {
+	case imgsniff.TIFF:
+		pages, err = splitTIFF(content)
+	case imgsniff.GIF:
+		pages, err = splitGIF(content)
+	default:
+		return [][]byte{content}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return [][]byte{content}, nil
+	}
+
+	return pages, nil
+}
+
+// splitTIFF returns a copy of content for every image file directory, with
+// header pointing at it, so decoders reading only the first one see
+// the page. Single page and BigTIFF content is returned as is.
+func splitTIFF(content []byte) ([][]byte, error) {
+	// Skip leading whitespace accepted by imgsniff
+	content = bytes.TrimLeft(content, " \t\r\n\v\f")
+	if len(content) < 8 {
+		return nil, fmt.Errorf("tiff: header too short")
+	}
+
+	var order binary.ByteOrder
+	switch string(content[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("tiff: invalid byte order %q", content[:2])
+	}
+	if order.Uint16(content[2:4]) != 42 {
+		return [][]byte{content}, nil
+	}
+
+	var offsets []uint32
+	seen := make(map[uint32]bool)
+	for offset := order.Uint32(content[4:8]); offset != 0; {
+		if seen[offset] {
+			return nil, fmt.Errorf("tiff: ifd loop at offset %d", offset)
+		}
+		seen[offset] = true
+
+		if int(offset)+2 > len(content) {
+			return nil, fmt.Errorf("tiff: ifd offset %d out of bounds", offset)
+		}
+		entries := int(order.Uint16(content[offset:]))
+		next := int(offset) + 2 + entries*12
+		if next+4 > len(content) {
+			return nil, fmt.Errorf("tiff: ifd at offset %d out of bounds", offset)
+		}
+		offsets = append(offsets, offset)
+		if len(offsets) == MaxPages {
+			break
+		}
+		offset = order.Uint32(content[next:])
+	}
+	if len(offsets) <= 1 {
+		return [][]byte{content}, nil
+	}
+
+	pages := make([][]byte, 0, len(offsets))
+	for _, offset := range offsets {
+		page := bytes.Clone(content)
+		order.PutUint32(page[4:8], offset)
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+// splitGIF renders every frame of an animated gif as png. Frames are drawn
+// over previous ones according to their disposal, like viewers show them.
+// Frames identical to the previous one are skipped.
+func splitGIF(content []byte) ([][]byte, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(bytes.TrimLeft(content, " \t\r\n\v\f")))
+	if err != nil {
+		return nil, fmt.Errorf("gif: %w", err)
+	}
+	if len(g.Image) <= 1 {
+		return [][]byte{content}, nil
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+	// Transparent areas are shown as white, which suits dark text
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, bounds, image.White, image.Point{}, draw.Src)
+
+	pages := make([][]byte, 0, len(g.Image))
+	var prev []byte
+	for i, frame := range g.Image {
+		var restore *image.RGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			restore = image.NewRGBA(bounds)
+			copy(restore.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		buf := new(bytes.Buffer)
+		if err := png.Encode(buf, canvas); err != nil {
+			return nil, fmt.Errorf("gif: encode frame %d: %w", i, err)
+		}
+		if !bytes.Equal(buf.Bytes(), prev) {
+			pages = append(pages, buf.Bytes())
+			prev = buf.Bytes()
+		}
+		if len(pages) == MaxPages {
+			break
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.White, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = restore
+		}
+	}
+
+	return pages, nil
+}
@@ -0,0 +1,194 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sizeEngine recognizes images as their size, so tests can tell pages apart.
+type sizeEngine struct{}
+
+func (sizeEngine) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	text := fmt.Sprintf("%dx%d", cfg.Width, cfg.Height)
+
+	return &Recognition{
+		Text:  text,
+		Words: []Box{{Text: text, Confidence: 90, Block: 1, Paragraph: 1, Line: 1}},
+	}, nil
+}
+
+func (sizeEngine) Close() error { return nil }
+
+// multiPageTIFF encodes uncompressed 8-bit gray pages of given sizes.
+func multiPageTIFF(t *testing.T, sizes ...image.Point) []byte {
+	t.Helper()
+
+	order := binary.LittleEndian
+	buf := bytes.NewBuffer([]byte("II*\x00\x00\x00\x00\x00"))
+
+	prevNext := 4 // Position of offset pointing at the next ifd
+	for _, size := range sizes {
+		pixels := size.X * size.Y
+		stripOffset := buf.Len()
+		buf.Write(bytes.Repeat([]byte{0xFF}, pixels))
+		if buf.Len()%2 == 1 {
+			buf.WriteByte(0)
+		}
+
+		ifd := buf.Len()
+		b := buf.Bytes()
+		order.PutUint32(b[prevNext:], uint32(ifd))
+
+		type field struct {
+			tag, typ uint16
+			value    uint32
+		}
+		fields := []field{
+			{256, 4, uint32(size.X)},      // ImageWidth
+			{257, 4, uint32(size.Y)},      // ImageLength
+			{258, 3, 8},                   // BitsPerSample
+			{259, 3, 1},                   // Compression: none
+			{262, 3, 1},                   // Photometric: black is zero
+			{273, 4, uint32(stripOffset)}, // StripOffsets
+			{277, 3, 1},                   // SamplesPerPixel
+			{278, 4, uint32(size.Y)},      // RowsPerStrip
+			{279, 4, uint32(pixels)},      // StripByteCounts
+		}
+		require.NoError(t, binary.Write(buf, order, uint16(len(fields))))
+		for _, f := range fields {
+			require.NoError(t, binary.Write(buf, order, f.tag))
+			require.NoError(t, binary.Write(buf, order, f.typ))
+			require.NoError(t, binary.Write(buf, order, uint32(1)))
+			if f.typ == 3 {
+				require.NoError(t, binary.Write(buf, order, uint16(f.value)))
+				require.NoError(t, binary.Write(buf, order, uint16(0)))
+			} else {
+				require.NoError(t, binary.Write(buf, order, f.value))
+			}
+		}
+		prevNext = buf.Len()
+		require.NoError(t, binary.Write(buf, order, uint32(0)))
+	}
+
+	return buf.Bytes()
+}
+
+func animatedGIF(t *testing.T, frames int) []byte {
+	t.Helper()
+
+	g := &gif.GIF{Config: image.Config{Width: 40, Height: 20}}
+	for i := range frames {
+		frame := image.NewPaletted(image.Rect(0, 0, 40, 20), palette.Plan9)
+		frame.SetColorIndex(i, i, 1)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	// Repeated frame is skipped
+	g.Image = append(g.Image, g.Image[frames-1])
+	g.Delay = append(g.Delay, 10)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, gif.EncodeAll(buf, g))
+
+	return buf.Bytes()
+}
+
+func TestSplitPages(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		content []byte
+		want    []string
+	}{
+		{
+			desc: "splits_multi_page_tiff",
+
+			content: multiPageTIFF(t, image.Pt(10, 5), image.Pt(20, 8), image.Pt(30, 9)),
+			want:    []string{"10x5", "20x8", "30x9"},
+		},
+		{
+			desc: "single_page_tiff",
+
+			content: multiPageTIFF(t, image.Pt(10, 5)),
+			want:    []string{"10x5"},
+		},
+		{
+			desc: "splits_animated_gif",
+
+			content: animatedGIF(t, 3),
+			want:    []string{"40x20", "40x20", "40x20"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			pages, err := splitPages(tC.content)
+			require.NoError(t, err)
+
+			got := make([]string, 0, len(pages))
+			for _, page := range pages {
+				rec, err := sizeEngine{}.Recognize(context.Background(), page)
+				require.NoError(t, err)
+				got = append(got, rec.Text)
+			}
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestSplitTIFFInvalid(t *testing.T) {
+	t.Parallel()
+
+	content := multiPageTIFF(t, image.Pt(10, 5), image.Pt(20, 8))
+
+	// First ifd points back at itself
+	looped := bytes.Clone(content)
+	first := binary.LittleEndian.Uint32(looped[4:])
+	entries := binary.LittleEndian.Uint16(looped[first:])
+	binary.LittleEndian.PutUint32(looped[int(first)+2+int(entries)*12:], first)
+	_, err := splitPages(looped)
+	require.Error(t, err)
+
+	_, err = splitPages(content[:len(content)-10])
+	require.Error(t, err)
+}
+
+func TestScanFromPages(t *testing.T) {
+	t.Parallel()
+
+	content := multiPageTIFF(t, image.Pt(10, 5), image.Pt(20, 8))
+
+	res, err := ScanFrom(context.Background(), sizeEngine{}, bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, "10x5\n\n20x8", res.Text())
+
+	pages := res.Pages()
+	require.Len(t, pages, 2)
+	require.Equal(t, 2, pages[1].Number)
+	require.Equal(t, "20x8", pages[1].Text)
+	require.Equal(t, 2, res.WordBoxes()[1].Page)
+
+	var got []string
+	for page, word := range res.PageWords() {
+		got = append(got, fmt.Sprintf("%d:%s", page, word))
+	}
+	require.Equal(t, []string{"1:10x5", "2:20x8"}, got)
+
+	filtered := res.WithMinConfidence(95)
+	require.Len(t, filtered.Pages(), 2)
+	require.Empty(t, filtered.Text())
+	require.Empty(t, filtered.Pages()[0].Text)
+}
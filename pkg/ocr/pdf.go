@@ -0,0 +1,392 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/kndrad/piccrack/pkg/imgsniff"
+	"github.com/ledongthuc/pdf"
+)
+
+// MaxPDFImagePixels limits size of a single image decoded from a pdf.
+var MaxPDFImagePixels = 40 * 1024 * 1024
+
+// MinPDFImageSize is the width and height under which embedded images,
+// e.g. icons and rules, aren't recognized.
+var MinPDFImageSize = 16
+
+// IsPDF reports whether content is a pdf document.
+func IsPDF(content []byte) bool {
+	return imgsniff.Is(content, imgsniff.PDF)
+}
+
+// scanPDF recognizes every page of a pdf document. Pages with a text layer
+// are read as they are, others are recognized from their embedded images.
+// The pdf reader panics on malformed documents, which is reported as an
+// error.
+func scanPDF(ctx context.Context, e Engine, content []byte) (recs []*Recognition, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			recs, err = nil, fmt.Errorf("pdf: %v", r)
+		}
+	}()
+
+	content = bytes.TrimLeft(content, " \t\r\n\v\f")
+	r, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	doc := &pdfDoc{content: content, used: make(map[int]bool)}
+
+	opts, _ := OptionsFromContext(ctx)
+
+	n := min(r.NumPage(), MaxPages)
+	recs = make([]*Recognition, 0, n)
+	for i := 1; i <= n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("page %d: %w", i, err)
+		}
+		page := r.Page(i)
+		if page.V.IsNull() {
+			recs = append(recs, &Recognition{Engine: "pdf"})
+
+			continue
+		}
+
+		if text := pageText(page); text != "" {
+			recs = append(recs, &Recognition{Text: text, Engine: "pdf"})
+
+			continue
+		}
+
+		images := doc.pageImages(page.Resources(), 0)
+		pageRecs := make([]*Recognition, 0, len(images))
+		for j, img := range images {
+			rec, err := recognize(ctx, e, img, opts)
+			if err != nil {
+				return nil, fmt.Errorf("recognize page %d image %d: %w", i, j+1, err)
+			}
+			pageRecs = append(pageRecs, rec)
+		}
+		recs = append(recs, mergeRecognitions(pageRecs))
+	}
+
+	return recs, nil
+}
+
+// mergeRecognitions joins recognitions of images found on the same page.
+// Blocks are renumbered so boxes of different images don't share them.
+func mergeRecognitions(recs []*Recognition) *Recognition {
+	merged := &Recognition{Engine: "pdf"}
+	texts := make([]string, 0, len(recs))
+	block := 0
+	for _, rec := range recs {
+		if rec.Engine != "" {
+			merged.Engine = rec.Engine
+		}
+		if rec.Text != "" {
+			texts = append(texts, rec.Text)
+		}
+		last := block
+		for _, boxes := range []struct {
+			src []Box
+			dst *[]Box
+		}{{rec.Words, &merged.Words}, {rec.Lines, &merged.Lines}} {
+			for _, b := range boxes.src {
+				b.Block += block
+				last = max(last, b.Block)
+				*boxes.dst = append(*boxes.dst, b)
+			}
+		}
+		block = last + 1
+	}
+	merged.Text = strings.Join(texts, "\n\n")
+
+	return merged
+}
+
+// pageText returns text layer of page, lines ordered top to bottom.
+// Malformed content streams yield no text.
+func pageText(page pdf.Page) (text string) {
+	defer func() {
+		if r := recover(); r != nil {
+			text = ""
+		}
+	}()
+
+	var lines [][]pdf.Text
+	for _, t := range page.Content().Text {
+		if strings.TrimSpace(t.S) == "" && t.S != " " {
+			continue
+		}
+		tol := max(t.FontSize/2, 1)
+		i := slices.IndexFunc(lines, func(line []pdf.Text) bool {
+			return math.Abs(line[0].Y-t.Y) < tol
+		})
+		if i < 0 {
+			lines = append(lines, []pdf.Text{t})
+
+			continue
+		}
+		lines[i] = append(lines[i], t)
+	}
+	// PDF coordinates increase bottom to top
+	slices.SortStableFunc(lines, func(a, b []pdf.Text) int {
+		return -compareFloat(a[0].Y, b[0].Y)
+	})
+
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		slices.SortStableFunc(line, func(a, b pdf.Text) int {
+			return compareFloat(a.X, b.X)
+		})
+		b := new(strings.Builder)
+		for i, t := range line {
+			if i > 0 {
+				// Glyph positions are all there is, words are separated
+				// by gaps wider than a fraction of the font size
+				prev := line[i-1]
+				if prev.W > 0 && t.X-(prev.X+prev.W) > t.FontSize*0.2 {
+					b.WriteString(" ")
+				}
+			}
+			b.WriteString(t.S)
+		}
+		if s := strings.Join(strings.Fields(b.String()), " "); s != "" {
+			out = append(out, s)
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// pdfDoc finds raw content of image streams, which pdf reader can't
+// decode, e.g. jpegs.
+type pdfDoc struct {
+	content []byte
+	// used are offsets of streams already returned by rawStream
+	used map[int]bool
+}
+
+// maxFormDepth limits nesting of form xobjects searched for images.
+const maxFormDepth = 4
+
+// pageImages returns images found in resources, as png or in their
+// original format, in order of their names. Unsupported images are skipped.
+func (d *pdfDoc) pageImages(resources pdf.Value, depth int) [][]byte {
+	xobjects := resources.Key("XObject")
+	names := xobjects.Keys()
+	slices.Sort(names)
+
+	images := make([][]byte, 0, len(names))
+	for _, name := range names {
+		x := xobjects.Key(name)
+		switch x.Key("Subtype").Name() {
+		case "Image":
+			if img := d.image(x); img != nil {
+				images = append(images, img)
+			}
+		case "Form":
+			if depth < maxFormDepth {
+				images = append(images, d.pageImages(x.Key("Resources"), depth+1)...)
+			}
+		}
+	}
+
+	return images
+}
+
+// image returns content of an image xobject or nil if it's not supported.
+func (d *pdfDoc) image(x pdf.Value) (content []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			content = nil
+		}
+	}()
+
+	w, h := int(x.Key("Width").Int64()), int(x.Key("Height").Int64())
+	if w < MinPDFImageSize || h < MinPDFImageSize || w*h > MaxPDFImagePixels {
+		return nil
+	}
+	if x.Key("ImageMask").Bool() {
+		return nil
+	}
+
+	filter := x.Key("Filter")
+	if filter.Kind() == pdf.Array {
+		if filter.Len() != 1 {
+			return nil
+		}
+		filter = filter.Index(0)
+	}
+	switch filter.Name() {
+	case "DCTDecode", "JPXDecode":
+		raw := d.rawStream(int(x.Key("Length").Int64()))
+		if raw == nil || !imgsniff.IsImage(raw) {
+			// Encrypted documents end up here too
+			return nil
+		}
+
+		return raw
+	case "FlateDecode", "":
+		return pixelImage(x, w, h)
+	default:
+		return nil
+	}
+}
+
+// rawStream returns content of a stream exactly length bytes long, the
+// first one not returned yet if there are many. Images shared by pages
+// are returned again once every stream of their length was used.
+func (d *pdfDoc) rawStream(length int) []byte {
+	if length <= 0 {
+		return nil
+	}
+
+	var found []int
+	keyword := []byte("stream")
+	for i := 0; ; {
+		j := bytes.Index(d.content[i:], keyword)
+		if j < 0 {
+			break
+		}
+		i += j + len(keyword)
+		if i-len(keyword) >= 3 && string(d.content[i-len(keyword)-3:i-len(keyword)]) == "end" {
+			continue
+		}
+
+		start := i
+		switch {
+		case bytes.HasPrefix(d.content[start:], []byte("\r\n")):
+			start += 2
+		case bytes.HasPrefix(d.content[start:], []byte("\n")):
+			start++
+		default:
+			continue
+		}
+		end := start + length
+		if end > len(d.content) {
+			continue
+		}
+		tail := bytes.TrimLeft(d.content[end:], " \r\n")
+		if !bytes.HasPrefix(tail, []byte("endstream")) {
+			continue
+		}
+		if !d.used[start] {
+			d.used[start] = true
+
+			return d.content[start:end]
+		}
+		found = append(found, start)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	return d.content[found[0] : found[0]+length]
+}
+
+// pixelImage decodes raw samples of an image xobject and encodes them
+// as png. Gray, rgb and cmyk images with 8 bits per component and 1 bit
+// gray images are supported.
+func pixelImage(x pdf.Value, w, h int) []byte {
+	if p := x.Key("DecodeParms").Key("Predictor").Int64(); p > 1 {
+		return nil
+	}
+
+	components := 0
+	cs := x.Key("ColorSpace")
+	name := cs.Name()
+	if cs.Kind() == pdf.Array {
+		name = cs.Index(0).Name()
+	}
+	switch name {
+	case "DeviceGray", "CalGray":
+		components = 1
+	case "DeviceRGB", "CalRGB":
+		components = 3
+	case "DeviceCMYK":
+		components = 4
+	case "ICCBased":
+		components = int(cs.Index(1).Key("N").Int64())
+	}
+	bpc := int(x.Key("BitsPerComponent").Int64())
+	if bpc != 8 && !(bpc == 1 && components == 1) {
+		return nil
+	}
+
+	stride := (w*components*bpc + 7) / 8
+	rc := x.Reader()
+	defer rc.Close()
+	samples := make([]byte, stride*h)
+	if _, err := io.ReadFull(rc, samples); err != nil {
+		return nil
+	}
+
+	var img image.Image
+	switch components {
+	case 1:
+		g := image.NewGray(image.Rect(0, 0, w, h))
+		if bpc == 8 {
+			copy(g.Pix, samples)
+		} else {
+			for y := range h {
+				for x := range w {
+					if samples[y*stride+x/8]&(0x80>>(x%8)) != 0 {
+						g.Pix[y*g.Stride+x] = 0xFF
+					}
+				}
+			}
+		}
+		img = g
+	case 3:
+		rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := range w * h {
+			copy(rgba.Pix[i*4:], samples[i*3:i*3+3])
+			rgba.Pix[i*4+3] = 0xFF
+		}
+		img = rgba
+	case 4:
+		cmyk := image.NewCMYK(image.Rect(0, 0, w, h))
+		copy(cmyk.Pix, samples)
+		img = cmyk
+	default:
+		return nil
+	}
+	if bpc == 1 && x.Key("Decode").Index(0).Int64() == 1 {
+		img = invertGray(img.(*image.Gray))
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil
+	}
+
+	return buf.Bytes()
+}
+
+func invertGray(g *image.Gray) *image.Gray {
+	for i, v := range g.Pix {
+		g.Pix[i] = 0xFF - v
+	}
+
+	return g
+}
@@ -0,0 +1,270 @@
+package ocr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pdfImage is an image xobject of a test pdf.
+type pdfImage struct {
+	dict string
+	data []byte
+}
+
+func jpegXObject(t *testing.T, w, h int) pdfImage {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, w, h)), nil))
+
+	return pdfImage{
+		dict: fmt.Sprintf("/Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode", w, h),
+		data: buf.Bytes(),
+	}
+}
+
+func flateXObject(t *testing.T, w, h int) pdfImage {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	zw := zlib.NewWriter(buf)
+	_, err := zw.Write(bytes.Repeat([]byte{0xFF, 0x00, 0x00}, w*h))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	return pdfImage{
+		dict: fmt.Sprintf("/Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", w, h),
+		data: buf.Bytes(),
+	}
+}
+
+// pdfPage is a page of a test pdf with text lines drawn top to bottom
+// and images.
+type pdfPage struct {
+	lines  []string
+	images []pdfImage
+}
+
+// buildPDF writes a minimal pdf document with a Helvetica font.
+func buildPDF(t *testing.T, pages ...pdfPage) []byte {
+	t.Helper()
+
+	var objects []string
+	add := func(obj string) int {
+		objects = append(objects, obj)
+
+		return len(objects)
+	}
+	stream := func(dict string, data []byte) string {
+		return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+	}
+
+	catalog := add("")
+	root := add("")
+	widths := strings.TrimSpace(strings.Repeat("500 ", 126-32+1))
+	font := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding " +
+		"/FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>")
+
+	kids := make([]string, 0, len(pages))
+	for _, p := range pages {
+		content := new(strings.Builder)
+		if len(p.lines) > 0 {
+			content.WriteString("BT /F1 12 Tf 72 720 Td\n")
+			for i, line := range p.lines {
+				if i > 0 {
+					content.WriteString("0 -16 Td\n")
+				}
+				fmt.Fprintf(content, "(%s) Tj\n", line)
+			}
+			content.WriteString("ET\n")
+		}
+		xobjects := new(strings.Builder)
+		for i, img := range p.images {
+			ref := add(stream("/Type /XObject /Subtype /Image "+img.dict, img.data))
+			fmt.Fprintf(xobjects, "/Im%d %d 0 R ", i+1, ref)
+			fmt.Fprintf(content, "q 100 0 0 100 72 %d cm /Im%d Do Q\n", 600-i*120, i+1)
+		}
+
+		contents := add(stream("", []byte(content.String())))
+		page := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] "+
+			"/Resources << /Font << /F1 %d 0 R >> /XObject << %s>> >> /Contents %d 0 R >>",
+			root, font, xobjects, contents))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	objects[catalog-1] = fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", root)
+	objects[root-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	buf := bytes.NewBufferString("%PDF-1.4\n")
+	offsets := make([]int, 0, len(objects))
+	for i, obj := range objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, xref)
+
+	return buf.Bytes()
+}
+
+func TestScanFromPDF(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		pages []pdfPage
+		want  []string
+	}{
+		{
+			desc: "text_layer",
+
+			pages: []pdfPage{
+				{lines: []string{"Senior Go developer", "Remote work"}},
+				{lines: []string{"Kubernetes and Postgres"}},
+			},
+			want: []string{"Senior Go developer\nRemote work", "Kubernetes and Postgres"},
+		},
+		{
+			desc: "scanned_pages_are_recognized_from_images",
+
+			pages: []pdfPage{
+				{images: []pdfImage{jpegXObject(t, 40, 30)}},
+				{images: []pdfImage{jpegXObject(t, 64, 32), flateXObject(t, 50, 20)}},
+			},
+			want: []string{"40x30", "64x32\n\n50x20"},
+		},
+		{
+			desc: "text_layer_takes_precedence_over_images",
+
+			pages: []pdfPage{
+				{lines: []string{"Go"}, images: []pdfImage{jpegXObject(t, 40, 30)}},
+			},
+			want: []string{"Go"},
+		},
+		{
+			desc: "icons_and_empty_pages_have_no_text",
+
+			pages: []pdfPage{
+				{images: []pdfImage{jpegXObject(t, 8, 8)}},
+				{},
+			},
+			want: []string{"", ""},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			content := buildPDF(t, tC.pages...)
+			require.True(t, IsPDF(content))
+			require.True(t, IsScannable(content))
+			require.False(t, IsImage(content))
+
+			result, err := ScanFrom(context.Background(), sizeEngine{}, bytes.NewReader(content))
+			require.NoError(t, err)
+
+			pages := result.Pages()
+			require.Len(t, pages, len(tC.want))
+			for i, want := range tC.want {
+				require.Equal(t, i+1, pages[i].Number)
+				require.Equal(t, want, pages[i].Text)
+			}
+		})
+	}
+}
+
+func TestScanPDFPageWords(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "offer.pdf")
+	content := buildPDF(t,
+		pdfPage{lines: []string{"Golang Developer"}},
+		pdfPage{images: []pdfImage{jpegXObject(t, 40, 30)}},
+	)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	result, err := ScanFile(context.Background(), sizeEngine{}, path)
+	require.NoError(t, err)
+
+	type pageWord struct {
+		page int
+		word string
+	}
+	var got []pageWord
+	for page, w := range result.PageWords() {
+		got = append(got, pageWord{page, w})
+	}
+	require.Equal(t, []pageWord{{1, "golang"}, {1, "developer"}, {2, "40x30"}}, got)
+	require.Equal(t, []Box{{Text: "40x30", Confidence: 90, Block: 1, Paragraph: 1, Line: 1, Page: 2}}, result.WordBoxes())
+}
+
+func TestScanPDFInvalid(t *testing.T) {
+	t.Parallel()
+
+	content := buildPDF(t, pdfPage{lines: []string{"Golang developer"}})
+
+	testCases := []struct {
+		desc string
+
+		content []byte
+	}{
+		{
+			desc: "garbage",
+
+			content: []byte("%PDF-1.4\ngarbage"),
+		},
+		{
+			desc: "truncated",
+
+			content: content[:len(content)/2],
+		},
+		{
+			desc: "garbled_object_header",
+
+			content: bytes.Replace(content, []byte("2 0 obj"), []byte("2 0 xbj"), 1),
+		},
+		{
+			desc: "garbled_delimiter",
+
+			content: bytes.Replace(content, []byte("/Count 1"), []byte("/Count )"), 1),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// Malformed documents fail without panicking
+			_, err := ScanFrom(context.Background(), sizeEngine{}, bytes.NewReader(tC.content))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMergeRecognitions(t *testing.T) {
+	t.Parallel()
+
+	merged := mergeRecognitions([]*Recognition{
+		{Text: "a", Engine: "tesseract", Words: []Box{{Text: "a", Block: 1}}},
+		{Text: ""},
+		{Text: "b", Engine: "tesseract", Words: []Box{{Text: "b", Block: 1}}, Lines: []Box{{Text: "b", Block: 2}}},
+	})
+
+	require.Equal(t, "a\n\nb", merged.Text)
+	require.Equal(t, "tesseract", merged.Engine)
+	require.Equal(t, []Box{{Text: "a", Block: 1}, {Text: "b", Block: 4}}, merged.Words)
+	require.Equal(t, []Box{{Text: "b", Block: 5}}, merged.Lines)
+}
@@ -0,0 +1,263 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var ErrPoolClosed = errors.New("engine pool closed")
+
+// HealthChecker is implemented by engines which can report whether they are
+// still usable. Pool checks idle engines before handing them out again.
+type HealthChecker interface {
+	HealthCheck() error
+}
+
+// PoolOptions configure a Pool.
+type PoolOptions struct {
+	// MaxSize is the maximum number of engines alive at once.
+	// If not positive, the number of CPUs is used.
+	MaxSize int
+
+	// IdleTimeout is how long an engine may stay idle before it's closed.
+	// Zero disables idle eviction.
+	IdleTimeout time.Duration
+}
+
+// minEvictInterval is the shortest interval of checking for idle engines.
+const minEvictInterval = time.Millisecond
+
+type idleEngine struct {
+	e        Engine
+	lastUsed time.Time
+}
+
+// Pool reuses engines between callers, so expensive initialization like
+// loading Tesseract language data happens once per engine instead of once
+// per scan.
+//
+// Pool is itself an Engine and is safe for concurrent use. It also implements
+// prometheus.Collector exposing gauges of engines in use and idle.
+type Pool struct {
+	newEngine NewEngineFunc
+	opts      PoolOptions
+
+	// sem bounds the number of engines in use.
+	sem chan struct{}
+
+	mu     sync.Mutex
+	idle   []idleEngine
+	closed bool
+
+	inUseGauge prometheus.Gauge
+	idleGauge  prometheus.Gauge
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+var (
+	_ Engine               = (*Pool)(nil)
+	_ prometheus.Collector = (*Pool)(nil)
+)
+
+func NewPool(newEngine NewEngineFunc, opts PoolOptions) *Pool {
+	if newEngine == nil {
+		panic("new engine func cannot be nil")
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = runtime.NumCPU()
+	}
+
+	p := &Pool{
+		newEngine: newEngine,
+		opts:      opts,
+		sem:       make(chan struct{}, opts.MaxSize),
+		inUseGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ocr_pool_engines_in_use",
+			Help: "Number of ocr engines currently in use.",
+		}),
+		idleGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ocr_pool_engines_idle",
+			Help: "Number of idle ocr engines waiting in the pool.",
+		}),
+		done: make(chan struct{}),
+	}
+
+	if opts.IdleTimeout > 0 {
+		p.wg.Add(1)
+		go p.evictLoop()
+	}
+
+	return p
+}
+
+// Acquire returns an engine from the pool, creating a new one if there are
+// no healthy idle engines. It blocks while MaxSize engines are in use.
+// The engine must be given back with Release.
+func (p *Pool) Acquire(ctx context.Context) (Engine, error) {
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			<-p.sem
+
+			return nil, ErrPoolClosed
+		}
+		n := len(p.idle)
+		if n == 0 {
+			p.mu.Unlock()
+
+			break
+		}
+		ie := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.idleGauge.Set(float64(len(p.idle)))
+		p.mu.Unlock()
+
+		if hc, ok := ie.e.(HealthChecker); ok {
+			if err := hc.HealthCheck(); err != nil {
+				ie.e.Close()
+
+				continue
+			}
+		}
+		p.inUseGauge.Inc()
+
+		return ie.e, nil
+	}
+
+	e, err := p.newEngine()
+	if err != nil {
+		<-p.sem
+
+		return nil, fmt.Errorf("new engine: %w", err)
+	}
+	p.inUseGauge.Inc()
+
+	return e, nil
+}
+
+// Release gives an engine acquired with Acquire back to the pool.
+func (p *Pool) Release(e Engine) {
+	if e == nil {
+		panic("engine cannot be nil")
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		e.Close()
+	} else {
+		p.idle = append(p.idle, idleEngine{e: e, lastUsed: time.Now()})
+		p.idleGauge.Set(float64(len(p.idle)))
+		p.mu.Unlock()
+	}
+	p.inUseGauge.Dec()
+	<-p.sem
+}
+
+// Recognize acquires an engine, recognizes content with it and releases it.
+func (p *Pool) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	e, err := p.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire: %w", err)
+	}
+	defer p.Release(e)
+
+	rec, err := e.Recognize(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("recognize: %w", err)
+	}
+
+	return rec, nil
+}
+
+// Close closes idle engines and stops eviction. Engines still in use are
+// closed when they are released.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+
+		return nil
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.idleGauge.Set(0)
+	p.mu.Unlock()
+
+	close(p.done)
+	p.wg.Wait()
+
+	var errs []error
+	for _, ie := range idle {
+		if err := ie.e.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *Pool) evictLoop() {
+	defer p.wg.Done()
+
+	// Tickers panic on intervals which aren't positive, e.g. half of 1ns
+	ticker := time.NewTicker(max(p.opts.IdleTimeout/2, minEvictInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.evict(now)
+		}
+	}
+}
+
+// evict closes engines which have been idle longer than IdleTimeout.
+func (p *Pool) evict(now time.Time) {
+	p.mu.Lock()
+	keep := p.idle[:0]
+	var expired []Engine
+	for _, ie := range p.idle {
+		if now.Sub(ie.lastUsed) >= p.opts.IdleTimeout {
+			expired = append(expired, ie.e)
+
+			continue
+		}
+		keep = append(keep, ie)
+	}
+	p.idle = keep
+	p.idleGauge.Set(float64(len(p.idle)))
+	p.mu.Unlock()
+
+	for _, e := range expired {
+		e.Close()
+	}
+}
+
+func (p *Pool) Describe(ch chan<- *prometheus.Desc) {
+	p.inUseGauge.Describe(ch)
+	p.idleGauge.Describe(ch)
+}
+
+func (p *Pool) Collect(ch chan<- prometheus.Metric) {
+	p.inUseGauge.Collect(ch)
+	p.idleGauge.Collect(ch)
+}
@@ -0,0 +1,180 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type mockEngine struct {
+	closed  atomic.Bool
+	healthy atomic.Bool
+}
+
+func (e *mockEngine) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	return &Recognition{Text: string(content), Engine: "mock"}, nil
+}
+
+func (e *mockEngine) Close() error {
+	e.closed.Store(true)
+
+	return nil
+}
+
+func (e *mockEngine) HealthCheck() error {
+	if !e.healthy.Load() {
+		return errors.New("unhealthy")
+	}
+
+	return nil
+}
+
+type mockEngines struct {
+	mu      sync.Mutex
+	engines []*mockEngine
+}
+
+func (m *mockEngines) New() (Engine, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := new(mockEngine)
+	e.healthy.Store(true)
+	m.engines = append(m.engines, e)
+
+	return e, nil
+}
+
+func (m *mockEngines) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.engines)
+}
+
+func TestPoolReusesEngines(t *testing.T) {
+	t.Parallel()
+
+	engines := new(mockEngines)
+	p := NewPool(engines.New, PoolOptions{MaxSize: 2})
+	defer p.Close()
+
+	for range 3 {
+		rec, err := p.Recognize(context.Background(), []byte("text"))
+		require.NoError(t, err)
+		require.Equal(t, "text", rec.Text)
+	}
+	require.Equal(t, 1, engines.Len())
+	require.InDelta(t, 0, testutil.ToFloat64(p.inUseGauge), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(p.idleGauge), 0)
+}
+
+func TestPoolBlocksAtMaxSize(t *testing.T) {
+	t.Parallel()
+
+	engines := new(mockEngines)
+	p := NewPool(engines.New, PoolOptions{MaxSize: 2})
+	defer p.Close()
+
+	ctx := context.Background()
+	e1, err := p.Acquire(ctx)
+	require.NoError(t, err)
+	e2, err := p.Acquire(ctx)
+	require.NoError(t, err)
+	require.InDelta(t, 2, testutil.ToFloat64(p.inUseGauge), 0)
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = p.Acquire(timeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	p.Release(e1)
+	p.Release(e2)
+	require.Equal(t, 2, engines.Len())
+}
+
+func TestPoolReplacesUnhealthyEngines(t *testing.T) {
+	t.Parallel()
+
+	engines := new(mockEngines)
+	p := NewPool(engines.New, PoolOptions{MaxSize: 1})
+	defer p.Close()
+
+	e, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	e.(*mockEngine).healthy.Store(false)
+	p.Release(e)
+
+	got, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	defer p.Release(got)
+
+	require.NotSame(t, e, got)
+	require.True(t, e.(*mockEngine).closed.Load())
+	require.Equal(t, 2, engines.Len())
+}
+
+func TestPoolEvictsIdleEngines(t *testing.T) {
+	t.Parallel()
+
+	engines := new(mockEngines)
+	p := NewPool(engines.New, PoolOptions{MaxSize: 1, IdleTimeout: time.Hour})
+	defer p.Close()
+
+	e, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	p.Release(e)
+
+	p.evict(time.Now())
+	require.False(t, e.(*mockEngine).closed.Load())
+
+	p.evict(time.Now().Add(2 * time.Hour))
+	require.True(t, e.(*mockEngine).closed.Load())
+	require.InDelta(t, 0, testutil.ToFloat64(p.idleGauge), 0)
+}
+
+func TestPoolTinyIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	engines := new(mockEngines)
+	p := NewPool(engines.New, PoolOptions{MaxSize: 1, IdleTimeout: time.Nanosecond})
+	defer p.Close()
+
+	e, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	p.Release(e)
+
+	require.Eventually(t, func() bool {
+		return e.(*mockEngine).closed.Load()
+	}, time.Second, time.Millisecond)
+}
+
+func TestPoolClose(t *testing.T) {
+	t.Parallel()
+
+	engines := new(mockEngines)
+	p := NewPool(engines.New, PoolOptions{MaxSize: 2, IdleTimeout: time.Minute})
+
+	idle, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	inUse, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	p.Release(idle)
+
+	require.NoError(t, p.Close())
+	require.True(t, idle.(*mockEngine).closed.Load())
+	require.False(t, inUse.(*mockEngine).closed.Load())
+
+	// Engines released after close are closed as well
+	p.Release(inUse)
+	require.True(t, inUse.(*mockEngine).closed.Load())
+
+	_, err = p.Acquire(context.Background())
+	require.ErrorIs(t, err, ErrPoolClosed)
+}
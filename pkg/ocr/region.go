@@ -0,0 +1,231 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Region is a named rectangle of an image recognized separately from the
+// rest of it, e.g. description panel of a job board screenshot.
+type Region struct {
+	Name string
+
+	// X, Y, Width and Height are pixels, or percent of image size if
+	// Percent is set.
+	X, Y, Width, Height float64
+	Percent             bool
+}
+
+var regionName = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// ParseRegion parses a region given as "name=x,y,width,height", e.g.
+// "description=0,120,800,600" in pixels or "description=0,20%,100%,60%"
+// in percent of image size. Units can't be mixed, zero needs none.
+func ParseRegion(s string) (Region, error) {
+	var r Region
+
+	name, rect, ok := strings.Cut(s, "=")
+	if !ok {
+		return r, fmt.Errorf("%w: region %q, want name=x,y,width,height", ErrInvalidOptions, s)
+	}
+	r.Name = strings.TrimSpace(name)
+
+	fields := strings.Split(rect, ",")
+	if len(fields) != 4 {
+		return r, fmt.Errorf("%w: region %q, want name=x,y,width,height", ErrInvalidOptions, s)
+	}
+	values := make([]float64, 0, len(fields))
+	var percents, pixels int
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		percent := false
+		if v, ok := strings.CutSuffix(f, "%"); ok {
+			f, percent = strings.TrimSpace(v), true
+		}
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return r, fmt.Errorf("%w: region %q: %w", ErrInvalidOptions, s, err)
+		}
+		switch {
+		case percent:
+			percents++
+		case v != 0:
+			pixels++
+		}
+		values = append(values, v)
+	}
+	if percents > 0 && pixels > 0 {
+		return r, fmt.Errorf("%w: region %q mixes pixels and percent", ErrInvalidOptions, s)
+	}
+	r.X, r.Y, r.Width, r.Height = values[0], values[1], values[2], values[3]
+	r.Percent = percents > 0
+
+	if err := r.Validate(); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// String formats r the way ParseRegion parses it.
+func (r Region) String() string {
+	unit := ""
+	if r.Percent {
+		unit = "%"
+	}
+	values := make([]string, 0, 4)
+	for _, v := range []float64{r.X, r.Y, r.Width, r.Height} {
+		values = append(values, strconv.FormatFloat(v, 'f', -1, 64)+unit)
+	}
+
+	return r.Name + "=" + strings.Join(values, ",")
+}
+
+func (r Region) Validate() error {
+	if !regionName.MatchString(r.Name) {
+		return fmt.Errorf("%w: region name %q", ErrInvalidOptions, r.Name)
+	}
+	for _, v := range []float64{r.X, r.Y, r.Width, r.Height} {
+		if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+			return fmt.Errorf("%w: region %q coordinates", ErrInvalidOptions, r.Name)
+		}
+	}
+	if r.Width == 0 || r.Height == 0 {
+		return fmt.Errorf("%w: region %q is empty", ErrInvalidOptions, r.Name)
+	}
+	if r.Percent && (r.X+r.Width > 100 || r.Y+r.Height > 100) {
+		return fmt.Errorf("%w: region %q exceeds 100%%", ErrInvalidOptions, r.Name)
+	}
+
+	return nil
+}
+
+// Rect returns pixel rectangle of r in an image with bounds, clipped
+// to them.
+func (r Region) Rect(bounds image.Rectangle) image.Rectangle {
+	x, y, w, h := r.X, r.Y, r.Width, r.Height
+	if r.Percent {
+		dx, dy := float64(bounds.Dx())/100, float64(bounds.Dy())/100
+		x, y, w, h = x*dx, y*dy, w*dx, h*dy
+	}
+	rect := image.Rect(
+		int(math.Round(x)), int(math.Round(y)),
+		int(math.Round(x+w)), int(math.Round(y+h)),
+	)
+
+	return rect.Add(bounds.Min).Intersect(bounds)
+}
+
+// RegionText is recognized text of a region of a page. Boxes are
+// positioned in the whole page.
+type RegionText struct {
+	Name  string
+	Page  int
+	Text  string
+	Words []Box
+	Lines []Box
+}
+
+var ErrRegionOutside = errors.New("region outside of image")
+
+// recognizeRegions crops every region in opts out of content and recognizes
+// it separately. Page text joins texts of regions in order.
+func recognizeRegions(ctx context.Context, e Engine, n int, content []byte, opts Options) (Page, error) {
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return Page{}, fmt.Errorf("decode: %w", err)
+	}
+
+	recs := make([]*Recognition, 0, len(opts.Regions))
+	for _, r := range opts.Regions {
+		rect := r.Rect(img.Bounds())
+		if rect.Empty() {
+			return Page{}, fmt.Errorf("%w: %q", ErrRegionOutside, r.Name)
+		}
+		cropped, err := encodePNG(subImage(img, rect))
+		if err != nil {
+			return Page{}, fmt.Errorf("crop region %q: %w", r.Name, err)
+		}
+
+		rec, err := recognize(ctx, e, cropped, opts)
+		if err != nil {
+			return Page{}, fmt.Errorf("region %q: %w", r.Name, err)
+		}
+		// Boxes of cropped image are moved back into the page
+		recs = append(recs, &Recognition{
+			Text:  rec.Text,
+			Words: inRegion(rec.Words, r.Name, rect.Min),
+			Lines: inRegion(rec.Lines, r.Name, rect.Min),
+		})
+	}
+
+	merged := mergeRecognitions(recs)
+	page := recognitionPage(n, merged)
+	for i, r := range opts.Regions {
+		page.Regions = append(page.Regions, RegionText{
+			Name:  r.Name,
+			Page:  n,
+			Text:  recs[i].Text,
+			Words: regionBoxes(page.Words, r.Name),
+			Lines: regionBoxes(page.Lines, r.Name),
+		})
+	}
+
+	return page, nil
+}
+
+// subImage returns part of img, sharing pixels if img supports it.
+func subImage(img image.Image, rect image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	dst := image.NewRGBA(rect)
+	draw.Draw(dst, rect, img, rect.Min, draw.Src)
+
+	return dst
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func inRegion(boxes []Box, name string, origin image.Point) []Box {
+	if boxes == nil {
+		return nil
+	}
+	out := make([]Box, len(boxes))
+	for i, b := range boxes {
+		b.Rect = b.Rect.Add(origin)
+		b.Region = name
+		out[i] = b
+	}
+
+	return out
+}
+
+func regionBoxes(boxes []Box, name string) []Box {
+	var out []Box
+	for _, b := range boxes {
+		if b.Region == name {
+			out = append(out, b)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,244 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// regionEngine recognizes size of the image it receives as a single word
+// covering the whole image, recognized with confidence equal to its width.
+type regionEngine struct {
+	mockEngine
+}
+
+func (e *regionEngine) Recognize(ctx context.Context, content []byte) (*Recognition, error) {
+	cfg, err := png.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	text := fmt.Sprintf("%dx%d", cfg.Width, cfg.Height)
+	rect := image.Rect(0, 0, cfg.Width, cfg.Height)
+
+	return &Recognition{
+		Text:  text,
+		Words: []Box{{Text: text, Rect: rect, Confidence: float64(cfg.Width), Block: 1, Paragraph: 1, Line: 1}},
+		Lines: []Box{{Text: text, Rect: rect, Confidence: float64(cfg.Width)}},
+	}, nil
+}
+
+func testImage(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, img))
+
+	return buf.Bytes()
+}
+
+func TestParseRegion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		in      string
+		want    Region
+		wantErr bool
+	}{
+		{
+			desc: "pixels",
+
+			in:   "description=0,120,800,600",
+			want: Region{Name: "description", X: 0, Y: 120, Width: 800, Height: 600},
+		},
+		{
+			desc: "percent",
+
+			in:   " panel = 0, 20%, 100%, 60.5% ",
+			want: Region{Name: "panel", X: 0, Y: 20, Width: 100, Height: 60.5, Percent: true},
+		},
+		{
+			desc: "percent_on_every_value",
+
+			in:   "panel=0%,20%,100%,60%",
+			want: Region{Name: "panel", X: 0, Y: 20, Width: 100, Height: 60, Percent: true},
+		},
+		{
+			desc:    "missing_name_err",
+			in:      "0,0,10,10",
+			wantErr: true,
+		},
+		{
+			desc:    "empty_name_err",
+			in:      "=0,0,10,10",
+			wantErr: true,
+		},
+		{
+			desc:    "too_few_values_err",
+			in:      "panel=0,0,10",
+			wantErr: true,
+		},
+		{
+			desc:    "not_a_number_err",
+			in:      "panel=0,0,ten,10",
+			wantErr: true,
+		},
+		{
+			desc:    "negative_err",
+			in:      "panel=-1,0,10,10",
+			wantErr: true,
+		},
+		{
+			desc:    "empty_err",
+			in:      "panel=0,0,0,10",
+			wantErr: true,
+		},
+		{
+			desc:    "over_100_percent_err",
+			in:      "panel=50%,0%,60%,10%",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r, err := ParseRegion(tC.in)
+			if tC.wantErr {
+				require.ErrorIs(t, err, ErrInvalidOptions)
+
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.want, r)
+
+			parsed, err := ParseRegion(r.String())
+			require.NoError(t, err)
+			require.Equal(t, r, parsed)
+		})
+	}
+}
+
+func TestRegionRect(t *testing.T) {
+	t.Parallel()
+
+	bounds := image.Rect(0, 0, 200, 100)
+
+	testCases := []struct {
+		desc string
+
+		region Region
+		want   image.Rectangle
+	}{
+		{
+			desc:   "pixels",
+			region: Region{X: 10, Y: 20, Width: 30, Height: 40},
+			want:   image.Rect(10, 20, 40, 60),
+		},
+		{
+			desc:   "percent",
+			region: Region{X: 50, Y: 10, Width: 50, Height: 25, Percent: true},
+			want:   image.Rect(100, 10, 200, 35),
+		},
+		{
+			desc:   "clipped_to_bounds",
+			region: Region{X: 150, Y: 50, Width: 100, Height: 100},
+			want:   image.Rect(150, 50, 200, 100),
+		},
+		{
+			desc:   "outside_is_empty",
+			region: Region{X: 300, Y: 0, Width: 10, Height: 10},
+			want:   image.Rectangle{},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := tC.region.Rect(bounds)
+			if tC.want.Empty() {
+				require.True(t, got.Empty())
+
+				return
+			}
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestScanRegions(t *testing.T) {
+	t.Parallel()
+
+	content := testImage(t, 200, 100)
+	ctx := ContextWithOptions(context.Background(), Options{
+		Regions: []Region{
+			{Name: "title", X: 0, Y: 0, Width: 200, Height: 20},
+			{Name: "description", X: 10, Y: 50, Width: 50, Height: 50, Percent: true},
+		},
+	})
+
+	res, err := ScanFrom(ctx, new(regionEngine), bytes.NewReader(content))
+	require.NoError(t, err)
+
+	require.Equal(t, "200x20\n\n100x50", res.Text())
+
+	regions := res.Regions()
+	require.Len(t, regions, 2)
+	require.Equal(t, "title", regions[0].Name)
+	require.Equal(t, "200x20", regions[0].Text)
+	require.Equal(t, "description", regions[1].Name)
+	require.Equal(t, "100x50", regions[1].Text)
+
+	// Boxes are positioned in the page and labelled with their region
+	word := regions[1].Words[0]
+	require.Equal(t, image.Rect(20, 50, 120, 100), word.Rect)
+	require.Equal(t, "description", word.Region)
+	require.Equal(t, 1, word.Page)
+	require.NotEqual(t, regions[0].Words[0].Block, word.Block)
+	require.Len(t, res.WordBoxes(), 2)
+
+	// Confidence filtering keeps regions
+	filtered := res.WithMinConfidence(150).Regions()
+	require.Len(t, filtered, 2)
+	require.Equal(t, "200x20", filtered[0].Text)
+	require.Empty(t, filtered[1].Text)
+	require.Equal(t, "200x20", res.WithMinConfidence(150).Text())
+}
+
+func TestScanRegionsErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		content []byte
+		region  Region
+	}{
+		{
+			desc: "region_outside_of_image",
+
+			content: testImage(t, 20, 20),
+			region:  Region{Name: "panel", X: 100, Y: 100, Width: 10, Height: 10},
+		},
+		{
+			desc: "pdf_document",
+
+			content: buildPDF(t, pdfPage{lines: []string{"Golang developer"}}),
+			region:  Region{Name: "panel", Width: 10, Height: 10},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			ctx := ContextWithOptions(context.Background(), Options{Regions: []Region{tC.region}})
+
+			_, err := ScanFrom(ctx, new(regionEngine), bytes.NewReader(tC.content))
+			require.Error(t, err)
+		})
+	}
+}
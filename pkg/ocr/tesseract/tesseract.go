@@ -0,0 +1,218 @@
+// Package tesseract implements ocr.Engine on top of a native Tesseract
+// installation via gosseract. It requires cgo.
+package tesseract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/otiai10/gosseract/v2"
+)
+
+const engineName = "tesseract"
+
+// NewClient creates a client configured with opts.
+// Options are validated by the caller.
+func NewClient(opts ocr.Options) (*gosseract.Client, error) {
+	client := gosseract.NewClient()
+	client.Trim = true
+
+	if len(opts.Languages) > 0 {
+		if err := client.SetLanguage(opts.Languages...); err != nil {
+			client.Close()
+
+			return nil, fmt.Errorf("set language: %w", err)
+		}
+	}
+	if opts.PSM != ocr.PSMDefault {
+		if err := client.SetPageSegMode(gosseract.PageSegMode(opts.PSM)); err != nil {
+			client.Close()
+
+			return nil, fmt.Errorf("set page seg mode: %w", err)
+		}
+	}
+
+	vars := make(map[string]string, len(opts.Variables)+3)
+	maps.Copy(vars, opts.Variables)
+	if opts.Whitelist != "" {
+		vars["tessedit_char_whitelist"] = opts.Whitelist
+	}
+	if opts.Blacklist != "" {
+		vars["tessedit_char_blacklist"] = opts.Blacklist
+	}
+	if opts.DPI > 0 {
+		vars["user_defined_dpi"] = strconv.Itoa(opts.DPI)
+	}
+	for k, v := range vars {
+		if err := client.SetVariable(gosseract.SettableVariable(k), v); err != nil {
+			client.Close()
+
+			return nil, fmt.Errorf("set variable %s: %w", k, err)
+		}
+	}
+
+	return client, nil
+}
+
+// Engine is an ocr.Engine backed by a single Tesseract client.
+// It is not safe for concurrent use.
+//
+// Options found in the context passed to Recognize are merged over the
+// engine options. The client is recreated whenever they change.
+type Engine struct {
+	opts ocr.Options
+
+	client  *gosseract.Client
+	applied ocr.Options
+}
+
+var (
+	_ ocr.Engine        = (*Engine)(nil)
+	_ ocr.HealthChecker = (*Engine)(nil)
+)
+
+// DefaultOptions recognize English and Polish without restricting
+// characters.
+var DefaultOptions = ocr.Options{
+	Languages: []string{"eng", "pol"},
+}
+
+// New creates an engine with DefaultOptions.
+func New() *Engine {
+	e, err := NewWithOptions(DefaultOptions)
+	if err != nil {
+		panic(err) // Default options are valid
+	}
+
+	return e
+}
+
+func NewWithOptions(opts ocr.Options) (*Engine, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("validate: %w", err)
+	}
+	client, err := NewClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("new client: %w", err)
+	}
+
+	return &Engine{
+		opts:    opts,
+		client:  client,
+		applied: opts,
+	}, nil
+}
+
+// NewEngine adapts New to ocr.NewEngineFunc.
+func NewEngine() (ocr.Engine, error) {
+	return New(), nil
+}
+
+// NewEngineFunc returns ocr.NewEngineFunc creating engines with opts.
+func NewEngineFunc(opts ocr.Options) ocr.NewEngineFunc {
+	return func() (ocr.Engine, error) {
+		return NewWithOptions(opts)
+	}
+}
+
+// configure makes sure the client runs with opts merged with ctx options.
+func (e *Engine) configure(ctx context.Context) error {
+	opts := e.opts
+	if override, ok := ocr.OptionsFromContext(ctx); ok {
+		opts = opts.Merge(override)
+	}
+	// Preprocessing and cropping of regions happen before recognition, they
+	// don't need a new client
+	opts.Preprocess, opts.DebugDir = e.applied.Preprocess, e.applied.DebugDir
+	opts.Regions = e.applied.Regions
+	if opts.Equal(e.applied) {
+		return nil
+	}
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	client, err := NewClient(opts)
+	if err != nil {
+		return fmt.Errorf("new client: %w", err)
+	}
+	e.client.Close()
+	e.client = client
+	e.applied = opts
+
+	return nil
+}
+
+func (e *Engine) Recognize(ctx context.Context, content []byte) (*ocr.Recognition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := e.configure(ctx); err != nil {
+		return nil, fmt.Errorf("configure: %w", err)
+	}
+	if err := e.client.SetImageFromBytes(content); err != nil {
+		return nil, fmt.Errorf("set image: %w", err)
+	}
+	text, err := e.client.Text()
+	if err != nil {
+		return nil, fmt.Errorf("text: %w", err)
+	}
+	words, err := e.client.GetBoundingBoxesVerbose()
+	if err != nil {
+		return nil, fmt.Errorf("word boxes: %w", err)
+	}
+	lines, err := e.client.GetBoundingBoxes(gosseract.RIL_TEXTLINE)
+	if err != nil {
+		return nil, fmt.Errorf("line boxes: %w", err)
+	}
+
+	return &ocr.Recognition{
+		Text:   text,
+		Engine: engineName,
+		Words:  toBoxes(words),
+		Lines:  toBoxes(lines),
+	}, nil
+}
+
+func toBoxes(bbs []gosseract.BoundingBox) []ocr.Box {
+	boxes := make([]ocr.Box, 0, len(bbs))
+	for _, bb := range bbs {
+		text := strings.TrimSpace(bb.Word)
+		if text == "" {
+			continue
+		}
+		boxes = append(boxes, ocr.Box{
+			Text:       text,
+			Rect:       bb.Box,
+			Confidence: bb.Confidence,
+			Block:      bb.BlockNum,
+			Paragraph:  bb.ParNum,
+			Line:       bb.LineNum,
+		})
+	}
+
+	return boxes
+}
+
+// HealthCheck reports whether the underlying Tesseract API still responds.
+func (e *Engine) HealthCheck() error {
+	if e.client.Version() == "" {
+		return errors.New("tesseract api not responding")
+	}
+
+	return nil
+}
+
+func (e *Engine) Close() error {
+	if err := e.client.Close(); err != nil {
+		return fmt.Errorf("close client: %w", err)
+	}
+
+	return nil
+}
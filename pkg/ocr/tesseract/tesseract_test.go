@@ -0,0 +1,119 @@
+package tesseract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineRecognize(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		path string
+	}{
+		{
+			desc: "returns_text",
+
+			path: filepath.Join("..", "testdata", "jpg_offer.jpg"),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			content, err := os.ReadFile(tC.path)
+			require.NoError(t, err)
+
+			e := New()
+			defer e.Close()
+
+			rec, err := e.Recognize(context.Background(), content)
+			require.NoError(t, err)
+			require.NotEmpty(t, rec.Text)
+			require.Equal(t, engineName, rec.Engine)
+			require.NotEmpty(t, rec.Words)
+			require.NotEmpty(t, rec.Lines)
+			for _, w := range rec.Words {
+				require.NotEmpty(t, w.Text)
+				require.False(t, w.Rect.Empty())
+			}
+		})
+	}
+}
+
+func TestNewWithOptionsValidates(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewWithOptions(ocr.Options{Languages: []string{"eng+pol"}})
+	require.ErrorIs(t, err, ocr.ErrInvalidOptions)
+}
+
+func TestEngineRecognizeWithContextOptions(t *testing.T) {
+	t.Parallel()
+
+	content, err := os.ReadFile(filepath.Join("..", "testdata", "jpg_offer.jpg"))
+	require.NoError(t, err)
+
+	e := New()
+	defer e.Close()
+
+	ctx := ocr.ContextWithOptions(context.Background(), ocr.Options{
+		Languages: []string{"eng"},
+		PSM:       ocr.PSMSingleBlock,
+	})
+	rec, err := e.Recognize(ctx, content)
+	require.NoError(t, err)
+	require.NotEmpty(t, rec.Text)
+	require.Equal(t, []string{"eng"}, e.applied.Languages)
+
+	_, err = e.Recognize(ocr.ContextWithOptions(ctx, ocr.Options{PSM: 99}), content)
+	require.ErrorIs(t, err, ocr.ErrInvalidOptions)
+}
+
+func TestEngineConfigureKeepsClient(t *testing.T) {
+	t.Parallel()
+
+	e := New()
+	defer e.Close()
+
+	testCases := []struct {
+		desc string
+
+		opts        ocr.Options
+		wantRebuilt bool
+	}{
+		{
+			desc: "regions_only",
+
+			opts: ocr.Options{Regions: []ocr.Region{{Name: "title", Width: 100, Height: 10, Percent: true}}},
+		},
+		{
+			desc: "preprocessing_only",
+
+			opts: ocr.Options{Preprocess: []string{"grayscale"}},
+		},
+		{
+			desc: "languages",
+
+			opts:        ocr.Options{Languages: []string{"pol"}},
+			wantRebuilt: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			client := e.client
+			require.NoError(t, e.configure(ocr.ContextWithOptions(context.Background(), tC.opts)))
+			if tC.wantRebuilt {
+				require.NotSame(t, client, e.client)
+
+				return
+			}
+			require.Same(t, client, e.client)
+		})
+	}
+}
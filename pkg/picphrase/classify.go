@@ -0,0 +1,239 @@
+package picphrase
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Category is the section of a job offer a phrase belongs to.
+type Category string
+
+const (
+	// CategoryRequirement is something a candidate must have or know.
+	CategoryRequirement Category = "requirement"
+	// CategoryNiceToHave is something welcome but not required.
+	CategoryNiceToHave Category = "nice_to_have"
+	// CategoryBenefit is something offered to an employee, e.g. pay.
+	CategoryBenefit Category = "benefit"
+	// CategoryBoilerplate is text of no interest, e.g. headings, company
+	// description or personal data clauses.
+	CategoryBoilerplate Category = "boilerplate"
+	// CategoryOther is a phrase no rule matched.
+	CategoryOther Category = "other"
+)
+
+// Categories returns every category in order of their importance.
+func Categories() []Category {
+	return []Category{
+		CategoryRequirement,
+		CategoryNiceToHave,
+		CategoryBenefit,
+		CategoryBoilerplate,
+		CategoryOther,
+	}
+}
+
+// ParseCategory parses category name, e.g. "nice_to_have".
+func ParseCategory(s string) (Category, error) {
+	c := Category(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Categories() {
+		if c == known {
+			return c, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown phrase category %q", s)
+}
+
+// rule tags phrases containing any of cues with category, weight is
+// confidence of the tag.
+type rule struct {
+	category Category
+	weight   float64
+	cues     []string
+}
+
+// headings start sections of an offer, every following phrase belongs to
+// the section until the next heading. Headings are matched as whole short
+// phrases only.
+var headings = []rule{
+	{CategoryRequirement, sectionWeight, []string{
+		"requirements", "requirement", "must have", "must haves", "we expect",
+		"what we expect", "our expectations", "your profile", "qualifications",
+		"required skills", "skills", "who we are looking for",
+		"what you need", "what you will need", "you have", "you should have",
+		"wymagania", "oczekujemy", "czego oczekujemy", "nasze oczekiwania",
+		"twój profil", "kogo szukamy", "wymagane umiejętności", "umiejętności",
+	}},
+	{CategoryNiceToHave, sectionWeight, []string{
+		"nice to have", "nice to haves", "bonus points", "would be a plus",
+		"will be a plus", "preferred qualifications", "it would be great if",
+		"mile widziane", "dodatkowym atutem będzie", "dodatkowe atuty",
+		"atutem będzie",
+	}},
+	{CategoryBenefit, sectionWeight, []string{
+		"we offer", "what we offer", "benefits", "perks", "perks and benefits",
+		"why join us", "what you get", "salary", "compensation",
+		"oferujemy", "co oferujemy", "benefity", "co zyskujesz",
+		"wynagrodzenie", "dlaczego warto",
+	}},
+	{CategoryBoilerplate, sectionWeight, []string{
+		"about us", "about the company", "about the role", "about the project",
+		"who we are", "your responsibilities", "responsibilities",
+		"your tasks", "how to apply", "recruitment process",
+		"o nas", "o firmie", "o projekcie", "twoje zadania", "obowiązki",
+		"zakres obowiązków", "proces rekrutacji",
+	}},
+}
+
+// cues tag phrases containing them regardless of section.
+var cues = []rule{
+	{CategoryNiceToHave, 0.9, []string{
+		"nice to have", "is a plus", "are a plus", "would be a plus",
+		"will be a plus", "is an advantage", "is a bonus", "bonus points",
+		"is preferred", "are preferred", "preferably", "ideally",
+		"mile widziane", "mile widziana", "mile widziany", "atutem",
+		"dodatkowym atutem", "będzie plusem", "jest plusem",
+	}},
+	{CategoryBenefit, 0.8, []string{
+		"we offer", "salary", "pay", "paid", "compensation", "pln", "eur", "usd",
+		"b2b", "uop", "private medical", "medical care", "healthcare",
+		"health insurance", "life insurance", "multisport", "sport card",
+		"remote work", "flexible hours", "flexible working hours",
+		"training budget", "annual bonus", "stock options", "paid leave",
+		"oferujemy", "wynagrodzenie", "umowa o pracę", "pakiet medyczny",
+		"opieka medyczna", "prywatna opieka", "karta multisport",
+		"ubezpieczenie", "praca zdalna", "elastyczne godziny", "budżet szkoleniowy",
+	}},
+	{CategoryRequirement, 0.7, []string{
+		"we expect", "experience", "years of", "knowledge of", "proficiency",
+		"proficient", "familiarity", "familiar with", "understanding of",
+		"ability to", "must", "required", "degree in", "fluent", "hands-on",
+		"strong", "solid", "expertise",
+		"oczekujemy", "doświadczenie", "znajomość", "umiejętność",
+		"wymagane", "wymagana", "wymagany", "lat doświadczenia",
+		"biegła", "biegły", "wykształcenie",
+	}},
+	{CategoryBoilerplate, 0.8, []string{
+		"apply", "gdpr", "personal data", "privacy policy", "equal opportunity",
+		"equal employment", "click", "cookies", "all rights reserved",
+		"aplikuj", "rodo", "dane osobowe", "danych osobowych", "klauzula",
+		"rekrutacji", "wyrażam zgodę", "polityka prywatności",
+	}},
+}
+
+const (
+	// sectionWeight is confidence of phrases tagged by section only.
+	sectionWeight = 0.7
+	// headingConfidence is confidence of headings tagged as boilerplate.
+	headingConfidence = 0.9
+)
+
+// Classifier tags phrases with a category using keyword rules in English
+// and Polish. Headings, e.g. "we offer" or "wymagania", start a section
+// every following phrase is tagged with, cues found in phrases themselves,
+// e.g. "is a plus" or "multisport", tag them on their own.
+//
+// Classifier keeps section between calls, use a new one for every offer.
+type Classifier struct {
+	section Category
+}
+
+// Classify returns category of phrase and confidence of it (0-1). Phrases
+// no rule matched are CategoryOther with zero confidence.
+func (c *Classifier) Classify(phrase string) (Category, float64) {
+	text := cueText(phrase)
+	if text == "  " {
+		return CategoryOther, 0
+	}
+
+	// Headings are boilerplate, inline headings like "nice to have: docker"
+	// tag the rest of the phrase too
+	heading, rest, inline := strings.Cut(phrase, ":")
+	if !inline || strings.TrimSpace(rest) == "" {
+		heading, rest = phrase, ""
+	}
+	if section, ok := headingCategory(cueText(heading)); ok {
+		c.section = section
+		if strings.TrimSpace(rest) == "" {
+			return CategoryBoilerplate, headingConfidence
+		}
+		text = cueText(rest)
+	}
+
+	scores := make(map[Category]float64)
+	if c.section != "" {
+		scores[c.section] = sectionWeight
+	}
+	for _, r := range cues {
+		for _, cue := range r.cues {
+			if strings.Contains(text, " "+cue+" ") {
+				scores[r.category] += r.weight
+
+				break
+			}
+		}
+	}
+
+	// Nice to have cues qualify requirements, "knowledge of aws is a plus"
+	// is not required
+	if scores[CategoryNiceToHave] > 0 {
+		scores[CategoryNiceToHave] += scores[CategoryRequirement]
+		delete(scores, CategoryRequirement)
+	}
+
+	best, second := CategoryOther, 0.0
+	for _, category := range Categories() {
+		score := scores[category]
+		switch {
+		case score == 0:
+			continue
+		case best == CategoryOther || score > scores[best]:
+			second = scores[best]
+			best = category
+		case score > second:
+			second = score
+		}
+	}
+	if best == CategoryOther {
+		return CategoryOther, 0
+	}
+
+	// Agreeing rules add up, competing ones take away from the best one
+	confidence := min(scores[best], 1) - second/2
+
+	return best, max(min(confidence, 1), 0.1)
+}
+
+func headingCategory(text string) (Category, bool) {
+	for _, r := range headings {
+		for _, h := range r.cues {
+			if text == " "+h+" " {
+				return r.category, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// cueText returns lower cased words of s separated by single spaces,
+// including leading and trailing one, with punctuation around them removed
+// so that cues match whole words only.
+func cueText(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",;:!?()[]\"'•·*|", r)
+	})
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		w := strings.TrimFunc(f, func(r rune) bool {
+			return unicode.IsPunct(r) && r != '+' && r != '#'
+		})
+		if w != "" {
+			words = append(words, w)
+		}
+	}
+
+	return " " + strings.Join(words, " ") + " "
+}
@@ -0,0 +1,154 @@
+package picphrase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifier(t *testing.T) {
+	t.Parallel()
+
+	type tag struct {
+		category Category
+		// confidence is the lowest expected confidence
+		confidence float64
+	}
+
+	testCases := []struct {
+		desc string
+
+		phrases []string
+		want    []tag
+	}{
+		{
+			desc: "english_offer_sections",
+
+			phrases: []string{
+				"About us",
+				"We build payment systems for banks",
+				"Requirements:",
+				"3+ years of experience with Go",
+				"Kubernetes",
+				"Knowledge of AWS is a plus",
+				"Nice to have",
+				"Terraform",
+				"What we offer",
+				"Private medical care and Multisport card",
+				"Please apply by clicking the button below",
+			},
+			want: []tag{
+				{CategoryBoilerplate, 0.9},
+				{CategoryBoilerplate, 0.7},
+				{CategoryBoilerplate, 0.9},
+				{CategoryRequirement, 1},
+				{CategoryRequirement, 0.7},
+				{CategoryNiceToHave, 0.5},
+				{CategoryBoilerplate, 0.9},
+				{CategoryNiceToHave, 0.7},
+				{CategoryBoilerplate, 0.9},
+				{CategoryBenefit, 1},
+				{CategoryBoilerplate, 0.4},
+			},
+		},
+		{
+			desc: "polish_offer_sections",
+
+			phrases: []string{
+				"Wymagania",
+				"Doświadczenie w pracy z Go",
+				"Znajomość PostgreSQL mile widziana",
+				"Oferujemy",
+				"Wynagrodzenie 20 000 - 25 000 PLN netto B2B",
+				"Pakiet medyczny",
+			},
+			want: []tag{
+				{CategoryBoilerplate, 0.9},
+				{CategoryRequirement, 1},
+				{CategoryNiceToHave, 0.5},
+				{CategoryBoilerplate, 0.9},
+				{CategoryBenefit, 1},
+				{CategoryBenefit, 1},
+			},
+		},
+		{
+			desc: "cues_without_sections",
+
+			phrases: []string{
+				"golang developer",
+				"strong knowledge of sql",
+				"docker is a plus",
+				"salary up to 30k",
+				"",
+			},
+			want: []tag{
+				{CategoryOther, 0},
+				{CategoryRequirement, 0.7},
+				{CategoryNiceToHave, 0.9},
+				{CategoryBenefit, 0.8},
+				{CategoryOther, 0},
+			},
+		},
+		{
+			desc: "inline_heading_tags_the_rest",
+
+			phrases: []string{
+				"Nice to have: Kafka, RabbitMQ",
+				"Elasticsearch",
+			},
+			want: []tag{
+				{CategoryNiceToHave, 0.7},
+				{CategoryNiceToHave, 0.7},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c := new(Classifier)
+			for i, phrase := range tC.phrases {
+				category, confidence := c.Classify(phrase)
+				require.Equal(t, tC.want[i].category, category, phrase)
+				require.GreaterOrEqual(t, confidence, tC.want[i].confidence, phrase)
+				require.LessOrEqual(t, confidence, 1.0, phrase)
+				if category == CategoryOther {
+					require.Zero(t, confidence, phrase)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCategory(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range Categories() {
+		got, err := ParseCategory(" " + strings.ToUpper(string(c)) + " ")
+		require.NoError(t, err)
+		require.Equal(t, c, got)
+	}
+
+	_, err := ParseCategory("requirements")
+	require.Error(t, err)
+}
+
+func TestScannerClassifies(t *testing.T) {
+	t.Parallel()
+
+	text := "Requirements\nGo\nWe offer\nRemote work\n"
+
+	got := make(map[string]Category)
+	s := &Scanner{Engine: testEngine(t)}
+	for ph, err := range s.PhrasesFrom(context.Background(), strings.NewReader(text)) {
+		require.NoError(t, err)
+		got[ph.String()] = ph.Category()
+		require.Positive(t, ph.Confidence())
+	}
+	require.Equal(t, map[string]Category{
+		"requirements": CategoryBoilerplate,
+		"go":           CategoryRequirement,
+		"we offer":     CategoryBoilerplate,
+		"remote work":  CategoryBenefit,
+	}, got)
+}
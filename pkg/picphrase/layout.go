@@ -0,0 +1,315 @@
+package picphrase
+
+import (
+	"bufio"
+	"cmp"
+	"image"
+	"slices"
+	"strings"
+
+	"github.com/kndrad/piccrack/pkg/ocr"
+)
+
+// Block is a group of lines of the same column or panel of a page, e.g.
+// a paragraph of requirements or a benefits sidebar.
+type Block struct {
+	// Index is the position of the block in reading order of the page,
+	// starting at 0.
+	Index int
+	Page  int
+	Rect  image.Rectangle
+	Lines []string
+
+	// text is Lines before lower casing.
+	text []string
+}
+
+// Layout tuning, in multiples of median line height.
+const (
+	// columnGap is the narrowest horizontal gap separating columns.
+	columnGap = 1.5
+	// lineGap is the widest vertical gap between lines of a block.
+	lineGap = 1.0
+)
+
+// segment is a line of text, or a part of it separated from the rest by
+// a gap wide enough to be a column gutter.
+type segment struct {
+	text string
+	rect image.Rectangle
+}
+
+// Blocks returns layout blocks of page in reading order. Lines are grouped
+// using their boxes, columns are read one after another, top to bottom and
+// left to right, so lines of side by side panels are never interleaved.
+// Regions are laid out separately, in order. Pages without boxes are
+// a single block of their text lines.
+func Blocks(p ocr.Page) []Block {
+	var blocks []Block
+	if len(p.Regions) > 0 {
+		for _, r := range p.Regions {
+			blocks = append(blocks, layout(p.Number, r.Text, r.Words, r.Lines)...)
+		}
+	} else {
+		blocks = layout(p.Number, p.Text, p.Words, p.Lines)
+	}
+	for i := range blocks {
+		blocks[i].Index = i
+	}
+
+	return blocks
+}
+
+func layout(page int, text string, words, lines []ocr.Box) []Block {
+	segs, height := segments(words, lines)
+	if len(segs) == 0 {
+		block := newBlock(page, text)
+		if len(block.Lines) == 0 {
+			return nil
+		}
+
+		return []Block{block}
+	}
+
+	groups := groupLines(segs, lineGap*height)
+	blocks := make([]Block, 0, len(groups))
+	for _, g := range groups {
+		rect := g[0].rect
+		texts := make([]string, 0, len(g))
+		for _, s := range g {
+			rect = rect.Union(s.rect)
+			texts = append(texts, s.text)
+		}
+		block := newBlock(page, strings.Join(texts, "\n"))
+		block.Rect = rect
+		blocks = append(blocks, block)
+	}
+
+	return readingOrder(blocks, int(columnGap*height))
+}
+
+// newBlock returns block of lines of text, read the way textproc.ScanLines
+// reads them.
+func newBlock(page int, text string) Block {
+	b := Block{Page: page}
+
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := strings.Trim(sc.Text(), " ")
+		b.text = append(b.text, line)
+		b.Lines = append(b.Lines, strings.ToLower(line))
+	}
+
+	return b
+}
+
+// segments returns lines built from word boxes, or line boxes if there are
+// no words, and median line height. Boxes without position yield none.
+func segments(words, lines []ocr.Box) ([]segment, float64) {
+	var segs []segment
+	if len(words) > 0 {
+		segs = wordSegments(words)
+	} else {
+		for _, l := range lines {
+			if text := strings.TrimSpace(l.Text); text != "" {
+				segs = append(segs, segment{text: text, rect: l.Rect})
+			}
+		}
+	}
+	for _, s := range segs {
+		if s.rect.Empty() {
+			return nil, 0
+		}
+	}
+	if len(segs) == 0 {
+		return nil, 0
+	}
+
+	heights := make([]int, 0, len(segs))
+	for _, s := range segs {
+		heights = append(heights, s.rect.Dy())
+	}
+	slices.Sort(heights)
+
+	return segs, float64(heights[len(heights)/2])
+}
+
+// wordSegments joins words of the same line, splitting lines at gaps wider
+// than columnGap, which appear when a line runs across columns.
+func wordSegments(words []ocr.Box) []segment {
+	var (
+		segs []segment
+		cur  []ocr.Box
+	)
+	flush := func() {
+		if len(cur) == 0 {
+			return
+		}
+		texts := make([]string, 0, len(cur))
+		rect := cur[0].Rect
+		for _, w := range cur {
+			texts = append(texts, w.Text)
+			rect = rect.Union(w.Rect)
+		}
+		segs = append(segs, segment{text: strings.Join(texts, " "), rect: rect})
+		cur = cur[:0]
+	}
+	for _, w := range words {
+		if strings.TrimSpace(w.Text) == "" {
+			continue
+		}
+		if len(cur) > 0 {
+			prev := cur[len(cur)-1]
+			sameLine := prev.Block == w.Block && prev.Paragraph == w.Paragraph && prev.Line == w.Line
+			gap := w.Rect.Min.X - prev.Rect.Max.X
+			if !sameLine || float64(gap) > columnGap*float64(max(prev.Rect.Dy(), w.Rect.Dy())) {
+				flush()
+			}
+		}
+		cur = append(cur, w)
+	}
+	flush()
+
+	return segs
+}
+
+// groupLines links every line with the line right below it if each is the
+// only one overlapping the other horizontally on that side, within maxGap.
+// Chains of linked lines are groups, a line spanning two columns ends
+// groups of both.
+func groupLines(segs []segment, maxGap float64) [][]segment {
+	below := make([]int, len(segs))
+	above := make([]int, len(segs))
+	for i := range segs {
+		below[i] = nearest(segs, i, maxGap, 1)
+		above[i] = nearest(segs, i, maxGap, -1)
+	}
+
+	var groups [][]segment
+	for i := range segs {
+		if j := above[i]; j >= 0 && below[j] == i {
+			continue // Not the first line of a group
+		}
+		group := []segment{segs[i]}
+		for j := i; below[j] >= 0 && above[below[j]] == j; {
+			j = below[j]
+			group = append(group, segs[j])
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// nearest returns index of the only line closest to segs[i] below it
+// (dir 1) or above it (dir -1) and overlapping it horizontally, -1 if there
+// are none or more of them.
+func nearest(segs []segment, i int, maxGap float64, dir int) int {
+	s := segs[i]
+	tolerance := s.rect.Dy() / 2
+
+	found, best := -1, 0
+	ambiguous := false
+	for j, o := range segs {
+		if j == i || min(s.rect.Max.X, o.rect.Max.X) <= max(s.rect.Min.X, o.rect.Min.X) {
+			continue
+		}
+		var gap int
+		if dir > 0 {
+			gap = o.rect.Min.Y - s.rect.Max.Y
+		} else {
+			gap = s.rect.Min.Y - o.rect.Max.Y
+		}
+		if gap < -tolerance || float64(gap) > maxGap {
+			continue
+		}
+		switch {
+		case found < 0 || gap < best-tolerance:
+			found, best, ambiguous = j, gap, false
+		case gap <= best+tolerance:
+			ambiguous = true
+			best = min(best, gap)
+		}
+	}
+	if ambiguous {
+		return -1
+	}
+
+	return found
+}
+
+// readingOrder orders blocks with recursive cuts. Columns separated by
+// a vertical gutter come first, left to right. Otherwise blocks are cut
+// into horizontal bands, top to bottom, keeping consecutive bands sharing
+// columns together.
+func readingOrder(blocks []Block, gutter int) []Block {
+	if len(blocks) <= 1 {
+		return blocks
+	}
+	if cols := cut(blocks, gutter, func(r image.Rectangle) (int, int) { return r.Min.X, r.Max.X }); len(cols) > 1 {
+		return orderParts(cols, gutter)
+	}
+
+	bands := cut(blocks, 0, func(r image.Rectangle) (int, int) { return r.Min.Y, r.Max.Y })
+	if len(bands) == 1 {
+		slices.SortStableFunc(blocks, func(a, b Block) int {
+			return cmp.Or(cmp.Compare(a.Rect.Min.Y, b.Rect.Min.Y), cmp.Compare(a.Rect.Min.X, b.Rect.Min.X))
+		})
+
+		return blocks
+	}
+
+	// Columns broken by gaps which happen to line up are joined back,
+	// whole blocks can't be cut into columns so there are still many bands
+	merged := [][]Block{bands[0]}
+	for _, band := range bands[1:] {
+		last := merged[len(merged)-1]
+		if columns(last, gutter) && columns(band, gutter) && columns(append(slices.Clip(last), band...), gutter) {
+			merged[len(merged)-1] = append(slices.Clip(last), band...)
+
+			continue
+		}
+		merged = append(merged, band)
+	}
+
+	return orderParts(merged, gutter)
+}
+
+func orderParts(parts [][]Block, gutter int) []Block {
+	ordered := make([]Block, 0)
+	for _, part := range parts {
+		ordered = append(ordered, readingOrder(part, gutter)...)
+	}
+
+	return ordered
+}
+
+func columns(blocks []Block, gutter int) bool {
+	return len(cut(blocks, gutter, func(r image.Rectangle) (int, int) { return r.Min.X, r.Max.X })) > 1
+}
+
+// cut splits blocks projected on an axis at gaps of at least minGap,
+// parts are ordered along the axis.
+func cut(blocks []Block, minGap int, span func(image.Rectangle) (int, int)) [][]Block {
+	sorted := slices.Clone(blocks)
+	slices.SortStableFunc(sorted, func(a, b Block) int {
+		lo, _ := span(a.Rect)
+		other, _ := span(b.Rect)
+
+		return cmp.Compare(lo, other)
+	})
+
+	parts := [][]Block{{sorted[0]}}
+	_, end := span(sorted[0].Rect)
+	for _, b := range sorted[1:] {
+		lo, hi := span(b.Rect)
+		if lo-end >= max(minGap, 0) {
+			parts = append(parts, []Block{b})
+		} else {
+			parts[len(parts)-1] = append(parts[len(parts)-1], b)
+		}
+		end = max(end, hi)
+	}
+
+	return parts
+}
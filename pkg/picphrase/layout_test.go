@@ -0,0 +1,196 @@
+package picphrase
+
+import (
+	"context"
+	"image"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/stretchr/testify/require"
+)
+
+// lineHeight of words built by textLine.
+const lineHeight = 20
+
+// textLine returns word boxes of text starting at x, y, every character
+// 10 pixels wide and words separated by 8 pixels.
+func textLine(block, line, x, y int, text string) []ocr.Box {
+	words := make([]ocr.Box, 0)
+	for _, w := range strings.Fields(text) {
+		width := 10 * len(w)
+		words = append(words, ocr.Box{
+			Text:       w,
+			Rect:       image.Rect(x, y, x+width, y+lineHeight),
+			Confidence: 90,
+			Block:      block,
+			Paragraph:  1,
+			Line:       line,
+		})
+		x += width + 8
+	}
+
+	return words
+}
+
+func blockLines(blocks []Block) [][]string {
+	lines := make([][]string, 0, len(blocks))
+	for _, b := range blocks {
+		lines = append(lines, b.Lines)
+	}
+
+	return lines
+}
+
+func TestBlocks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		words []ocr.Box
+		want  [][]string
+	}{
+		{
+			desc: "single_column_is_a_single_block",
+
+			words: concat(
+				textLine(1, 1, 0, 0, "golang developer"),
+				textLine(1, 2, 0, 30, "warsaw remote"),
+			),
+			want: [][]string{{"golang developer", "warsaw remote"}},
+		},
+		{
+			desc: "columns_are_read_one_after_another",
+
+			words: concat(
+				textLine(1, 1, 0, 0, "Senior Golang Developer at Company in Warsaw"),
+				textLine(2, 1, 0, 40, "requirements"),
+				textLine(2, 2, 0, 70, "go experience"),
+				textLine(2, 3, 0, 100, "kubernetes"),
+				textLine(3, 1, 400, 40, "benefits"),
+				textLine(3, 2, 400, 70, "private healthcare"),
+				textLine(3, 3, 400, 100, "remote work"),
+			),
+			want: [][]string{
+				{"senior golang developer at company in warsaw"},
+				{"requirements", "go experience", "kubernetes"},
+				{"benefits", "private healthcare", "remote work"},
+			},
+		},
+		{
+			desc: "lines_running_across_columns_are_split",
+
+			// Single block segmentation reads both columns as one line
+			words: concat(
+				textLine(1, 1, 0, 0, "requirements"), textLine(1, 1, 400, 0, "benefits"),
+				textLine(1, 2, 0, 30, "go experience"), textLine(1, 2, 400, 30, "private healthcare"),
+				textLine(1, 3, 0, 60, "kubernetes"), textLine(1, 3, 400, 60, "remote work"),
+			),
+			want: [][]string{
+				{"requirements", "go experience", "kubernetes"},
+				{"benefits", "private healthcare", "remote work"},
+			},
+		},
+		{
+			desc: "paragraph_gaps_lined_up_in_columns_keep_columns_together",
+
+			words: concat(
+				textLine(1, 1, 0, 0, "Senior Golang Developer at Company in Warsaw"),
+				textLine(2, 1, 0, 40, "about us"),
+				textLine(2, 2, 0, 70, "we build payments"),
+				textLine(3, 1, 0, 140, "requirements"),
+				textLine(3, 2, 0, 170, "go experience"),
+				textLine(4, 1, 400, 40, "benefits"),
+				textLine(4, 2, 400, 70, "private healthcare"),
+				textLine(5, 1, 400, 140, "perks"),
+				textLine(5, 2, 400, 170, "gym card"),
+				textLine(6, 1, 0, 240, "Apply now at company careers page and join the team"),
+			),
+			want: [][]string{
+				{"senior golang developer at company in warsaw"},
+				{"about us", "we build payments"},
+				{"requirements", "go experience"},
+				{"benefits", "private healthcare"},
+				{"perks", "gym card"},
+				{"apply now at company careers page and join the team"},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			blocks := Blocks(ocr.Page{Number: 1, Words: tC.words})
+			require.Equal(t, tC.want, blockLines(blocks))
+			for i, b := range blocks {
+				require.Equal(t, i, b.Index)
+				require.Equal(t, 1, b.Page)
+				require.False(t, b.Rect.Empty())
+			}
+		})
+	}
+}
+
+func concat(boxes ...[]ocr.Box) []ocr.Box {
+	var out []ocr.Box
+	for _, b := range boxes {
+		out = append(out, b...)
+	}
+
+	return out
+}
+
+func TestBlocksWithoutBoxes(t *testing.T) {
+	t.Parallel()
+
+	blocks := Blocks(ocr.Page{
+		Number: 2,
+		Text:   "Golang developer\n\nWarsaw",
+		Words:  []ocr.Box{{Text: "Golang"}, {Text: "developer"}, {Text: "Warsaw"}},
+	})
+	require.Len(t, blocks, 1)
+	require.Equal(t, []string{"golang developer", "", "warsaw"}, blocks[0].Lines)
+	require.Equal(t, 2, blocks[0].Page)
+
+	require.Empty(t, Blocks(ocr.Page{Number: 1}))
+}
+
+func TestBlocksRegions(t *testing.T) {
+	t.Parallel()
+
+	// Regions keep their order even if the second one is higher
+	blocks := Blocks(ocr.Page{
+		Number: 1,
+		Regions: []ocr.RegionText{
+			{Name: "description", Words: textLine(1, 1, 0, 300, "go experience")},
+			{Name: "title", Words: textLine(2, 1, 0, 0, "golang developer")},
+		},
+	})
+	require.Equal(t, [][]string{{"go experience"}, {"golang developer"}}, blockLines(blocks))
+}
+
+func TestScannerBlocks(t *testing.T) {
+	t.Parallel()
+
+	e := &boxesEngine{
+		words: concat(
+			textLine(1, 1, 0, 0, "requirements"), textLine(1, 1, 400, 0, "benefits"),
+			textLine(1, 2, 0, 30, "go experience"), textLine(1, 2, 400, 30, "remote work"),
+		),
+	}
+	s := &Scanner{Engine: e}
+
+	phrases, err := s.ScanAt(context.Background(), filepath.Join("testdata", "0.png"))
+	require.NoError(t, err)
+
+	blocks := make(map[string]int)
+	for ph := range phrases {
+		blocks[ph.String()] = ph.Block()
+	}
+	require.Equal(t, map[string]int{
+		"requirements":  0,
+		"go experience": 0,
+		"benefits":      1,
+		"remote work":   1,
+	}, blocks)
+}
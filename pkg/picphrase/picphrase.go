@@ -5,16 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"os"
 	"path/filepath"
-	"sync"
+	"strings"
 
+	"github.com/kndrad/piccrack/pkg/manifest"
 	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/pproc"
+	"github.com/kndrad/piccrack/pkg/textdoc"
 	"github.com/kndrad/piccrack/pkg/textproc"
 )
 
 type Phrase struct {
 	value string
+	path  string
+	page  int
+	line  int
+	block int
+
+	category   Category
+	confidence float64
 }
 
 func (ph *Phrase) String() string {
@@ -25,107 +36,447 @@ func (ph *Phrase) String() string {
 	return ph.value
 }
 
-// ScanAt uses ocr client to scan for phrases found in image located at path.
-func ScanAt(ctx context.Context, path string) (<-chan *Phrase, error) {
-	tc := ocr.NewClient()
-	defer tc.Close()
+// Path returns path of the file the phrase was found in, empty for phrases
+// read from a reader.
+func (ph *Phrase) Path() string {
+	if ph == nil {
+		return ""
+	}
 
-	sentences := make(chan *Phrase)
+	return ph.path
+}
 
-	res, err := ocr.ScanFile(tc, filepath.Clean(path))
-	if err != nil {
-		return nil, fmt.Errorf("single ocr: %w", err)
+// Page returns number of the image page the phrase was found on,
+// starting at 1.
+func (ph *Phrase) Page() int {
+	if ph == nil {
+		return 0
 	}
 
-	var wg sync.WaitGroup
-	for line := range textproc.ScanLines(res.Text()) {
-		wg.Add(1)
-		go func() {
-			select {
-			case sentences <- &Phrase{line}:
-			case <-ctx.Done():
+	return ph.page
+}
+
+// Line returns number of the line of the page the phrase was found on,
+// starting at 1 in reading order.
+func (ph *Phrase) Line() int {
+	if ph == nil {
+		return 0
+	}
+
+	return ph.line
+}
+
+// Block returns index of the layout block of the page the phrase was found
+// in, starting at 0 in reading order. Phrases of different blocks, e.g.
+// a sidebar and main content, never come from the same line.
+func (ph *Phrase) Block() int {
+	if ph == nil {
+		return 0
+	}
+
+	return ph.block
+}
+
+// Mode is the way phrases are built from lines of a page.
+type Mode string
+
+const (
+	// ModeLines makes every line a phrase.
+	ModeLines Mode = "lines"
+	// ModeSentences rejoins lines of a block wrapped by ocr and splits them
+	// into sentences, see textproc.AssembleSentences.
+	ModeSentences Mode = "sentences"
+)
+
+var ErrUnknownMode = errors.New("unknown phrase mode")
+
+// ParseMode parses mode name, empty one is ModeLines.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return ModeLines, nil
+	case ModeLines, ModeSentences:
+		return m, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownMode, s)
+	}
+}
+
+// Category returns section of the offer the phrase belongs to, see
+// Classifier.
+func (ph *Phrase) Category() Category {
+	if ph == nil {
+		return CategoryOther
+	}
+
+	return ph.category
+}
+
+// Confidence returns confidence of the phrase category (0-1).
+func (ph *Phrase) Confidence() float64 {
+	if ph == nil {
+		return 0
+	}
+
+	return ph.confidence
+}
+
+// pagePhrases yields phrases of every page in reading order, block by
+// block. Sentences of a block are numbered with their first line. Phrases
+// are classified in order, as parts of a single offer.
+func pagePhrases(path string, pages []ocr.Page, mode Mode) iter.Seq[*Phrase] {
+	return func(yield func(*Phrase) bool) {
+		c := new(Classifier)
+		classified := func(ph *Phrase) *Phrase {
+			ph.category, ph.confidence = c.Classify(ph.value)
+
+			return ph
+		}
+		for _, p := range pages {
+			line := 0
+			for _, b := range Blocks(p) {
+				if mode == ModeSentences {
+					for _, sentence := range textproc.AssembleSentences(b.text) {
+						ph := &Phrase{
+							value: strings.ToLower(sentence.Text),
+							path:  path,
+							page:  p.Number,
+							line:  line + sentence.Start + 1,
+							block: b.Index,
+						}
+						if !yield(classified(ph)) {
+							return
+						}
+					}
+					line += len(b.Lines)
+
+					continue
+				}
+				for _, value := range b.Lines {
+					line++
+					ph := &Phrase{value: value, path: path, page: p.Number, line: line, block: b.Index}
+					if !yield(classified(ph)) {
+						return
+					}
+				}
 			}
-			wg.Done()
-		}()
+		}
 	}
+}
+
+// stream sends phrases in order on a channel, which is closed after the
+// last one or once ctx is done. Receivers must drain the channel or cancel
+// ctx, otherwise the sending goroutine is blocked.
+func stream(ctx context.Context, phrases iter.Seq[*Phrase]) <-chan *Phrase {
+	out := make(chan *Phrase)
 	go func() {
-		wg.Wait()
-		close(sentences)
+		defer close(out)
+
+		for ph := range phrases {
+			select {
+			case out <- ph:
+			case <-ctx.Done():
+				return
+			}
+		}
 	}()
 
-	return sentences, nil
+	return out
 }
 
-// ScanDir performs OCR on all images found in dir.
-func ScanDir(ctx context.Context, dir string) (<-chan *Phrase, error) {
-	dir = filepath.Clean(dir)
+// Scanner scans phrases from images with an ocr engine.
+//
+// Phrases come in order they appear in sources: page by page, line by line
+// in reading order. Directories are scanned file by file, ordered by path.
+type Scanner struct {
+	Engine ocr.Engine
 
-	info, err := os.Stat(dir)
-	if err != nil {
-		return nil, fmt.Errorf("stat: %w", err)
+	// NewEngine, if set, creates engines of Workers workers which recognize
+	// images of directories concurrently, each with its own engine. If
+	// Workers is not positive, the number of CPUs is used. Phrases still
+	// come in order of paths.
+	NewEngine ocr.NewEngineFunc
+	Workers   int
+
+	// MinConfidence drops recognized words with lower confidence (0-100)
+	// before phrases are built. Zero keeps every word.
+	MinConfidence float64
+
+	// Mode is the way phrases are built, ModeLines if it's empty.
+	Mode Mode
+
+	// Walk selects files of directories to scan.
+	Walk pproc.WalkOptions
+
+	// Manifest, if set, records files of directories once they're scanned,
+	// so that scanning a directory again skips files which didn't change.
+	// Full scans every file anyway.
+	Manifest *manifest.File
+	Full     bool
+
+	// OnFile, if set, is called with status of every file of a directory
+	// compared to its record in the manifest, before it's scanned or
+	// skipped.
+	OnFile func(path string, status manifest.Status)
+
+	// OnError, if set, is called by ScanDir with a *FileError of every
+	// file which can't be read or scanned, and with the error which stops
+	// scanning early, e.g. when the manifest can't be written.
+	OnError func(err error)
+}
+
+// Phrases yields phrases found in src. Iteration stops at the first error,
+// which is yielded with a nil phrase, e.g. when ctx is done.
+func (s *Scanner) Phrases(ctx context.Context, src Source) iter.Seq2[*Phrase, error] {
+	return func(yield func(*Phrase, error) bool) {
+		pages, err := src.Pages(ctx)
+		if err != nil {
+			yield(nil, fmt.Errorf("pages: %w", err))
+
+			return
+		}
+		for ph := range pagePhrases(sourcePath(src), pages, s.Mode) {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+
+				return
+			}
+			if !yield(ph, nil) {
+				return
+			}
+		}
 	}
-	if !info.IsDir() {
-		return nil, errors.New("path must be dir")
+}
+
+// PhrasesAt yields phrases found in image or document located at path,
+// see Phrases.
+func (s *Scanner) PhrasesAt(ctx context.Context, path string) iter.Seq2[*Phrase, error] {
+	return func(yield func(*Phrase, error) bool) {
+		path = filepath.Clean(path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			yield(nil, fmt.Errorf("read file: %w", err))
+
+			return
+		}
+		for ph, err := range s.Phrases(ctx, s.NewSource(path, content)) {
+			if !yield(ph, err) {
+				return
+			}
+		}
+	}
+}
+
+// PhrasesInDir yields phrases found in all images and documents in dir,
+// file by file in order of their paths, see Phrases. Dir may be an archive
+// if s.Walk.Archives is set. A file which can't be read or scanned is
+// yielded as a *FileError and the next one is scanned.
+func (s *Scanner) PhrasesInDir(ctx context.Context, dir string) iter.Seq2[*Phrase, error] {
+	return func(yield func(*Phrase, error) bool) {
+		files, err := s.files(ctx, dir)
+		if err != nil {
+			yield(nil, err)
+
+			return
+		}
+		for ph, err := range s.dirPhrases(ctx, files) {
+			if !yield(ph, err) {
+				return
+			}
+		}
 	}
+}
+
+// dirPhrases yields phrases of files, see PhrasesInDir. A file is recorded
+// in the manifest once its last phrase is yielded.
+func (s *Scanner) dirPhrases(ctx context.Context, files []dirFile) iter.Seq2[*Phrase, error] {
+	return func(yield func(*Phrase, error) bool) {
+		var images *prefetch
+		if s.NewEngine != nil {
+			var err error
+			if images, err = s.prefetch(ctx, files); err != nil {
+				yield(nil, err)
+
+				return
+			}
+			if images != nil {
+				defer images.stop()
+			}
+		}
+		for _, f := range files {
+			ok, err := s.filePhrases(ctx, f, images, yield)
+			if !ok {
+				return
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					yield(nil, err)
 
-	tc := ocr.NewClient()
-	defer tc.Close()
+					return
+				}
+				if !yield(nil, &FileError{Path: f.path, Err: err}) {
+					return
+				}
+
+				continue
+			}
+			if err := s.scanned(f); err != nil {
+				yield(nil, err)
 
-	texts := make([]string, 0)
+				return
+			}
+		}
+	}
+}
 
-	results, err := ocr.ScanDir(ctx, tc, dir)
+// filePhrases yields phrases of f and returns error of the file. Images
+// come from images if it's not nil. It returns false if yield did.
+func (s *Scanner) filePhrases(ctx context.Context, f dirFile, images *prefetch, yield func(*Phrase, error) bool) (bool, error) {
+	var (
+		src Source
+		err error
+	)
+	if images != nil && f.err == nil && f.kind == textdoc.Unknown {
+		src, err = images.source(ctx, f, s.MinConfidence)
+	} else {
+		src, err = s.dirSource(f)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("ocr dir: %w", err)
+		return true, err
 	}
-	for _, res := range results {
-		texts = append(texts, res.Text())
+	for ph, err := range s.Phrases(ctx, src) {
+		if err != nil {
+			return true, err
+		}
+		if !yield(ph, nil) {
+			return false, nil
+		}
 	}
 
-	out := make(chan *Phrase)
+	return true, nil
+}
 
-	var wg sync.WaitGroup
-	for _, text := range texts {
-		wg.Add(1)
-		go func() {
-			for line := range textproc.ScanLines(text) {
-				out <- &Phrase{line}
+// PhrasesFrom yields phrases found in image or document read from r,
+// see Phrases. Text documents are recognized by content only.
+func (s *Scanner) PhrasesFrom(ctx context.Context, r io.Reader) iter.Seq2[*Phrase, error] {
+	return func(yield func(*Phrase, error) bool) {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			yield(nil, fmt.Errorf("read all: %w", err))
+
+			return
+		}
+		for ph, err := range s.Phrases(ctx, s.NewSource("", content)) {
+			if !yield(ph, err) {
+				return
 			}
-			wg.Done()
-		}()
+		}
 	}
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
+}
 
-	return out, nil
+// ScanAt scans phrases found in image located at path with default options.
+func ScanAt(ctx context.Context, e ocr.Engine, path string) (<-chan *Phrase, error) {
+	return (&Scanner{Engine: e}).ScanAt(ctx, path)
 }
 
-func ScanReader(ctx context.Context, r io.Reader) (<-chan *Phrase, error) {
-	tc := ocr.NewClient()
-	defer tc.Close()
+// ScanAt scans phrases found in image or document located at path.
+// Text documents are read as they are, other files go through ocr.
+func (s *Scanner) ScanAt(ctx context.Context, path string) (<-chan *Phrase, error) {
+	path = filepath.Clean(path)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	return s.ScanSource(ctx, s.NewSource(path, content))
+}
 
-	res, err := ocr.ScanFrom(tc, r)
+// ScanSource scans phrases found in src. Phrases are sent in order, the
+// channel is closed after the last one or once ctx is done.
+func (s *Scanner) ScanSource(ctx context.Context, src Source) (<-chan *Phrase, error) {
+	pages, err := src.Pages(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("scan from: %w", err)
+		return nil, fmt.Errorf("single ocr: %w", err)
 	}
 
-	out := make(chan *Phrase)
+	return stream(ctx, pagePhrases(sourcePath(src), pages, s.Mode)), nil
+}
 
-	var wg sync.WaitGroup
-	for line := range textproc.ScanLines(res.Text()) {
-		wg.Add(1)
+// ScanDir scans phrases found in all images in dir with default options.
+func ScanDir(ctx context.Context, e ocr.Engine, dir string) (<-chan *Phrase, error) {
+	return (&Scanner{Engine: e}).ScanDir(ctx, dir)
+}
 
-		go func() {
-			defer wg.Done()
-			out <- &Phrase{line}
-		}()
+// ScanDir scans phrases found in all images and documents in dir. Phrases
+// are sent file by file in order of paths, and a file is recorded in the
+// manifest once its last phrase is sent. Files which can't be read or
+// scanned are reported to s.OnError and skipped.
+func (s *Scanner) ScanDir(ctx context.Context, dir string) (<-chan *Phrase, error) {
+	files, err := s.files(ctx, dir)
+	if err != nil {
+		return nil, err
 	}
 
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
+	return stream(ctx, func(yield func(*Phrase) bool) {
+		for ph, err := range s.dirPhrases(ctx, files) {
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if s.OnError != nil {
+					s.OnError(err)
+				}
+				if !errors.As(err, new(*FileError)) {
+					return
+				}
+
+				continue
+			}
+			if !yield(ph) {
+				return
+			}
+		}
+	}), nil
+}
+
+// files returns files of dir ordered by path.
+func (s *Scanner) files(ctx context.Context, dir string) ([]dirFile, error) {
+	dir = filepath.Clean(dir)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	if !info.IsDir() && !(s.Walk.Archives && pproc.IsArchive(dir)) {
+		return nil, errors.New("path must be dir or archive")
+	}
+
+	files, err := s.dirFiles(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("dir files: %w", err)
+	}
+
+	return files, nil
+}
+
+// ScanReader scans phrases found in image read from r with default options.
+func ScanReader(ctx context.Context, e ocr.Engine, r io.Reader) (<-chan *Phrase, error) {
+	return (&Scanner{Engine: e}).ScanReader(ctx, r)
+}
+
+// ScanReader scans phrases found in image or document read from r. Text
+// documents are recognized by content only, see textdoc.Detect.
+func (s *Scanner) ScanReader(ctx context.Context, r io.Reader) (<-chan *Phrase, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read all: %w", err)
+	}
+
+	src := s.NewSource("", content)
+	pages, err := src.Pages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("scan from: %w", err)
+	}
 
-	return out, nil
+	return stream(ctx, pagePhrases("", pages, s.Mode)), nil
 }
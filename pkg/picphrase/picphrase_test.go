@@ -1,18 +1,40 @@
 package picphrase
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"iter"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/kndrad/piccrack/pkg/manifest"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/pproc"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 )
 
+func testEngine(t *testing.T) ocr.Engine {
+	t.Helper()
+
+	e, err := ocr.NewFixtureEngine("testdata")
+	require.NoError(t, err)
+
+	return e
+}
+
 func TestScanAtPath(t *testing.T) {
 	path := filepath.Join("testdata", "0.png")
 
-	phrases, err := ScanAt(context.Background(), path)
+	phrases, err := ScanAt(context.Background(), testEngine(t), path)
 	require.NoError(t, err)
 
 	i := 0
@@ -20,13 +42,13 @@ func TestScanAtPath(t *testing.T) {
 		i++
 	}
 
-	require.Equal(t, 60, i)
+	require.Equal(t, 64, i)
 }
 
 func TestScanInDir(t *testing.T) {
 	path := "testdata"
 
-	phrases, err := ScanDir(context.Background(), path)
+	phrases, err := ScanDir(context.Background(), testEngine(t), path)
 	require.NoError(t, err)
 
 	i := 0
@@ -34,7 +56,7 @@ func TestScanInDir(t *testing.T) {
 		i++
 	}
 
-	require.Equal(t, 191, i)
+	require.Equal(t, 192, i)
 }
 
 func TestScanReader(t *testing.T) {
@@ -46,7 +68,7 @@ func TestScanReader(t *testing.T) {
 
 	ctx := context.Background()
 
-	phrases, err := ScanReader(ctx, f)
+	phrases, err := ScanReader(ctx, testEngine(t), f)
 	require.NoError(t, err)
 
 	i := 0
@@ -54,5 +76,610 @@ func TestScanReader(t *testing.T) {
 		i++
 	}
 
-	require.Equal(t, 60, i)
+	require.Equal(t, 64, i)
+}
+
+// boxesEngine returns recognition with word boxes for any content.
+type boxesEngine struct {
+	words []ocr.Box
+}
+
+func (e *boxesEngine) Recognize(ctx context.Context, content []byte) (*ocr.Recognition, error) {
+	text := ""
+	for _, w := range e.words {
+		text += w.Text + "\n"
+	}
+
+	return &ocr.Recognition{Text: text, Words: e.words}, nil
+}
+
+func (e *boxesEngine) Close() error {
+	return nil
+}
+
+func TestScannerMinConfidence(t *testing.T) {
+	t.Parallel()
+
+	e := &boxesEngine{
+		words: []ocr.Box{
+			{Text: "kubernetes", Confidence: 93, Line: 1},
+			{Text: "~#x", Confidence: 12, Line: 2},
+			{Text: "docker", Confidence: 87, Line: 3},
+		},
+	}
+
+	testCases := []struct {
+		desc string
+
+		min  float64
+		want []string
+	}{
+		{
+			desc: "keeps_all_by_default",
+
+			min:  0,
+			want: []string{"kubernetes", "~#x", "docker"},
+		},
+		{
+			desc: "drops_low_confidence_lines",
+
+			min:  50,
+			want: []string{"kubernetes", "docker"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s := &Scanner{Engine: e, MinConfidence: tC.min}
+
+			phrases, err := s.ScanAt(context.Background(), filepath.Join("testdata", "0.png"))
+			require.NoError(t, err)
+
+			got := make([]string, 0)
+			for ph := range phrases {
+				if ph.String() != "" {
+					got = append(got, ph.String())
+				}
+			}
+			require.ElementsMatch(t, tC.want, got)
+		})
+	}
+}
+
+func TestScanReaderPages(t *testing.T) {
+	t.Parallel()
+
+	g := new(gif.GIF)
+	for i := range 2 {
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette.Plan9)
+		frame.SetColorIndex(i, i, 1)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, gif.EncodeAll(buf, g))
+
+	e := &boxesEngine{words: []ocr.Box{{Text: "golang", Confidence: 90, Line: 1}}}
+
+	phrases, err := ScanReader(context.Background(), e, buf)
+	require.NoError(t, err)
+
+	pages := make([]int, 0)
+	for ph := range phrases {
+		require.Equal(t, "golang", ph.String())
+		pages = append(pages, ph.Page())
+	}
+	require.ElementsMatch(t, []int{1, 2}, pages)
+}
+
+// writeLines writes a text file of n numbered lines into dir.
+func writeLines(t *testing.T, dir, name string, n int) string {
+	t.Helper()
+
+	lines := make([]string, 0, n)
+	for i := range n {
+		lines = append(lines, fmt.Sprintf("%s line %d", name, i+1))
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+
+	return path
+}
+
+func TestScanKeepsOrder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeLines(t, dir, "offer.txt", 100)
+
+	phrases, err := ScanAt(context.Background(), testEngine(t), path)
+	require.NoError(t, err)
+
+	i := 0
+	for ph := range phrases {
+		i++
+		require.Equal(t, fmt.Sprintf("offer.txt line %d", i), ph.String())
+		require.Equal(t, path, ph.Path())
+		require.Equal(t, 1, ph.Page())
+		require.Equal(t, i, ph.Line())
+	}
+	require.Equal(t, 100, i)
+}
+
+func TestScanDirKeepsOrder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	b := writeLines(t, dir, "b.txt", 20)
+	a := writeLines(t, dir, "a.md", 20)
+
+	phrases, err := ScanDir(context.Background(), testEngine(t), dir)
+	require.NoError(t, err)
+
+	got := make([]string, 0)
+	for ph := range phrases {
+		got = append(got, ph.Path()+":"+strconv.Itoa(ph.Line()))
+	}
+	want := make([]string, 0)
+	for _, path := range []string{a, b} {
+		for i := range 20 {
+			want = append(want, path+":"+strconv.Itoa(i+1))
+		}
+	}
+	require.Equal(t, want, got)
+}
+
+func TestScannerWorkers(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	// scan returns phrases of testdata with their paths
+	scan := func(s *Scanner, limit int) []string {
+		got := make([]string, 0)
+		for ph, err := range s.PhrasesInDir(context.Background(), "testdata") {
+			require.NoError(t, err)
+			got = append(got, ph.Path()+":"+ph.String())
+			if len(got) == limit {
+				break
+			}
+		}
+
+		return got
+	}
+	want := scan(&Scanner{Engine: testEngine(t)}, 0)
+
+	e := testEngine(t)
+	newEngine := func() (ocr.Engine, error) { return e, nil }
+	for _, workers := range []int{0, 1, 3} {
+		s := &Scanner{NewEngine: newEngine, Workers: workers}
+		require.Equal(t, want, scan(s, 0))
+
+		// Workers stop once scanning does
+		require.Equal(t, want[:5], scan(s, 5))
+	}
+}
+
+func TestScanDirCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	dir := t.TempDir()
+	writeLines(t, dir, "offer.txt", 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	phrases, err := ScanDir(ctx, testEngine(t), dir)
+	require.NoError(t, err)
+
+	<-phrases
+	cancel()
+
+	// Channel is closed once ctx is done, without reading every phrase
+	n := 0
+	for range phrases {
+		n++
+	}
+	require.Less(t, n, 99)
+}
+
+func TestPhrases(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeLines(t, dir, "offer.txt", 10)
+	writeLines(t, dir, "notes.md", 10)
+
+	s := &Scanner{Engine: testEngine(t)}
+
+	testCases := []struct {
+		desc string
+
+		seq       iter.Seq2[*Phrase, error]
+		wantFirst string
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			desc: "at_path",
+
+			seq:       s.PhrasesAt(context.Background(), path),
+			wantFirst: "offer.txt line 1",
+			wantCount: 10,
+		},
+		{
+			desc: "in_dir_by_path",
+
+			seq:       s.PhrasesInDir(context.Background(), dir),
+			wantFirst: "notes.md line 1",
+			wantCount: 20,
+		},
+		{
+			desc: "from_reader",
+
+			seq:       s.PhrasesFrom(context.Background(), strings.NewReader("Golang\nPostgres")),
+			wantFirst: "golang",
+			wantCount: 2,
+		},
+		{
+			desc: "missing_file_err",
+
+			seq:     s.PhrasesAt(context.Background(), filepath.Join(dir, "missing.txt")),
+			wantErr: true,
+		},
+		{
+			desc: "not_a_dir_err",
+
+			seq:     s.PhrasesInDir(context.Background(), path),
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var got []string
+			for ph, err := range tC.seq {
+				if tC.wantErr {
+					require.Error(t, err)
+					require.Nil(t, ph)
+
+					continue
+				}
+				require.NoError(t, err)
+				got = append(got, ph.String())
+			}
+			if tC.wantErr {
+				require.Empty(t, got)
+
+				return
+			}
+			require.Len(t, got, tC.wantCount)
+			require.Equal(t, tC.wantFirst, got[0])
+		})
+	}
+}
+
+func TestPhrasesStopEarly(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	dir := t.TempDir()
+	writeLines(t, dir, "offer.txt", 100)
+	writeLines(t, dir, "notes.md", 100)
+
+	s := &Scanner{Engine: testEngine(t)}
+
+	n := 0
+	for ph, err := range s.PhrasesInDir(context.Background(), dir) {
+		require.NoError(t, err)
+		require.NotNil(t, ph)
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	require.Equal(t, 3, n)
+}
+
+func TestPhrasesCanceled(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeLines(t, dir, "offer.txt", 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var errs []error
+	for ph, err := range (&Scanner{Engine: testEngine(t)}).PhrasesAt(ctx, path) {
+		require.Nil(t, ph)
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], context.Canceled)
+}
+
+func TestScannerSentences(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Join([]string{
+		"Requirements:",
+		"- Experience with distributed systems and Linux",
+		"networking, including TCP/IP. Strong know-",
+		"ledge of Go",
+		"Benefits",
+	}, "\n")
+
+	testCases := []struct {
+		desc string
+
+		mode      Mode
+		want      []string
+		wantLines []int
+	}{
+		{
+			desc: "sentences",
+
+			mode: ModeSentences,
+			want: []string{
+				"requirements:",
+				"experience with distributed systems and linux networking, including tcp/ip.",
+				"strong knowledge of go",
+				"benefits",
+			},
+			wantLines: []int{1, 2, 3, 5},
+		},
+		{
+			desc: "lines_by_default",
+
+			want: []string{
+				"requirements:",
+				"- experience with distributed systems and linux",
+				"networking, including tcp/ip. strong know-",
+				"ledge of go",
+				"benefits",
+			},
+			wantLines: []int{1, 2, 3, 4, 5},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s := &Scanner{Engine: testEngine(t), Mode: tC.mode}
+
+			var (
+				got   []string
+				lines []int
+			)
+			for ph, err := range s.PhrasesFrom(context.Background(), strings.NewReader(text)) {
+				require.NoError(t, err)
+				got = append(got, ph.String())
+				lines = append(lines, ph.Line())
+			}
+			require.Equal(t, tC.want, got)
+			require.Equal(t, tC.wantLines, lines)
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]Mode{"": ModeLines, "lines": ModeLines, " Sentences ": ModeSentences} {
+		got, err := ParseMode(in)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	_, err := ParseMode("paragraphs")
+	require.ErrorIs(t, err, ErrUnknownMode)
+}
+
+func TestScannerWalkOptions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeLines(t, dir, "offer.txt", 2)
+	writeLines(t, dir, "notes.md", 2)
+	writeLines(t, dir, ".draft.txt", 2)
+
+	s := &Scanner{
+		Engine: testEngine(t),
+		Walk:   pproc.WalkOptions{Exclude: []string{"*.md"}, SkipHidden: true},
+	}
+
+	paths := make(map[string]int)
+	for ph, err := range s.PhrasesInDir(context.Background(), dir) {
+		require.NoError(t, err)
+		paths[ph.Path()]++
+	}
+	require.Equal(t, map[string]int{path: 2}, paths)
+}
+
+func TestScannerFileErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	offer := writeLines(t, dir, "offer.txt", 2)
+	notes := writeLines(t, dir, "notes.txt", 1)
+
+	// Image without fixture fails to be recognized
+	buf := new(bytes.Buffer)
+	require.NoError(t, gif.Encode(buf, image.NewPaletted(image.Rect(0, 0, 8, 8), palette.Plan9), nil))
+	unknown := filepath.Join(dir, "unknown.gif")
+	require.NoError(t, os.WriteFile(unknown, buf.Bytes(), 0o600))
+
+	m, err := manifest.Open(filepath.Join(t.TempDir(), "manifest.json"))
+	require.NoError(t, err)
+	s := &Scanner{Engine: testEngine(t), Manifest: m}
+
+	paths := make(map[string]int)
+	var errs []error
+	for ph, err := range s.PhrasesInDir(context.Background(), dir) {
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		paths[ph.Path()]++
+	}
+	require.Equal(t, map[string]int{offer: 2, notes: 1}, paths)
+	require.Len(t, errs, 1)
+
+	var fileErr *FileError
+	require.ErrorAs(t, errs[0], &fileErr)
+	require.Equal(t, unknown, fileErr.Path)
+	require.ErrorIs(t, errs[0], ocr.ErrNoFixture)
+
+	// Failed file isn't recorded, so that it's scanned again
+	records := m.Records()
+	require.Len(t, records, 2)
+	require.Equal(t, "notes.txt", records[0].Path)
+	require.Equal(t, "offer.txt", records[1].Path)
+
+	// ScanDir reports file errors and goes on
+	errs = nil
+	s = &Scanner{Engine: testEngine(t), Full: true, OnError: func(err error) { errs = append(errs, err) }}
+	phrases, err := s.ScanDir(context.Background(), dir)
+	require.NoError(t, err)
+	total := 0
+	for range phrases {
+		total++
+	}
+	require.Equal(t, 3, total)
+	require.Len(t, errs, 1)
+	require.ErrorAs(t, errs[0], &fileErr)
+	require.Equal(t, unknown, fileErr.Path)
+}
+
+func TestScanDirRecordsSentFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeLines(t, dir, "a.txt", 1)
+	writeLines(t, dir, "b.txt", 2)
+
+	m, err := manifest.Open(filepath.Join(t.TempDir(), "manifest.json"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	phrases, err := (&Scanner{Engine: testEngine(t), Manifest: m}).ScanDir(ctx, dir)
+	require.NoError(t, err)
+	<-phrases
+	cancel()
+	for range phrases {
+	}
+
+	// Only file with every phrase received is recorded
+	records := m.Records()
+	require.Len(t, records, 1)
+	require.Equal(t, "a.txt", records[0].Path)
+}
+
+func TestScannerManifest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	offer := writeLines(t, dir, "offer.txt", 2)
+	notes := writeLines(t, dir, "notes.txt", 1)
+
+	m, err := manifest.Open(filepath.Join(t.TempDir(), "manifest.json"))
+	require.NoError(t, err)
+
+	// scan returns number of phrases of every scanned file and status of
+	// every file of dir
+	scan := func(s *Scanner) (map[string]int, map[string]manifest.Status) {
+		statuses := make(map[string]manifest.Status)
+		s.Engine, s.Manifest = testEngine(t), m
+		s.OnFile = func(path string, status manifest.Status) {
+			statuses[path] = status
+		}
+
+		paths := make(map[string]int)
+		for ph, err := range s.PhrasesInDir(context.Background(), dir) {
+			require.NoError(t, err)
+			paths[ph.Path()]++
+		}
+
+		return paths, statuses
+	}
+
+	paths, statuses := scan(new(Scanner))
+	require.Equal(t, map[string]int{offer: 2, notes: 1}, paths)
+	require.Equal(t, map[string]manifest.Status{offer: manifest.StatusAdded, notes: manifest.StatusAdded}, statuses)
+	require.Len(t, m.Records(), 2)
+	require.Equal(t, "notes.txt", m.Records()[0].Path)
+
+	// Files which didn't change are skipped
+	paths, statuses = scan(new(Scanner))
+	require.Empty(t, paths)
+	require.Equal(t, map[string]manifest.Status{offer: manifest.StatusUnchanged, notes: manifest.StatusUnchanged}, statuses)
+
+	writeLines(t, dir, "offer.txt", 3)
+	added := writeLines(t, dir, "new.txt", 1)
+	paths, statuses = scan(new(Scanner))
+	require.Equal(t, map[string]int{offer: 3, added: 1}, paths)
+	require.Equal(t, manifest.StatusChanged, statuses[offer])
+	require.Equal(t, manifest.StatusAdded, statuses[added])
+	require.Equal(t, manifest.StatusUnchanged, statuses[notes])
+
+	// Full scan goes through every file
+	paths, _ = scan(&Scanner{Full: true})
+	require.Equal(t, map[string]int{offer: 3, added: 1, notes: 1}, paths)
+
+	// ScanDir records files too
+	writeLines(t, dir, "notes.txt", 2)
+	s := &Scanner{Engine: testEngine(t), Manifest: m}
+	phrases, err := s.ScanDir(context.Background(), dir)
+	require.NoError(t, err)
+	total := 0
+	for ph := range phrases {
+		require.Equal(t, notes, ph.Path())
+		total++
+	}
+	require.Equal(t, 2, total)
+	content, err := os.ReadFile(notes)
+	require.NoError(t, err)
+	require.Equal(t, manifest.StatusUnchanged, m.Status("notes.txt", manifest.Hash(content)))
+}
+
+func TestScannerArchives(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, text := range map[string]string{"img/offer.txt": "Golang developer\nRemote work", "notes.md": "# Go"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(text))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bundle.zip")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0o600))
+
+	m, err := manifest.Open(filepath.Join(t.TempDir(), "manifest.json"))
+	require.NoError(t, err)
+	s := &Scanner{Engine: testEngine(t), Walk: pproc.WalkOptions{Archives: true}, Manifest: m}
+
+	for _, root := range []string{dir, archive} {
+		paths := make(map[string]int)
+		for ph, err := range s.PhrasesInDir(context.Background(), root) {
+			require.NoError(t, err)
+			paths[ph.Path()]++
+		}
+		if root == archive {
+			// Files were recorded when their directory was scanned
+			require.Empty(t, paths)
+
+			continue
+		}
+		require.Equal(t, map[string]int{archive + "!/img/offer.txt": 2, archive + "!/notes.md": 1}, paths)
+	}
+
+	records := m.Records()
+	require.Len(t, records, 2)
+	require.Equal(t, "bundle.zip!/img/offer.txt", records[0].Path)
+
+	// Archives are scanned only if they're enabled
+	_, err = new(Scanner).ScanDir(context.Background(), archive)
+	require.Error(t, err)
 }
@@ -0,0 +1,384 @@
+package picphrase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kndrad/piccrack/pkg/manifest"
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/pproc"
+	"github.com/kndrad/piccrack/pkg/textdoc"
+)
+
+// Source is a document phrases are scanned from.
+type Source interface {
+	// Pages returns text of every page of the document.
+	Pages(ctx context.Context) ([]ocr.Page, error)
+}
+
+// ImageSource recognizes text of an image or pdf with an ocr engine.
+type ImageSource struct {
+	Engine  ocr.Engine
+	Content []byte
+
+	// Path the content was read from, reported by phrases. It may be empty.
+	Path string
+
+	// MinConfidence drops recognized words with lower confidence (0-100).
+	MinConfidence float64
+}
+
+func (src *ImageSource) Pages(ctx context.Context) ([]ocr.Page, error) {
+	res, err := ocr.ScanContent(ctx, src.Engine, src.Content)
+	if err != nil {
+		return nil, fmt.Errorf("scan content: %w", err)
+	}
+
+	return res.WithMinConfidence(src.MinConfidence).Pages(), nil
+}
+
+// TextSource reads HTML, Markdown and plain text documents without ocr.
+// Documents have a single page.
+type TextSource struct {
+	Kind    textdoc.Kind
+	Content []byte
+
+	// Path the content was read from, reported by phrases. It may be empty.
+	Path string
+}
+
+func (src *TextSource) Pages(ctx context.Context) ([]ocr.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	lines := textdoc.Lines(src.Kind, src.Content)
+
+	return []ocr.Page{{Number: 1, Text: strings.Join(lines, "\n")}}, nil
+}
+
+// PagesSource holds pages already recognized, e.g. when words of an image
+// are stored too, so that it doesn't go through ocr again.
+type PagesSource struct {
+	Recognized []ocr.Page
+
+	// Path the pages were read from, reported by phrases. It may be empty.
+	Path string
+}
+
+func (src *PagesSource) Pages(ctx context.Context) ([]ocr.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return src.Recognized, nil
+}
+
+// NewSource returns a TextSource for text documents and an ImageSource for
+// other content. Path, which may be empty, helps to detect documents.
+func (s *Scanner) NewSource(path string, content []byte) Source {
+	if kind := textdoc.Detect(path, content); kind != textdoc.Unknown {
+		return &TextSource{Kind: kind, Content: content, Path: path}
+	}
+
+	return &ImageSource{Engine: s.Engine, Content: content, Path: path, MinConfidence: s.MinConfidence}
+}
+
+// sourcePath returns path of sources which know it.
+func sourcePath(src Source) string {
+	switch src := src.(type) {
+	case *ImageSource:
+		return src.Path
+	case *TextSource:
+		return src.Path
+	case *PagesSource:
+		return src.Path
+	default:
+		return ""
+	}
+}
+
+// FileError is an error of a single file of a scanned directory, which
+// doesn't stop scanning of other files.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// dirFile is an image, pdf or text document of a scanned directory. Its
+// content is read once it's scanned, see Scanner.dirSource. Record is nil if
+// there's no manifest.
+type dirFile struct {
+	path string
+	kind textdoc.Kind // Unknown for images and pdfs
+	err  error        // Walking error of the file
+
+	record *manifest.Record
+}
+
+// dirFiles returns images, pdfs and text documents in dir allowed by walk
+// options of s, ordered by path. Files recorded in the manifest with the
+// same content are skipped, unless s.Full is set. Files which couldn't be
+// read have their error set.
+func (s *Scanner) dirFiles(ctx context.Context, dir string) ([]dirFile, error) {
+	// Walk stops if entries aren't drained because of an error
+	walkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	entries, err := pproc.WalkWithOptions(walkCtx, dir, s.walkOptions())
+	if err != nil {
+		return nil, fmt.Errorf("walk: %w", err)
+	}
+
+	// Files of an archive are recorded with path relative to its directory
+	base := dir
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		base = filepath.Dir(dir)
+	}
+
+	var files []dirFile
+	for entry := range entries {
+		f := dirFile{path: entry.Path(), err: entry.Err()}
+		if f.err != nil {
+			files = append(files, f)
+
+			continue
+		}
+		content := entry.Content()
+		if !ocr.IsScannable(content) {
+			if f.kind = textdoc.Detect(f.path, content); f.kind == textdoc.Unknown {
+				continue
+			}
+		}
+		if s.Manifest != nil {
+			r, err := fileRecord(base, f.path, content)
+			if err != nil {
+				f.err = err
+				files = append(files, f)
+
+				continue
+			}
+			status := s.Manifest.Status(r.Path, r.Hash)
+			if s.OnFile != nil {
+				s.OnFile(f.path, status)
+			}
+			if status == manifest.StatusUnchanged && !s.Full {
+				continue
+			}
+			f.record = &r
+		}
+		files = append(files, f)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(files, func(a, b dirFile) int {
+		return strings.Compare(a.path, b.path)
+	})
+
+	return files, nil
+}
+
+// walkOptions returns s.Walk. Unless it sniffs files already, files are
+// sniffed for images, pdfs and text documents, so that other files aren't
+// read whole only to be skipped.
+func (s *Scanner) walkOptions() pproc.WalkOptions {
+	opts := s.Walk
+	if opts.Sniff == nil && opts.SniffFile == nil {
+		opts.SniffFile = func(name string, header []byte) bool {
+			return ocr.IsScannable(header) || textdoc.Sniff(name, header)
+		}
+	}
+
+	return opts
+}
+
+// dirSource reads content of f and returns its source.
+func (s *Scanner) dirSource(f dirFile) (Source, error) {
+	content, err := s.readFile(f)
+	if err != nil {
+		return nil, err
+	}
+	if f.kind != textdoc.Unknown {
+		return &TextSource{Kind: f.kind, Content: content, Path: f.path}, nil
+	}
+
+	return &ImageSource{Engine: s.Engine, Content: content, Path: f.path, MinConfidence: s.MinConfidence}, nil
+}
+
+// readFile returns content of f.
+func (s *Scanner) readFile(f dirFile) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	content, err := s.walkOptions().ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if content == nil {
+		return nil, errors.New("file no longer passes walk options")
+	}
+
+	return content, nil
+}
+
+// prefetch recognizes images of a scanned directory with workers of
+// Scanner.NewEngine ahead of scanning, at most window of them at a time, so
+// that results don't pile up in memory.
+type prefetch struct {
+	results <-chan *ocr.FileResult
+	window  chan struct{}
+	pending map[string]*ocr.FileResult
+	cancel  context.CancelFunc
+}
+
+// prefetch starts recognition of images of files, in order of files. It
+// returns nil if there are none. Stop must be called once scanning ends.
+func (s *Scanner) prefetch(ctx context.Context, files []dirFile) (*prefetch, error) {
+	images := make([]dirFile, 0, len(files))
+	for _, f := range files {
+		if f.err == nil && f.kind == textdoc.Unknown {
+			images = append(images, f)
+		}
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	jobs := make(chan ocr.ScanJob)
+	results, err := ocr.ScanConcurrent(ctx, s.NewEngine, jobs, workers)
+	if err != nil {
+		cancel()
+
+		return nil, fmt.Errorf("scan concurrent: %w", err)
+	}
+
+	p := &prefetch{
+		results: results,
+		window:  make(chan struct{}, 2*workers),
+		pending: make(map[string]*ocr.FileResult),
+		cancel:  cancel,
+	}
+	go func() {
+		defer close(jobs)
+
+		for _, f := range images {
+			select {
+			case p.window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			job := ocr.ScanJob{Path: f.path, Read: func() ([]byte, error) { return s.readFile(f) }}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return p, nil
+}
+
+// result waits for result of image at path. Images must be waited for in
+// order they were started.
+func (p *prefetch) result(ctx context.Context, path string) (*ocr.FileResult, error) {
+	for {
+		if fr, ok := p.pending[path]; ok {
+			delete(p.pending, path)
+			<-p.window
+
+			return fr, nil
+		}
+		fr, ok := <-p.results
+		if !ok {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			return nil, errors.New("image wasn't recognized")
+		}
+		p.pending[fr.Path] = fr
+	}
+}
+
+// stop cancels recognition and waits until engines are closed.
+func (p *prefetch) stop() {
+	p.cancel()
+	for range p.results {
+	}
+}
+
+// source returns source of image f recognized already.
+func (p *prefetch) source(ctx context.Context, f dirFile, minConfidence float64) (Source, error) {
+	fr, err := p.result(ctx, f.path)
+	if err != nil {
+		return nil, err
+	}
+	if fr.Err != nil {
+		return nil, fr.Err
+	}
+
+	return &PagesSource{Recognized: fr.Result.WithMinConfidence(minConfidence).Pages(), Path: f.path}, nil
+}
+
+// fileRecord returns manifest record of file at path of dir, keyed by slash
+// separated path relative to dir. Files of archives have modification time
+// of the archive.
+func fileRecord(dir, path string, content []byte) (manifest.Record, error) {
+	stat := path
+	if archive, _, ok := pproc.SplitArchivePath(path); ok {
+		stat = archive
+	}
+	info, err := os.Stat(stat)
+	if err != nil {
+		return manifest.Record{}, fmt.Errorf("stat: %w", err)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return manifest.Record{}, fmt.Errorf("rel: %w", err)
+	}
+
+	return manifest.Record{
+		Path:    filepath.ToSlash(rel),
+		Size:    int64(len(content)),
+		ModTime: info.ModTime(),
+		Hash:    manifest.Hash(content),
+	}, nil
+}
+
+// scanned records f in the manifest.
+func (s *Scanner) scanned(f dirFile) error {
+	if s.Manifest == nil || f.record == nil {
+		return nil
+	}
+	r := *f.record
+	r.ProcessedAt = time.Now()
+	if err := s.Manifest.Put(r); err != nil {
+		return fmt.Errorf("manifest put: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package picphrase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/ocr"
+	"github.com/kndrad/piccrack/pkg/pproc"
+	"github.com/kndrad/piccrack/pkg/textdoc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	t.Parallel()
+
+	png, err := os.ReadFile(filepath.Join("testdata", "0.png"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc string
+
+		path    string
+		content []byte
+		want    Source
+	}{
+		{
+			desc: "image",
+
+			path:    "0.png",
+			content: png,
+			want:    &ImageSource{Content: png, Path: "0.png"},
+		},
+		{
+			desc: "html",
+
+			path:    "offer.html",
+			content: []byte("<p>Go</p>"),
+			want:    &TextSource{Kind: textdoc.HTML, Content: []byte("<p>Go</p>"), Path: "offer.html"},
+		},
+		{
+			desc: "text_without_path",
+
+			content: []byte("Go"),
+			want:    &TextSource{Kind: textdoc.Plain, Content: []byte("Go")},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, new(Scanner).NewSource(tC.path, tC.content))
+		})
+	}
+}
+
+func TestScanDirMixed(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	png, err := os.ReadFile(filepath.Join("testdata", "0.png"))
+	require.NoError(t, err)
+	sidecar, err := os.ReadFile(filepath.Join("testdata", "0.png"+ocr.FixtureExt))
+	require.NoError(t, err)
+
+	files := map[string]string{
+		"0.png":                  string(png),
+		"0.png" + ocr.FixtureExt: string(sidecar), // Not a document
+		"0.png.txt":              "Notes about the offer",
+		"offer.html":             "<html><body><nav>Jobs</nav><h1>Go Developer</h1><p>Remote</p></body></html>",
+		"notes.md":               "# Kubernetes\n\n- **Docker**",
+		"main.go":                "package main", // Not a document
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	phrases, err := (&Scanner{Engine: testEngine(t)}).ScanDir(context.Background(), dir)
+	require.NoError(t, err)
+
+	got := make([]string, 0)
+	for ph := range phrases {
+		got = append(got, ph.String())
+	}
+
+	image, err := ScanAt(context.Background(), testEngine(t), filepath.Join(dir, "0.png"))
+	require.NoError(t, err)
+	want := []string{"go developer", "remote", "kubernetes", "docker", "notes about the offer"}
+	for ph := range image {
+		want = append(want, ph.String())
+	}
+	require.ElementsMatch(t, want, got)
+}
+
+func TestScanDirSniffs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	movie := make([]byte, 1<<20)
+	copy(movie, "\x1aE\xdf\xa3")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "movie.webm"), movie, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "offer.txt"), []byte("Go Developer"), 0o600))
+
+	// Filter sees content of files read whole
+	var (
+		mu   sync.Mutex
+		read []int
+	)
+	s := &Scanner{
+		Engine: testEngine(t),
+		Walk: pproc.WalkOptions{Filter: func(data []byte) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			read = append(read, len(data))
+
+			return true
+		}},
+	}
+
+	got := make([]string, 0)
+	for ph, err := range s.PhrasesInDir(context.Background(), dir) {
+		require.NoError(t, err)
+		got = append(got, ph.String())
+	}
+	require.Equal(t, []string{"go developer"}, got)
+	require.NotContains(t, read, len(movie))
+}
+
+func TestScanReaderText(t *testing.T) {
+	t.Parallel()
+
+	phrases, err := ScanReader(context.Background(), testEngine(t),
+		strings.NewReader("<!DOCTYPE html><p>Golang</p><script>x()</script><p>Postgres</p>"))
+	require.NoError(t, err)
+
+	got := make([]string, 0)
+	for ph := range phrases {
+		require.Equal(t, 1, ph.Page())
+		got = append(got, ph.String())
+	}
+	require.ElementsMatch(t, []string{"golang", "postgres"}, got)
+}
+
+func TestPagesSource(t *testing.T) {
+	t.Parallel()
+
+	src := &PagesSource{
+		Recognized: []ocr.Page{{Number: 1, Text: "Golang developer"}, {Number: 2, Text: "Remote work"}},
+		Path:       "offer.png",
+	}
+
+	var got []*Phrase
+	for ph, err := range new(Scanner).Phrases(context.Background(), src) {
+		require.NoError(t, err)
+		got = append(got, ph)
+	}
+	require.Len(t, got, 2)
+	require.Equal(t, "golang developer", got[0].String())
+	require.Equal(t, "offer.png", got[1].Path())
+	require.Equal(t, 2, got[1].Page())
+}
@@ -0,0 +1,341 @@
+package pproc
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// DefaultMaxArchiveSize is the number of bytes decompressed from an
+	// archive before reading it stops.
+	DefaultMaxArchiveSize = 1 << 30
+	// DefaultMaxArchiveFiles is the number of files of an archive before
+	// reading it stops.
+	DefaultMaxArchiveFiles = 10000
+)
+
+// ArchiveSeparator separates path of an archive from path of a file in it
+// in virtual paths, e.g. "bundle.zip!/img/offer.png".
+const ArchiveSeparator = "!/"
+
+// ErrArchiveLimit is an error of an archive which has more files or
+// decompresses to more bytes than allowed.
+var ErrArchiveLimit = errors.New("archive limit exceeded")
+
+// IsArchive reports whether name is a zip, tar or gzipped tar archive by its
+// extension.
+func IsArchive(name string) bool {
+	return archiveKind(name) != ""
+}
+
+func archiveKind(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	default:
+		return ""
+	}
+}
+
+// SplitArchivePath splits virtual path of a file in an archive into path of
+// the archive and slash separated path of the file in it. It reports false
+// for other paths.
+func SplitArchivePath(name string) (archive, member string, ok bool) {
+	return strings.Cut(name, ArchiveSeparator)
+}
+
+// allowsArchive reports whether archive at path rel of size bytes is
+// descended into. Include patterns select files in it instead.
+func (opts WalkOptions) allowsArchive(rel string, size int64) bool {
+	return !opts.hidden(rel) &&
+		!matchAny(opts.Exclude, rel) &&
+		(opts.MaxDepth == 0 || depth(rel) <= opts.MaxDepth) &&
+		size <= opts.maxArchiveSize()
+}
+
+func (opts WalkOptions) maxArchiveSize() int64 {
+	if opts.MaxArchiveSize == 0 {
+		return DefaultMaxArchiveSize
+	}
+
+	return opts.MaxArchiveSize
+}
+
+func (opts WalkOptions) maxArchiveFiles() int {
+	if opts.MaxArchiveFiles == 0 {
+		return DefaultMaxArchiveFiles
+	}
+
+	return opts.MaxArchiveFiles
+}
+
+// allowsMember reports whether file member of archive at path rel, of
+// size bytes as the archive claims, is read.
+func (opts WalkOptions) allowsMember(rel, member string, size int64) bool {
+	for dir := path.Dir(member); dir != "."; dir = path.Dir(dir) {
+		if !opts.AllowsDir(rel + ArchiveSeparator + dir) {
+			return false
+		}
+	}
+
+	return opts.AllowsFile(rel+ArchiveSeparator+member, size)
+}
+
+// archiveFile is a regular file of an archive. Files of tar archives can be
+// read only until the next one is yielded.
+type archiveFile struct {
+	name string
+	size int64
+	open func() (io.ReadCloser, error)
+}
+
+// readArchive sends entries of files of archive rel of fsys, named name,
+// allowed by opts. Errors of reading the archive stop it and are sent as
+// an entry of the archive. It reports false if send failed.
+func (opts WalkOptions) readArchive(fsys fs.FS, rel, name string, send func(*Entry) bool) bool {
+	f, err := fsys.Open(rel)
+	if err != nil {
+		return send(&Entry{path: name, err: fmt.Errorf("open: %w", err)})
+	}
+	defer f.Close()
+
+	// Every byte decompressed from the archive counts, including ones of
+	// skipped files
+	budget := &budgetReader{n: opts.maxArchiveSize()}
+
+	files := 0
+	for file, err := range archiveFiles(f, archiveKind(rel), budget) {
+		if err != nil {
+			return send(&Entry{path: name, err: fmt.Errorf("read archive: %w", err)})
+		}
+		if files++; files > opts.maxArchiveFiles() {
+			return send(&Entry{path: name, err: fmt.Errorf("more than %d files: %w", opts.maxArchiveFiles(), ErrArchiveLimit)})
+		}
+
+		member := strings.TrimPrefix(file.name, "/")
+		vname := name + ArchiveSeparator + member
+		if !fs.ValidPath(member) {
+			if !send(&Entry{path: vname, err: errors.New("invalid path in archive")}) {
+				return false
+			}
+
+			continue
+		}
+		if !opts.allowsMember(rel, member, file.size) {
+			continue
+		}
+
+		data, err := opts.readMember(file)
+		switch {
+		case errors.Is(err, ErrArchiveLimit):
+			return send(&Entry{path: name, err: fmt.Errorf("more than %d bytes: %w", opts.maxArchiveSize(), err)})
+		case err != nil:
+			err = fmt.Errorf("read %s: %w", vname, err)
+		case data == nil:
+			continue
+		}
+		if !send(&Entry{path: vname, content: data, err: err}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readArchiveFile returns content of file member of archive at path name,
+// see ReadFile. Limits of the archive apply as if it was walked.
+func (opts WalkOptions) readArchiveFile(name, member string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	budget := &budgetReader{n: opts.maxArchiveSize()}
+
+	files := 0
+	for file, err := range archiveFiles(f, archiveKind(name), budget) {
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		if files++; files > opts.maxArchiveFiles() {
+			return nil, fmt.Errorf("more than %d files: %w", opts.maxArchiveFiles(), ErrArchiveLimit)
+		}
+		if strings.TrimPrefix(file.name, "/") != member {
+			continue
+		}
+		data, err := opts.readMember(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", member, err)
+		}
+
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", member, fs.ErrNotExist)
+}
+
+func (opts WalkOptions) readMember(file archiveFile) ([]byte, error) {
+	r, err := file.open()
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer r.Close()
+
+	return opts.readFrom(file.name, r)
+}
+
+// archiveFiles yields regular files of archive f of kind. Bytes decompressed
+// from it are read through budget.
+func archiveFiles(f fs.File, kind string, budget *budgetReader) iter.Seq2[archiveFile, error] {
+	return func(yield func(archiveFile, error) bool) {
+		if kind == "zip" {
+			zipFiles(f, budget)(yield)
+
+			return
+		}
+
+		var r io.Reader = f
+		if kind == "tar.gz" {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				yield(archiveFile{}, fmt.Errorf("gzip: %w", err))
+
+				return
+			}
+			defer gz.Close()
+			r = gz
+		}
+		budget.r = r
+
+		tr := tar.NewReader(budget)
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(archiveFile{}, fmt.Errorf("tar: %w", err))
+
+				return
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			file := archiveFile{
+				name: hdr.Name,
+				size: hdr.Size,
+				open: func() (io.ReadCloser, error) {
+					return io.NopCloser(tr), nil
+				},
+			}
+			if !yield(file, nil) {
+				return
+			}
+		}
+	}
+}
+
+func zipFiles(f fs.File, budget *budgetReader) iter.Seq2[archiveFile, error] {
+	return func(yield func(archiveFile, error) bool) {
+		ra, size, err := readerAt(f, budget.n)
+		if err != nil {
+			yield(archiveFile{}, err)
+
+			return
+		}
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			yield(archiveFile{}, fmt.Errorf("zip: %w", err))
+
+			return
+		}
+		for _, zf := range zr.File {
+			if !zf.Mode().IsRegular() {
+				continue
+			}
+			file := archiveFile{
+				name: zf.Name,
+				size: int64(zf.UncompressedSize64),
+				open: func() (io.ReadCloser, error) {
+					rc, err := zf.Open()
+					if err != nil {
+						return nil, err
+					}
+					budget.r = rc
+
+					return struct {
+						io.Reader
+						io.Closer
+					}{budget, rc}, nil
+				},
+			}
+			if !yield(file, nil) {
+				return
+			}
+		}
+	}
+}
+
+// readerAt returns f as io.ReaderAt with its size, f is read into memory if
+// it isn't one. Files bigger than limit aren't read.
+func readerAt(f fs.File, limit int64) (io.ReaderAt, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("stat: %w", err)
+	}
+	if ra, ok := f.(io.ReaderAt); ok {
+		return ra, info.Size(), nil
+	}
+	if info.Size() > limit {
+		return nil, 0, ErrArchiveLimit
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read: %w", err)
+	}
+
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// budgetReader reads from r until n bytes were read in total, once there's
+// more to read it fails with ErrArchiveLimit.
+type budgetReader struct {
+	r io.Reader
+	n int64
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	if b.n <= 0 {
+		// Reading exactly n bytes is fine, only more of them is too much
+		var probe [1]byte
+		n, err := b.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrArchiveLimit
+		}
+
+		return 0, err
+	}
+	if int64(len(p)) > b.n {
+		p = p[:b.n]
+	}
+	n, err := b.r.Read(p)
+	b.n -= int64(n)
+
+	return n, err
+}
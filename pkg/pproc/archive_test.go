@@ -0,0 +1,344 @@
+package pproc_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/pproc"
+	"github.com/stretchr/testify/require"
+)
+
+// file is a file of a test archive.
+type file struct {
+	name    string
+	content []byte
+}
+
+func zipArchive(t *testing.T, files ...file) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		require.NoError(t, err)
+		_, err = fw.Write(f.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func tarArchive(t *testing.T, gzipped bool, files ...file) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if gzipped {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     f.name,
+			Mode:     0o600,
+			Size:     int64(len(f.content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(f.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	if gz != nil {
+		require.NoError(t, gz.Close())
+	}
+
+	return buf.Bytes()
+}
+
+// walkPaths returns sorted paths of entries relative to root and errors.
+func walkPaths(t *testing.T, root string, opts pproc.WalkOptions) ([]string, []error) {
+	t.Helper()
+
+	entries, err := pproc.WalkWithOptions(context.Background(), root, opts)
+	require.NoError(t, err)
+
+	var (
+		paths []string
+		errs  []error
+	)
+	dir := root
+	if filepath.Ext(root) != "" {
+		dir = filepath.Dir(root)
+	}
+	for e := range entries {
+		if e.Err() != nil {
+			errs = append(errs, e.Err())
+
+			continue
+		}
+		rel, err := filepath.Rel(dir, e.Path())
+		require.NoError(t, err)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	slices.Sort(paths)
+
+	return paths, errs
+}
+
+func TestWalkArchives(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100))
+	files := []file{
+		{"img/offer1.png", png},
+		{"img/.thumb.png", png},
+		{"notes.txt", []byte("golang developer")},
+		{"nested.zip", zipArchive(t, file{"offer.png", png})},
+	}
+	root := writeTree(t, map[string][]byte{
+		"a.png":           png,
+		"bundle.zip":      zipArchive(t, files...),
+		"bundle.tar":      tarArchive(t, false, files...),
+		"old/bundle.tgz":  tarArchive(t, true, files...),
+		".hidden/x.zip":   zipArchive(t, files...),
+		"broken.tar.gz":   []byte("not gzip"),
+		"archive/old.zip": zipArchive(t, files...),
+	})
+
+	testCases := []struct {
+		desc string
+
+		opts     pproc.WalkOptions
+		want     []string
+		wantErrs int
+	}{
+		{
+			desc: "archives_are_files_by_default",
+
+			opts: pproc.WalkOptions{SkipHidden: true, Exclude: []string{"archive", "*.gz"}},
+			want: []string{"a.png", "bundle.tar", "bundle.zip", "old/bundle.tgz"},
+		},
+		{
+			desc: "archives_are_descended_into",
+
+			opts: pproc.WalkOptions{Archives: true, SkipHidden: true, Exclude: []string{"archive"}},
+			want: []string{
+				"a.png",
+				"bundle.tar!/img/offer1.png",
+				"bundle.tar!/nested.zip",
+				"bundle.tar!/notes.txt",
+				"bundle.zip!/img/offer1.png",
+				"bundle.zip!/nested.zip",
+				"bundle.zip!/notes.txt",
+				"old/bundle.tgz!/img/offer1.png",
+				"old/bundle.tgz!/nested.zip",
+				"old/bundle.tgz!/notes.txt",
+			},
+			wantErrs: 1,
+		},
+		{
+			desc: "files_of_archives_are_filtered",
+
+			opts: pproc.WalkOptions{
+				Archives: true,
+				Include:  []string{"*.png"},
+				Exclude:  []string{"archive", "old", "*.gz", "bundle.zip!/img"},
+				Sniff:    func(header []byte) bool { return bytes.HasPrefix(header, []byte("\x89PNG")) },
+			},
+			want: []string{".hidden/x.zip!/img/.thumb.png", ".hidden/x.zip!/img/offer1.png", "a.png", "bundle.tar!/img/.thumb.png", "bundle.tar!/img/offer1.png"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			paths, errs := walkPaths(t, root, tC.opts)
+			require.Equal(t, tC.want, paths)
+			require.Len(t, errs, tC.wantErrs)
+		})
+	}
+}
+
+func TestWalkArchiveRoot(t *testing.T) {
+	t.Parallel()
+
+	root := writeTree(t, map[string][]byte{
+		"bundle.zip": zipArchive(t, file{"img/offer1.png", []byte("png")}),
+	})
+
+	paths, errs := walkPaths(t, filepath.Join(root, "bundle.zip"), pproc.WalkOptions{Archives: true})
+	require.Empty(t, errs)
+	require.Equal(t, []string{"bundle.zip!/img/offer1.png"}, paths)
+
+	archive, member, ok := pproc.SplitArchivePath(filepath.Join(root, "bundle.zip!/img/offer1.png"))
+	require.True(t, ok)
+	require.Equal(t, filepath.Join(root, "bundle.zip"), archive)
+	require.Equal(t, "img/offer1.png", member)
+}
+
+func TestReadFileOfArchive(t *testing.T) {
+	t.Parallel()
+
+	files := []file{{"img/offer1.png", []byte("png")}, {"notes.txt", []byte("golang developer")}}
+	root := writeTree(t, map[string][]byte{
+		"bundle.zip": zipArchive(t, files...),
+		"bundle.tgz": tarArchive(t, true, files...),
+	})
+
+	testCases := []struct {
+		desc string
+
+		name    string
+		opts    pproc.WalkOptions
+		want    []byte
+		wantErr error
+	}{
+		{
+			desc: "zip",
+
+			name: "bundle.zip!/notes.txt",
+			opts: pproc.WalkOptions{Archives: true},
+			want: []byte("golang developer"),
+		},
+		{
+			desc: "gzipped_tar",
+
+			name: "bundle.tgz!/img/offer1.png",
+			opts: pproc.WalkOptions{Archives: true},
+			want: []byte("png"),
+		},
+		{
+			desc: "filtered",
+
+			name: "bundle.zip!/notes.txt",
+			opts: pproc.WalkOptions{Archives: true, MaxSize: 3},
+		},
+		{
+			desc: "missing_err",
+
+			name:    "bundle.zip!/missing.txt",
+			opts:    pproc.WalkOptions{Archives: true},
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			desc: "archives_disabled_err",
+
+			name:    "bundle.zip!/notes.txt",
+			wantErr: fs.ErrNotExist,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tC.opts.ReadFile(filepath.Join(root, tC.name))
+			if tC.wantErr != nil {
+				require.ErrorIs(t, err, tC.wantErr)
+
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestWalkArchiveLimits(t *testing.T) {
+	t.Parallel()
+
+	zeros := bytes.Repeat([]byte{0}, 64<<10)
+	many := make([]file, 0, 20)
+	for i := range 20 {
+		many = append(many, file{name: strings.Repeat("f", i+1), content: []byte("x")})
+	}
+
+	testCases := []struct {
+		desc string
+
+		name    string
+		archive []byte
+		opts    pproc.WalkOptions
+		// want is the number of entries read before reading stopped
+		want int
+	}{
+		{
+			desc: "zip_decompressing_to_too_many_bytes",
+
+			name:    "bomb.zip",
+			archive: zipArchive(t, file{"a", zeros}, file{"b", zeros}),
+			opts:    pproc.WalkOptions{MaxArchiveSize: 100 << 10},
+			want:    1,
+		},
+		{
+			desc: "tar_gz_decompressing_to_too_many_bytes",
+
+			name:    "bomb.tar.gz",
+			archive: tarArchive(t, true, file{"a", zeros}, file{"b", zeros}),
+			opts:    pproc.WalkOptions{MaxArchiveSize: 100 << 10},
+			want:    1,
+		},
+		{
+			desc: "skipped_files_count_too",
+
+			name:    "bomb.tar.gz",
+			archive: tarArchive(t, true, file{"a", zeros}, file{"b", zeros}, file{"c.png", []byte("png")}),
+			opts:    pproc.WalkOptions{MaxArchiveSize: 100 << 10, Include: []string{"*.png"}},
+			want:    0,
+		},
+		{
+			desc: "too_many_files",
+
+			name:    "bomb.zip",
+			archive: zipArchive(t, many...),
+			opts:    pproc.WalkOptions{MaxArchiveFiles: 10},
+			want:    10,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			root := writeTree(t, map[string][]byte{tC.name: tC.archive})
+
+			tC.opts.Archives = true
+			paths, errs := walkPaths(t, root, tC.opts)
+			require.Len(t, paths, tC.want)
+			require.Len(t, errs, 1)
+			require.ErrorIs(t, errs[0], pproc.ErrArchiveLimit)
+		})
+	}
+}
+
+func TestWalkArchiveInvalidPaths(t *testing.T) {
+	t.Parallel()
+
+	root := writeTree(t, map[string][]byte{
+		"bundle.tar": tarArchive(t, false, file{"../../etc/passwd", []byte("x")}, file{"/img/offer.png", []byte("png")}),
+	})
+
+	paths, errs := walkPaths(t, root, pproc.WalkOptions{Archives: true})
+	require.Equal(t, []string{"bundle.tar!/img/offer.png"}, paths)
+	require.Len(t, errs, 1)
+}
+
+func TestIsArchive(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"a.zip", "a.ZIP", "a.tar", "a.tar.gz", "a.tgz"} {
+		require.True(t, pproc.IsArchive(name), name)
+	}
+	for _, name := range []string{"a.png", "a.gz", "zip", "a.tar.bz2"} {
+		require.False(t, pproc.IsArchive(name), name)
+	}
+}
@@ -1,7 +1,13 @@
 package pproc_test
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/kndrad/piccrack/pkg/ocr"
@@ -36,3 +42,197 @@ func TestWalkWithImageFilter(t *testing.T) {
 		require.True(t, ocr.IsImage(e.Content()))
 	}
 }
+
+func writeTree(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+	}
+
+	return root
+}
+
+func TestWalkWithOptions(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100))
+	root := writeTree(t, map[string][]byte{
+		"a.png":            png,
+		"b.txt":            []byte("golang developer"),
+		".hidden.png":      png,
+		".git/config":      []byte("[core]"),
+		"sub/c.png":        png,
+		"sub/deep/d.png":   png,
+		"dump/db.sql":      bytes.Repeat([]byte("insert;"), 1000),
+		"video/movie.webm": bytes.Repeat([]byte{0}, 2000),
+	})
+
+	testCases := []struct {
+		desc string
+
+		opts pproc.WalkOptions
+		want []string
+	}{
+		{
+			desc: "every_file",
+
+			want: []string{
+				".git/config", ".hidden.png", "a.png", "b.txt", "dump/db.sql",
+				"sub/c.png", "sub/deep/d.png", "video/movie.webm",
+			},
+		},
+		{
+			desc: "include_by_base_name",
+
+			opts: pproc.WalkOptions{Include: []string{"*.png"}},
+			want: []string{".hidden.png", "a.png", "sub/c.png", "sub/deep/d.png"},
+		},
+		{
+			desc: "include_by_relative_path",
+
+			opts: pproc.WalkOptions{Include: []string{"sub/*.png"}},
+			want: []string{"sub/c.png"},
+		},
+		{
+			desc: "exclude_files_and_dirs",
+
+			opts: pproc.WalkOptions{Exclude: []string{"*.sql", "video", "deep"}},
+			want: []string{".git/config", ".hidden.png", "a.png", "b.txt", "sub/c.png"},
+		},
+		{
+			desc: "max_size",
+
+			opts: pproc.WalkOptions{MaxSize: 1000},
+			want: []string{".git/config", ".hidden.png", "a.png", "b.txt", "sub/c.png", "sub/deep/d.png"},
+		},
+		{
+			desc: "max_depth",
+
+			opts: pproc.WalkOptions{MaxDepth: 2},
+			want: []string{
+				".git/config", ".hidden.png", "a.png", "b.txt", "dump/db.sql",
+				"sub/c.png", "video/movie.webm",
+			},
+		},
+		{
+			desc: "skip_hidden",
+
+			opts: pproc.WalkOptions{SkipHidden: true, MaxDepth: 1},
+			want: []string{"a.png", "b.txt"},
+		},
+		{
+			desc: "sniff_and_filter",
+
+			opts: pproc.WalkOptions{
+				Sniff:     ocr.IsImage,
+				SniffSize: 8,
+				Filter:    func(data []byte) bool { return len(data) == len(png) },
+				Exclude:   []string{"deep"},
+			},
+			want: []string{".hidden.png", "a.png", "sub/c.png"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			entries, err := pproc.WalkWithOptions(context.Background(), root, tC.opts)
+			require.NoError(t, err)
+
+			got := make([]string, 0)
+			for e := range entries {
+				rel, err := filepath.Rel(root, e.Path())
+				require.NoError(t, err)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			slices.Sort(got)
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestWalkWithOptionsSniffsHeaderOnly(t *testing.T) {
+	t.Parallel()
+
+	root := writeTree(t, map[string][]byte{
+		"movie.webm": bytes.Repeat([]byte{0}, 1<<20),
+		"offer.txt":  []byte("golang developer"),
+	})
+
+	var mu sync.Mutex
+	var sniffed []int
+	opts := pproc.WalkOptions{
+		Sniff: func(header []byte) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			sniffed = append(sniffed, len(header))
+
+			return header[0] != 0
+		},
+		Filter: func(data []byte) bool {
+			require.Equal(t, "golang developer", string(data))
+
+			return true
+		},
+	}
+
+	entries, err := pproc.WalkWithOptions(context.Background(), root, opts)
+	require.NoError(t, err)
+
+	total := 0
+	for range entries {
+		total++
+	}
+	require.Equal(t, 1, total)
+	slices.Sort(sniffed)
+	require.Equal(t, []int{len("golang developer"), pproc.DefaultSniffSize}, sniffed)
+}
+
+func TestWalkWithOptionsSniffFile(t *testing.T) {
+	t.Parallel()
+
+	root := writeTree(t, map[string][]byte{
+		"movie.webm":      bytes.Repeat([]byte{1}, 1<<20),
+		"docs/offer.txt":  []byte("golang developer"),
+		"docs/offer.json": []byte(`{"title":"golang developer"}`),
+	})
+
+	var mu sync.Mutex
+	names := make(map[string]int)
+	opts := pproc.WalkOptions{
+		SniffFile: func(name string, header []byte) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			names[name] = len(header)
+
+			return filepath.Ext(name) == ".txt"
+		},
+	}
+
+	entries, err := pproc.WalkWithOptions(context.Background(), root, opts)
+	require.NoError(t, err)
+
+	var got []string
+	for entry := range entries {
+		require.NoError(t, entry.Err())
+		got = append(got, entry.Path())
+	}
+	require.Equal(t, []string{filepath.Join(root, "docs", "offer.txt")}, got)
+	require.Equal(t, map[string]int{
+		"movie.webm":      pproc.DefaultSniffSize,
+		"docs/offer.txt":  len("golang developer"),
+		"docs/offer.json": len(`{"title":"golang developer"}`),
+	}, names)
+}
+
+func TestWalkWithOptionsInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := pproc.WalkWithOptions(context.Background(), "testdata", pproc.WalkOptions{Include: []string{"[a-"}})
+	require.Error(t, err)
+
+	_, err = pproc.WalkWithOptions(context.Background(), "testdata", pproc.WalkOptions{MaxSize: -1})
+	require.Error(t, err)
+}
@@ -0,0 +1,354 @@
+package pproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultSniffSize is the number of leading bytes of a file passed to
+// WalkOptions.Sniff, enough for magic numbers of every known format.
+const DefaultSniffSize = 512
+
+// WalkOptions control which files Walk reads. Paths, sizes and headers are
+// checked before file contents are read.
+type WalkOptions struct {
+	// Include and Exclude are glob patterns, see path.Match, matched with
+	// both slash separated path relative to root and base name of a file.
+	// If there are Include patterns, files matching none of them are
+	// skipped. Files and directories matching any Exclude pattern are
+	// skipped.
+	Include []string
+	Exclude []string
+
+	// MaxSize skips files bigger than MaxSize bytes. Zero means no limit.
+	MaxSize int64
+
+	// MaxDepth skips files more than MaxDepth directories deep, files in
+	// root are 1 deep. Zero means no limit.
+	MaxDepth int
+
+	// SkipHidden skips files and directories whose name starts with a dot.
+	SkipHidden bool
+
+	// Sniff, if set, filters files by their first SniffSize bytes before
+	// they're read whole. SniffFile does the same knowing name of the file
+	// too, e.g. to check its extension. Files must pass both if they're
+	// set.
+	Sniff     FilterFunc
+	SniffFile func(name string, header []byte) bool
+	SniffSize int
+
+	// Filter, if set, filters files by their whole content.
+	Filter FilterFunc
+
+	// Workers is the number of files read at once, the number of CPUs if
+	// it's zero.
+	Workers int
+
+	// Archives descends into zip, tar and gzipped tar archives, see
+	// IsArchive. Their files get virtual paths like
+	// "bundle.zip!/img/offer.png" and go through the same filters. Archives
+	// are read whatever Include patterns are, archives in archives aren't
+	// descended into.
+	Archives bool
+
+	// MaxArchiveSize stops reading an archive once more bytes than that were
+	// decompressed from it, MaxArchiveFiles once it had more files, which
+	// protects from archive bombs. DefaultMaxArchiveSize and
+	// DefaultMaxArchiveFiles are used if they're zero.
+	MaxArchiveSize  int64
+	MaxArchiveFiles int
+}
+
+// Validate reports malformed glob patterns and negative limits.
+func (opts WalkOptions) Validate() error {
+	for _, p := range slices.Concat(opts.Include, opts.Exclude) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", p, err)
+		}
+	}
+	if opts.MaxSize < 0 || opts.MaxDepth < 0 || opts.SniffSize < 0 || opts.Workers < 0 ||
+		opts.MaxArchiveSize < 0 || opts.MaxArchiveFiles < 0 {
+		return errors.New("negative walk limits")
+	}
+
+	return nil
+}
+
+// AllowsDir reports whether directory at slash separated path rel,
+// relative to root, is descended into.
+func (opts WalkOptions) AllowsDir(rel string) bool {
+	if rel == "." {
+		return true
+	}
+
+	return !opts.hidden(rel) &&
+		!matchAny(opts.Exclude, rel) &&
+		(opts.MaxDepth == 0 || depth(rel) < opts.MaxDepth)
+}
+
+// AllowsFile reports whether file at slash separated path rel, relative to
+// root, of size bytes is read. Its content may still be rejected by Sniff
+// or Filter.
+func (opts WalkOptions) AllowsFile(rel string, size int64) bool {
+	switch {
+	case opts.hidden(rel):
+		return false
+	case opts.MaxDepth > 0 && depth(rel) > opts.MaxDepth:
+		return false
+	case opts.MaxSize > 0 && size > opts.MaxSize:
+		return false
+	case len(opts.Include) > 0 && !matchAny(opts.Include, rel):
+		return false
+	}
+
+	return !matchAny(opts.Exclude, rel)
+}
+
+func (opts WalkOptions) hidden(rel string) bool {
+	return opts.SkipHidden && strings.HasPrefix(path.Base(rel), ".")
+}
+
+func depth(rel string) int {
+	return strings.Count(rel, "/") + 1
+}
+
+func matchAny(patterns []string, rel string) bool {
+	base := path.Base(rel)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// read returns content of file name of fsys, nil if it's rejected by
+// sniff or filter, or grew bigger than MaxSize.
+func (opts WalkOptions) read(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	return opts.readFrom(name, f)
+}
+
+// readFrom returns content of file name read from r, see read.
+func (opts WalkOptions) readFrom(name string, r io.Reader) ([]byte, error) {
+	if opts.MaxSize > 0 {
+		r = io.LimitReader(r, opts.MaxSize+1)
+	}
+
+	var header []byte
+	if opts.Sniff != nil || opts.SniffFile != nil {
+		size := opts.SniffSize
+		if size == 0 {
+			size = DefaultSniffSize
+		}
+		header = make([]byte, size)
+		n, err := io.ReadFull(r, header)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+		header = header[:n]
+		if opts.Sniff != nil && !opts.Sniff(header) {
+			return nil, nil
+		}
+		if opts.SniffFile != nil && !opts.SniffFile(name, header) {
+			return nil, nil
+		}
+	}
+
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	data := append(header, rest...)
+	if opts.MaxSize > 0 && int64(len(data)) > opts.MaxSize {
+		return nil, nil
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	if opts.Filter != nil && !opts.Filter(data) {
+		return nil, nil
+	}
+
+	return data, nil
+}
+
+// ReadFile returns content of file name if it passes Sniff and Filter and
+// isn't bigger than MaxSize, nil otherwise. Its path isn't checked, see
+// AllowsFile. If Archives is set, name may be a virtual path of a file in an
+// archive, see SplitArchivePath.
+func (opts WalkOptions) ReadFile(name string) ([]byte, error) {
+	if archive, member, ok := SplitArchivePath(name); ok && opts.Archives && IsArchive(archive) {
+		return opts.readArchiveFile(archive, member)
+	}
+
+	return opts.read(os.DirFS(filepath.Dir(name)), filepath.Base(name))
+}
+
+// Walk traverses root directory and streams file entries which pass
+// filter f, see WalkWithOptions.
+func Walk(ctx context.Context, root string, f FilterFunc) (<-chan *Entry, error) {
+	return WalkWithOptions(ctx, root, WalkOptions{Filter: f})
+}
+
+// WalkWithOptions traverses root, a directory or a single file, and streams
+// entries of regular files allowed by opts. Files are read by a fixed number
+// of workers, so entries come in no particular order. Root may be an
+// archive if opts.Archives is set.
+//
+// Errors of reading a file or a directory are sent as entries with Err set
+// and don't stop the walk. The channel is closed once every file is read,
+// or right after ctx is done, whether it's drained or not.
+func WalkWithOptions(ctx context.Context, root string, opts WalkOptions) (<-chan *Entry, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("walk options: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+
+	dir, start := root, "."
+	if !info.IsDir() {
+		dir, start = filepath.Dir(root), filepath.Base(root)
+	}
+	name := func(rel string) string {
+		if !info.IsDir() {
+			return root
+		}
+
+		return filepath.Join(root, filepath.FromSlash(rel))
+	}
+
+	return walkFS(ctx, os.DirFS(dir), start, name, opts), nil
+}
+
+// walkFS streams entries of files of fsys below start, named by name.
+func walkFS(ctx context.Context, fsys fs.FS, start string, name func(rel string) string, opts WalkOptions) <-chan *Entry {
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+
+	out := make(chan *Entry)
+	paths := make(chan string)
+
+	send := func(e *Entry) bool {
+		select {
+		case out <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(paths)
+
+		_ = fs.WalkDir(fsys, start, func(rel string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if !send(&Entry{path: name(rel), err: fmt.Errorf("walk: %w", err)}) {
+					return ctx.Err()
+				}
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+
+				return nil
+			}
+			if d.IsDir() {
+				if !opts.AllowsDir(rel) {
+					return fs.SkipDir
+				}
+
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				if !send(&Entry{path: name(rel), err: fmt.Errorf("info: %w", err)}) {
+					return ctx.Err()
+				}
+
+				return nil
+			}
+			if opts.Archives && IsArchive(rel) {
+				if !opts.allowsArchive(rel, info.Size()) {
+					return nil
+				}
+			} else if !opts.AllowsFile(rel, info.Size()) {
+				return nil
+			}
+
+			select {
+			case paths <- rel:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for rel := range paths {
+				if ctx.Err() != nil {
+					return
+				}
+				if opts.Archives && IsArchive(rel) {
+					if !opts.readArchive(fsys, rel, name(rel), send) {
+						return
+					}
+
+					continue
+				}
+				data, err := opts.read(fsys, rel)
+				switch {
+				case err != nil:
+					err = fmt.Errorf("read %s: %w", name(rel), err)
+				case data == nil:
+					continue
+				}
+				if !send(&Entry{path: name(rel), content: data, err: err}) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,213 @@
+package pproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// testFS is a file system of n files, opening files named in fail fails.
+// Open blocks until release is closed, if it's set, and counts files open
+// at once.
+type testFS struct {
+	fstest.MapFS
+
+	fail    map[string]bool
+	release chan struct{}
+
+	open, maxOpen atomic.Int32
+}
+
+var errOpen = errors.New("open failed")
+
+func newTestFS(n int, fail ...string) *testFS {
+	fsys := &testFS{MapFS: make(fstest.MapFS), fail: make(map[string]bool)}
+	for i := range n {
+		fsys.MapFS[fmt.Sprintf("dir%d/file%d.txt", i%3, i)] = &fstest.MapFile{Data: []byte("golang developer")}
+	}
+	for _, name := range fail {
+		fsys.fail[name] = true
+	}
+
+	return fsys
+}
+
+func (fsys *testFS) Open(name string) (fs.File, error) {
+	if fsys.fail[name] {
+		return nil, errOpen
+	}
+	if path.Ext(name) == ".txt" {
+		n := fsys.open.Add(1)
+		defer fsys.open.Add(-1)
+		for {
+			maxOpen := fsys.maxOpen.Load()
+			if n <= maxOpen || fsys.maxOpen.CompareAndSwap(maxOpen, n) {
+				break
+			}
+		}
+		if fsys.release != nil {
+			<-fsys.release
+		}
+	}
+
+	return fsys.MapFS.Open(name)
+}
+
+func walkTestFS(ctx context.Context, fsys fs.FS, opts WalkOptions) <-chan *Entry {
+	return walkFS(ctx, fsys, ".", func(rel string) string { return rel }, opts)
+}
+
+func TestWalkFSErrors(t *testing.T) {
+	t.Parallel()
+
+	fsys := newTestFS(10, "dir1/file4.txt", "dir2/file5.txt")
+
+	var (
+		paths []string
+		errs  []error
+	)
+	for e := range walkTestFS(context.Background(), fsys, WalkOptions{Workers: 2}) {
+		if err := e.Err(); err != nil {
+			require.Nil(t, e.Content())
+			errs = append(errs, err)
+
+			continue
+		}
+		paths = append(paths, e.Path())
+	}
+
+	// Errors don't stop the walk and every one of them is reported
+	require.Len(t, paths, 8)
+	require.Len(t, errs, 2)
+	for _, err := range errs {
+		require.ErrorIs(t, err, errOpen)
+	}
+}
+
+func TestWalkFSWorkers(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		workers int
+	}{
+		{desc: "single_worker", workers: 1},
+		{desc: "three_workers", workers: 3},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			fsys := newTestFS(30)
+			fsys.release = make(chan struct{})
+
+			entries := walkTestFS(context.Background(), fsys, WalkOptions{Workers: tC.workers})
+
+			// Every worker blocks on a file
+			require.Eventually(t, func() bool {
+				return fsys.open.Load() == int32(tC.workers)
+			}, time.Second, time.Millisecond)
+			close(fsys.release)
+
+			total := 0
+			for range entries {
+				total++
+			}
+			require.Equal(t, 30, total)
+			require.Equal(t, int32(tC.workers), fsys.maxOpen.Load())
+		})
+	}
+}
+
+func TestWalkFSNoLeaks(t *testing.T) {
+	testCases := []struct {
+		desc string
+
+		// consume reads entries and may cancel the walk
+		consume func(entries <-chan *Entry, cancel context.CancelFunc)
+	}{
+		{
+			desc: "drained",
+
+			consume: func(entries <-chan *Entry, cancel context.CancelFunc) {
+				for range entries {
+				}
+			},
+		},
+		{
+			desc: "canceled_and_drained",
+
+			consume: func(entries <-chan *Entry, cancel context.CancelFunc) {
+				<-entries
+				cancel()
+				for range entries {
+				}
+			},
+		},
+		{
+			desc: "canceled_and_abandoned",
+
+			consume: func(entries <-chan *Entry, cancel context.CancelFunc) {
+				<-entries
+				<-entries
+				cancel()
+			},
+		},
+		{
+			desc: "canceled_before_reading",
+
+			consume: func(entries <-chan *Entry, cancel context.CancelFunc) {
+				cancel()
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fsys := newTestFS(100, "dir0/file3.txt")
+			tC.consume(walkTestFS(ctx, fsys, WalkOptions{Workers: 4}), cancel)
+		})
+	}
+}
+
+func TestWalkFSClosedOnCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	fsys := newTestFS(100)
+	fsys.release = make(chan struct{})
+	entries := walkTestFS(ctx, fsys, WalkOptions{Workers: 2})
+
+	var (
+		wg    sync.WaitGroup
+		total int
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for range entries {
+			total++
+		}
+	}()
+
+	cancel()
+	close(fsys.release)
+	wg.Wait()
+
+	require.Less(t, total, 100)
+}
@@ -0,0 +1,139 @@
+package textdoc
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped elements never hold readable content of a page.
+var skipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Button:   true,
+	atom.Select:   true,
+}
+
+// blocks are elements which start a new line.
+var blocks = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Blockquote: true,
+	atom.Br:         true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Form:       true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.Main:       true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Tr:         true,
+	atom.Ul:         true,
+}
+
+// HTMLLines returns text of content with scripts, styles, navigation and
+// hidden elements left out. Block elements end lines, whitespace within
+// them is collapsed except in preformatted text.
+func HTMLLines(content []byte) []string {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		// Parser recovers from malformed markup, only read errors end here
+		return nil
+	}
+
+	w := &lineWriter{}
+	w.walk(doc, false)
+	w.flush()
+
+	return w.lines
+}
+
+type lineWriter struct {
+	lines []string
+	line  strings.Builder
+}
+
+func (w *lineWriter) flush() {
+	if s := strings.Join(strings.Fields(w.line.String()), " "); s != "" {
+		w.lines = append(w.lines, s)
+	}
+	w.line.Reset()
+}
+
+func (w *lineWriter) walk(n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if !pre {
+			w.line.WriteString(n.Data)
+
+			return
+		}
+		for i, line := range strings.Split(n.Data, "\n") {
+			if i > 0 {
+				w.flush()
+			}
+			w.line.WriteString(line)
+		}
+
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] || hidden(n) {
+			return
+		}
+		pre = pre || n.DataAtom == atom.Pre
+	case html.CommentNode, html.DoctypeNode:
+		return
+	}
+
+	block := blocks[n.DataAtom]
+	if block {
+		w.flush()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c, pre)
+		if c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th) {
+			// Keep cells from sticking together
+			w.line.WriteString(" ")
+		}
+	}
+	if block {
+		w.flush()
+	}
+}
+
+func hidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch {
+		case a.Key == "hidden":
+			return true
+		case a.Key == "aria-hidden" && a.Val == "true":
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package textdoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLLines(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		html string
+		want []string
+	}{
+		{
+			desc: "inline_elements_stay_on_line",
+
+			html: "<p>Go <b>and</b> <a href='#'>Rust</a></p>",
+			want: []string{"Go and Rust"},
+		},
+		{
+			desc: "line_breaks",
+
+			html: "Go<br>Rust<div>Zig</div>",
+			want: []string{"Go", "Rust", "Zig"},
+		},
+		{
+			desc: "preformatted_text_keeps_lines",
+
+			html: "<pre>go build\ngo test</pre>",
+			want: []string{"go build", "go test"},
+		},
+		{
+			desc: "skips_scripts_styles_navigation_and_hidden",
+
+			html: "<script>x()</script><style>p{}</style><nav>Menu</nav>" +
+				"<span aria-hidden=\"true\">*</span><p>Go</p><!-- comment -->",
+			want: []string{"Go"},
+		},
+		{
+			desc: "entities",
+
+			html: "<p>C&#43;&#43; &lt;3 Go&nbsp;&amp;&nbsp;Rust</p>",
+			want: []string{"C++ <3 Go & Rust"},
+		},
+		{
+			desc: "malformed_markup",
+
+			html: "<div><p>Go<li>Rust</div></span>",
+			want: []string{"Go", "Rust"},
+		},
+		{
+			desc: "empty",
+
+			html: "",
+			want: nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, HTMLLines([]byte(tC.html)))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package textdoc
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\](?:\([^)]*\)|\[[^\]]*\])`)
+	mdAutolink   = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	mdTag        = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdComment    = regexp.MustCompile(`<!--.*?-->`)
+	mdLinkDef    = regexp.MustCompile(`^\[[^\]]+\]:\s*\S+`)
+	mdHeading    = regexp.MustCompile(`^#{1,6}(?:\s+|$)`)
+	mdClosing    = regexp.MustCompile(`\s+#+$`)
+	mdRule       = regexp.MustCompile(`^(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,}|=+)$`)
+	mdListMarker = regexp.MustCompile(`^(?:[-*+]|\d{1,9}[.)])\s+(?:\[[ xX]\]\s+)?`)
+	mdTableRule  = regexp.MustCompile(`^\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?$`)
+	mdOpenEmph   = regexp.MustCompile(`(^|[\s(])[*_]+([^\s*_])`)
+	mdCloseEmph  = regexp.MustCompile(`([^\s*_])[*_]+([\s).,;:!?]|$)`)
+	mdEscape     = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|>~])`)
+)
+
+// escapeBase starts private use code points escaped ASCII characters are
+// mapped to while syntax is stripped.
+const escapeBase = '\uE000'
+
+// MarkdownLines returns text of content without Markdown syntax. Links and
+// images are replaced by their text, front matter, rules and link
+// definitions are left out. Code blocks are kept as they are.
+func MarkdownLines(content []byte) []string {
+	var (
+		lines   []string
+		fence   string
+		comment bool
+	)
+	src := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	// Front matter
+	if len(src) > 0 && strings.TrimSpace(src[0]) == "---" {
+		for i := 1; i < len(src); i++ {
+			if strings.TrimSpace(src[i]) == "---" {
+				src = src[i+1:]
+
+				break
+			}
+		}
+	}
+
+	for _, line := range src {
+		line = strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(line, fence) {
+				fence = ""
+
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			continue
+		}
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			fence = line[:3]
+
+			continue
+		}
+
+		if comment {
+			end := strings.Index(line, "-->")
+			if end < 0 {
+				continue
+			}
+			comment = false
+			line = line[end+3:]
+		}
+		line = mdComment.ReplaceAllString(line, "")
+		if start := strings.Index(line, "<!--"); start >= 0 {
+			comment = true
+			line = line[:start]
+		}
+
+		if line = markdownLine(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// markdownLine strips block and inline syntax of a single line.
+func markdownLine(line string) string {
+	if mdRule.MatchString(line) || mdTableRule.MatchString(line) && strings.Contains(line, "-") {
+		return ""
+	}
+	if mdLinkDef.MatchString(line) {
+		return ""
+	}
+
+	// Escaped characters are hidden from syntax rules until the end
+	line = mdEscape.ReplaceAllStringFunc(line, func(esc string) string {
+		return string(escapeBase + rune(esc[1]))
+	})
+
+	for strings.HasPrefix(line, ">") {
+		line = strings.TrimSpace(line[1:])
+	}
+	line = mdHeading.ReplaceAllString(line, "")
+	line = mdClosing.ReplaceAllString(line, "")
+	line = mdListMarker.ReplaceAllString(line, "")
+	if strings.HasPrefix(line, "|") || strings.HasSuffix(line, "|") {
+		line = strings.Trim(line, "|")
+		line = strings.ReplaceAll(line, "|", " ")
+	}
+
+	line = mdImage.ReplaceAllString(line, "$1")
+	line = mdLink.ReplaceAllString(line, "$1")
+	line = mdAutolink.ReplaceAllString(line, "$1")
+	line = mdTag.ReplaceAllString(line, "")
+
+	line = strings.NewReplacer("**", "", "__", "", "~~", "", "`", "").Replace(line)
+	line = mdOpenEmph.ReplaceAllString(line, "$1$2")
+	line = mdCloseEmph.ReplaceAllString(line, "$1$2")
+	line = strings.Map(func(r rune) rune {
+		if r >= escapeBase && r < escapeBase+utf8.RuneSelf {
+			return r - escapeBase
+		}
+
+		return r
+	}, line)
+
+	return strings.Join(strings.Fields(line), " ")
+}
@@ -0,0 +1,68 @@
+package textdoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownLines(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		md   string
+		want []string
+	}{
+		{
+			desc: "headings",
+
+			md:   "# Go #\n\n### Rust",
+			want: []string{"Go", "Rust"},
+		},
+		{
+			desc: "emphasis_keeps_identifiers",
+
+			md:   "*Go* and __Rust__ with ~~Java~~ in `snake_case` or *C#*",
+			want: []string{"Go and Rust with Java in snake_case or C#"},
+		},
+		{
+			desc: "links_and_images",
+
+			md:   "[Go](https://go.dev) ![gopher](g.png) [Rust][rust]\n\n[rust]: https://rust-lang.org",
+			want: []string{"Go gopher Rust"},
+		},
+		{
+			desc: "lists_and_quotes",
+
+			md:   "- Go\n+ Rust\n2) Zig\n> > Nested quote",
+			want: []string{"Go", "Rust", "Zig", "Nested quote"},
+		},
+		{
+			desc: "code_blocks_are_kept",
+
+			md:   "```go\n# not a heading\n```\n",
+			want: []string{"# not a heading"},
+		},
+		{
+			desc: "setext_heading_and_rules",
+
+			md:   "Go\n===\n\n***\n- - -",
+			want: []string{"Go"},
+		},
+		{
+			desc: "escapes",
+
+			md:   `1\. \*literal\* C\#`,
+			want: []string{"1. *literal* C#"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, MarkdownLines([]byte(tC.md)))
+		})
+	}
+}
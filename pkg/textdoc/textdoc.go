@@ -0,0 +1,146 @@
+// Package textdoc reads HTML, Markdown and plain text documents as readable
+// lines, so text saved from a page doesn't have to go through ocr.
+package textdoc
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kndrad/piccrack/pkg/imgsniff"
+)
+
+// Kind is a type of text document.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	Plain
+	Markdown
+	HTML
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Plain:
+		return "plain"
+	case Markdown:
+		return "markdown"
+	case HTML:
+		return "html"
+	default:
+		return "unknown"
+	}
+}
+
+var extensions = map[string]Kind{
+	".txt":      Plain,
+	".text":     Plain,
+	".md":       Markdown,
+	".markdown": Markdown,
+	".html":     HTML,
+	".htm":      HTML,
+	".xhtml":    HTML,
+}
+
+var bom = []byte("\xEF\xBB\xBF")
+
+// Detect returns kind of document at path. Files with known extensions
+// must hold valid UTF-8 text, other files are only recognized as HTML by
+// their doctype or html tag. Without path, any text is read as Plain.
+func Detect(path string, content []byte) Kind {
+	if imgsniff.IsImage(content) || imgsniff.Is(content, imgsniff.PDF) {
+		return Unknown
+	}
+	if !isText(content) {
+		return Unknown
+	}
+	if kind, ok := extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return kind
+	}
+	if isHTML(content) {
+		return HTML
+	}
+	if path == "" {
+		return Plain
+	}
+
+	return Unknown
+}
+
+// Sniff reports whether file at path, whose content starts with header,
+// may be a document Detect recognizes, so that it's worth reading whole:
+// text with a known extension, or with a header of an HTML document.
+func Sniff(path string, header []byte) bool {
+	if imgsniff.IsImage(header) || imgsniff.Is(header, imgsniff.PDF) {
+		return false
+	}
+	if !isText(trimPartialRune(header)) {
+		return false
+	}
+	if _, ok := extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return true
+	}
+
+	return isHTML(header) || path == ""
+}
+
+// trimPartialRune trims the last rune of b if it's cut off, e.g. at the end
+// of a header.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+
+			break
+		}
+	}
+
+	return b
+}
+
+// isText reports whether content is UTF-8 without control characters
+// found in binary files.
+func isText(content []byte) bool {
+	if !utf8.Valid(content) {
+		return false
+	}
+
+	return bytes.IndexByte(content, 0) < 0
+}
+
+func isHTML(content []byte) bool {
+	content = bytes.TrimLeft(bytes.TrimPrefix(content, bom), " \t\r\n")
+	head := strings.ToLower(string(content[:min(len(content), 64)]))
+
+	return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
+}
+
+// Lines returns readable lines of content, trimmed and without empty ones.
+func Lines(kind Kind, content []byte) []string {
+	content = bytes.TrimPrefix(content, bom)
+
+	switch kind {
+	case HTML:
+		return HTMLLines(content)
+	case Markdown:
+		return MarkdownLines(content)
+	default:
+		return PlainLines(content)
+	}
+}
+
+// PlainLines splits content into lines.
+func PlainLines(content []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
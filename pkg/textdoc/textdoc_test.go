@@ -0,0 +1,215 @@
+package textdoc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		path    string
+		content []byte
+		want    Kind
+	}{
+		{
+			desc: "html_by_extension",
+
+			path:    "offer.HTM",
+			content: []byte("<p>Go</p>"),
+			want:    HTML,
+		},
+		{
+			desc: "html_by_doctype",
+
+			path:    "offer",
+			content: []byte("\xEF\xBB\xBF\n<!DOCTYPE html><p>Go</p>"),
+			want:    HTML,
+		},
+		{
+			desc: "markdown",
+
+			path:    "offer.md",
+			content: []byte("# Go"),
+			want:    Markdown,
+		},
+		{
+			desc: "plain_text",
+
+			path:    "offer.txt",
+			content: []byte("Go"),
+			want:    Plain,
+		},
+		{
+			desc: "text_without_path",
+
+			content: []byte("Go developer"),
+			want:    Plain,
+		},
+		{
+			desc: "unknown_extension",
+
+			path:    "main.go",
+			content: []byte("package main"),
+			want:    Unknown,
+		},
+		{
+			desc: "binary_content",
+
+			path:    "offer.txt",
+			content: []byte("Go\x00\x01"),
+			want:    Unknown,
+		},
+		{
+			desc: "invalid_utf8",
+
+			path:    "offer.txt",
+			content: []byte("Go\xFF"),
+			want:    Unknown,
+		},
+		{
+			desc: "image",
+
+			path:    "offer.txt",
+			content: []byte("GIF89a"),
+			want:    Unknown,
+		},
+		{
+			desc: "pdf",
+
+			content: []byte("%PDF-1.4\n"),
+			want:    Unknown,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, Detect(tC.path, tC.content))
+		})
+	}
+}
+
+func TestSniff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		path   string
+		header []byte
+		want   bool
+	}{
+		{
+			desc: "known_extension",
+
+			path:   "offer.md",
+			header: []byte("# Go"),
+			want:   true,
+		},
+		{
+			desc: "html_by_doctype",
+
+			path:   "offer",
+			header: []byte("<!DOCTYPE html><p>Go</p>"),
+			want:   true,
+		},
+		{
+			desc: "rune_cut_off_at_the_end_of_header",
+
+			path:   "offer.txt",
+			header: []byte("Zażółć")[:4],
+			want:   true,
+		},
+		{
+			desc: "unknown_extension",
+
+			path:   "server.log",
+			header: []byte("GET /offers 200"),
+		},
+		{
+			desc: "binary",
+
+			path:   "movie.txt",
+			header: []byte("\x1aE\xdf\xa3\x00\x00"),
+		},
+		{
+			desc: "image",
+
+			path:   "offer.txt",
+			header: []byte("GIF89a"),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, Sniff(tC.path, tC.header))
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		name string
+		want []string
+	}{
+		{
+			desc: "html",
+
+			name: "offer.html",
+			want: []string{
+				"Senior Go Developer",
+				"We are looking for an engineer with Kubernetes & Postgres experience.",
+				"Remote work",
+				"Private healthcare",
+				"Salary 30k PLN",
+			},
+		},
+		{
+			desc: "markdown",
+
+			name: "offer.md",
+			want: []string{
+				"Senior Go Developer",
+				"We are looking for an engineer with Kubernetes & Postgres experience.",
+				"Benefits",
+				"Remote work",
+				"Private healthcare",
+				"Team of snake_case fans",
+				"Apply at https://example.com/jobs",
+				"Salary 30k PLN",
+				"Office photo",
+			},
+		},
+		{
+			desc: "plain",
+
+			name: "offer.txt",
+			want: []string{"Senior Go Developer", "Remote work"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join("testdata", tC.name)
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			kind := Detect(path, content)
+			require.NotEqual(t, Unknown, kind)
+			require.Equal(t, tC.want, Lines(kind, content))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package textproc
+
+import (
+	"cmp"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// stopWords are function words which n-grams can't start nor end with.
+// Lists of stop words of search engines include words like "go", "system"
+// or "full", which are terms here.
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true,
+	"am": true, "an": true, "and": true, "any": true, "are": true, "as": true,
+	"at": true, "be": true, "been": true, "being": true, "but": true,
+	"by": true, "can": true, "could": true, "did": true, "do": true,
+	"does": true, "during": true, "each": true, "etc": true, "every": true,
+	"for": true, "from": true, "had": true, "has": true, "have": true,
+	"he": true, "her": true, "his": true, "how": true, "i": true, "if": true,
+	"in": true, "including": true, "into": true, "is": true, "it": true,
+	"its": true, "may": true, "me": true, "more": true, "most": true,
+	"must": true, "my": true, "not": true, "of": true, "on": true, "or": true,
+	"other": true, "our": true, "ours": true, "over": true, "per": true,
+	"plus": true, "should": true, "so": true, "some": true, "such": true,
+	"than": true, "that": true, "the": true, "their": true, "them": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true,
+	"those": true, "through": true, "to": true, "under": true, "up": true,
+	"us": true, "very": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true,
+	"who": true, "will": true, "with": true, "within": true, "would": true,
+	"you": true, "your": true, "yours": true, "we're": true, "you'll": true,
+	"you're": true, "it's": true,
+	"aby": true, "ale": true, "bez": true, "być": true, "dla": true,
+	"jak": true, "jest": true, "jeśli": true, "już": true, "lub": true,
+	"na": true, "nad": true, "nie": true, "o": true, "od": true,
+	"oraz": true, "po": true, "pod": true, "przez": true, "przy": true,
+	"się": true, "są": true, "także": true, "u": true, "w": true, "z": true,
+	"za": true, "ze": true, "że": true, "nasz": true, "nasze": true,
+	"naszym": true, "twoje": true, "twój": true, "który": true,
+	"która": true, "które": true, "których": true,
+}
+
+// IsStopWord reports whether word, as returned by Words, is an English or
+// Polish function word like "and", "of" or "oraz".
+func IsStopWord(word string) bool {
+	return stopWords[word]
+}
+
+// segmentBoundary matches punctuation ending a clause, a sentence or a line,
+// and opening brackets, which n-grams don't cross. Dots inside tokens, like
+// in "node.js", aren't followed by a space.
+var segmentBoundary = regexp.MustCompile(`[,.!?;:)\]}|•·▪●■►✓✔"”]+(?:\s+|$)|(?:^|\s)[(\[{"“]+|\s[-–—/]+\s|\n`)
+
+// Segments splits text into runs of words, as returned by Words, at ends
+// of lines, sentences and clauses.
+func Segments(text string) [][]string {
+	var segments [][]string
+	for _, part := range segmentBoundary.Split(text, -1) {
+		if words := Words(part); len(words) > 0 {
+			segments = append(segments, words)
+		}
+	}
+
+	return segments
+}
+
+// Ngram is a sequence of words of segments scored as a collocation.
+type Ngram struct {
+	// Text is words of the n-gram joined with single spaces.
+	Text  string
+	N     int
+	Count int
+	// PMI is pointwise mutual information of words of the n-gram, in bits.
+	// Rare words occurring together score high.
+	PMI float64
+	// LLR is log-likelihood ratio (G²) of the n-gram against words
+	// preceding its last word by chance, see Dunning (1993). Frequent
+	// collocations score high.
+	LLR float64
+}
+
+// NgramOptions configure extraction of n-grams.
+type NgramOptions struct {
+	// MinCount is the number of occurrences of n-grams below which they're
+	// skipped, 1 if zero.
+	MinCount int
+	// Stop reports words which n-grams can't start nor end with,
+	// IsStopWord if nil. Stop words inside trigrams are kept, e.g. in
+	// "infrastructure as code".
+	Stop func(word string) bool
+}
+
+// Ngrams returns bigrams and trigrams of segments, see Segments, sorted by
+// LLR, most significant first. N-grams don't cross segments.
+func Ngrams(segments [][]string, opts NgramOptions) []Ngram {
+	if opts.MinCount == 0 {
+		opts.MinCount = 1
+	}
+	if opts.Stop == nil {
+		opts.Stop = IsStopWord
+	}
+
+	unigrams := make(map[string]int)
+	total := 0
+	for _, words := range segments {
+		for _, w := range words {
+			unigrams[w]++
+			total++
+		}
+	}
+
+	var ngrams []Ngram
+	for n := 2; n <= 3; n++ {
+		ngrams = append(ngrams, countNgrams(segments, n, unigrams, total, opts)...)
+	}
+	slices.SortStableFunc(ngrams, func(a, b Ngram) int {
+		if c := cmp.Compare(b.LLR, a.LLR); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Text, b.Text)
+	})
+
+	return ngrams
+}
+
+// countNgrams returns scored n-grams of n words of segments. Unigrams hold
+// counts of words of segments, total is their sum.
+func countNgrams(segments [][]string, n int, unigrams map[string]int, total int, opts NgramOptions) []Ngram {
+	var (
+		counts   = make(map[string]int)
+		prefixes = make(map[string]int)
+		lasts    = make(map[string]int)
+		windows  = 0
+		order    []string
+	)
+	for _, words := range segments {
+		for i := 0; i+n <= len(words); i++ {
+			window := words[i : i+n]
+			windows++
+			prefixes[strings.Join(window[:n-1], " ")]++
+			lasts[window[n-1]]++
+
+			if opts.Stop(window[0]) || opts.Stop(window[n-1]) {
+				continue
+			}
+			text := strings.Join(window, " ")
+			if counts[text] == 0 {
+				order = append(order, text)
+			}
+			counts[text]++
+		}
+	}
+
+	var ngrams []Ngram
+	for _, text := range order {
+		count := counts[text]
+		if count < opts.MinCount {
+			continue
+		}
+		words := strings.Split(text, " ")
+
+		// log(P(w1..wn) / (P(w1)...P(wn)))
+		pmi := math.Log2(float64(count) / float64(windows))
+		for _, w := range words {
+			pmi -= math.Log2(float64(unigrams[w]) / float64(total))
+		}
+
+		prefix := strings.Join(words[:n-1], " ")
+		ngrams = append(ngrams, Ngram{
+			Text:  text,
+			N:     n,
+			Count: count,
+			PMI:   pmi,
+			LLR:   logLikelihood(count, prefixes[prefix], lasts[words[n-1]], windows),
+		})
+	}
+
+	return ngrams
+}
+
+// logLikelihood returns G² of contingency table of n windows, k of which
+// have both the prefix and the last word, with a prefix and b last word
+// counts of windows.
+func logLikelihood(k, a, b, n int) float64 {
+	observed := [4]float64{
+		float64(k),
+		float64(a - k),
+		float64(b - k),
+		float64(n - a - b + k),
+	}
+	expected := [4]float64{
+		float64(a) * float64(b) / float64(n),
+		float64(a) * float64(n-b) / float64(n),
+		float64(n-a) * float64(b) / float64(n),
+		float64(n-a) * float64(n-b) / float64(n),
+	}
+
+	g2 := 0.0
+	for i, o := range observed {
+		if o > 0 && expected[i] > 0 {
+			g2 += o * math.Log(o/expected[i])
+		}
+	}
+
+	return 2 * g2
+}
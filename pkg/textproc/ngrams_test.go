@@ -0,0 +1,98 @@
+package textproc_test
+
+import (
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegments(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		text string
+		want [][]string
+	}{
+		{
+			desc: "sentences_and_lines",
+
+			text: "We build distributed systems. Node.js and Go\nKubernetes",
+			want: [][]string{{"we", "build", "distributed", "systems"}, {"node.js", "and", "go"}, {"kubernetes"}},
+		},
+		{
+			desc: "clauses",
+
+			text: "Kafka, RabbitMQ (nice to have) - Docker",
+			want: [][]string{{"kafka"}, {"rabbitmq"}, {"nice", "to", "have"}, {"docker"}},
+		},
+		{
+			desc: "no_words",
+
+			text: " ... \n",
+			want: nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, textproc.Segments(tC.text))
+		})
+	}
+}
+
+func TestNgrams(t *testing.T) {
+	t.Parallel()
+
+	text := `We build distributed systems. Experience with distributed systems
+and infrastructure as code is required. Knowledge of design patterns;
+design patterns in Go, infrastructure as code (Terraform).`
+
+	ngrams := textproc.Ngrams(textproc.Segments(text), textproc.NgramOptions{})
+	require.NotEmpty(t, ngrams)
+
+	byText := make(map[string]textproc.Ngram)
+	for _, ng := range ngrams {
+		byText[ng.Text] = ng
+	}
+
+	// The most frequent collocations come first
+	require.ElementsMatch(t, []string{"distributed systems", "design patterns"}, []string{ngrams[0].Text, ngrams[1].Text})
+	require.Equal(t, 2, byText["distributed systems"].Count)
+	require.Equal(t, 2, byText["design patterns"].Count)
+	require.Equal(t, 2, byText["infrastructure as code"].Count)
+	require.Equal(t, 3, byText["infrastructure as code"].N)
+	require.Greater(t, byText["infrastructure as code"].PMI, 0.0)
+	require.Greater(t, byText["design patterns"].LLR, byText["build distributed"].LLR)
+
+	for _, ng := range ngrams {
+		require.Positive(t, ng.Count)
+		require.GreaterOrEqual(t, ng.LLR, 0.0)
+	}
+
+	// N-grams neither start nor end with stop words, nor cross segments
+	for _, text := range []string{"with distributed", "systems and", "as code", "code terraform", "systems experience"} {
+		require.NotContains(t, byText, text)
+	}
+
+	frequent := textproc.Ngrams(textproc.Segments(text), textproc.NgramOptions{MinCount: 2})
+	require.Len(t, frequent, 3)
+}
+
+func TestNgramsCustomStopWords(t *testing.T) {
+	t.Parallel()
+
+	segments := [][]string{{"senior", "go", "developer"}}
+	ngrams := textproc.Ngrams(segments, textproc.NgramOptions{
+		Stop: func(word string) bool { return word == "senior" },
+	})
+
+	var texts []string
+	for _, ng := range ngrams {
+		texts = append(texts, ng.Text)
+	}
+	require.ElementsMatch(t, []string{"go developer"}, texts)
+}
@@ -0,0 +1,187 @@
+package textproc
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sentence is text assembled from one or more wrapped lines.
+type Sentence struct {
+	Text string
+
+	// Start and End are indexes of the first and the last line the
+	// sentence was assembled from.
+	Start, End int
+}
+
+// bulletMarker matches list item markers starting a line, e.g. "-", "•",
+// "1." or "a)".
+var bulletMarker = regexp.MustCompile(`^(?:[-–—•·▪▫●○◦■□►▸✓✔*+]+|\(?(?:\d{1,2}|[a-zA-Z])[.)])\s+`)
+
+// connectors are words after which a line can't end a sentence.
+var connectors = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true,
+	"for": true, "from": true, "in": true, "including": true, "into": true,
+	"like": true, "of": true, "on": true, "or": true, "such": true, "the": true,
+	"to": true, "with": true, "within": true,
+	"do": true, "dla": true, "i": true, "lub": true, "na": true, "oraz": true,
+	"od": true, "po": true, "przy": true, "w": true, "we": true, "z": true,
+	"ze": true, "np.": true, "tj.": true,
+}
+
+// abbreviations end with a dot which doesn't end a sentence.
+var abbreviations = map[string]bool{
+	"e.g.": true, "i.e.": true, "etc.": true, "vs.": true, "approx.": true,
+	"incl.": true, "min.": true, "max.": true, "mr.": true, "mrs.": true,
+	"ms.": true, "dr.": true, "inc.": true, "ltd.": true, "no.": true,
+	"np.": true, "tj.": true, "itp.": true, "itd.": true, "m.in.": true,
+	"ok.": true, "tzw.": true, "wg.": true, "ul.": true, "godz.": true,
+}
+
+// AssembleSentences rejoins lines wrapped by ocr or layout into sentences
+// and splits them at sentence boundaries.
+//
+// A word broken with a hyphen at the end of a line is joined with its rest.
+// A line continues the previous one unless that one ends with terminal
+// punctuation, the line starts with a bullet marker, or it starts with an
+// upper case letter after a complete line. Empty lines always end
+// a sentence. Bullet markers are removed.
+func AssembleSentences(lines []string) []Sentence {
+	var (
+		sentences []Sentence
+		cur       *lineWords
+	)
+	flush := func() {
+		if cur != nil {
+			sentences = append(sentences, cur.sentences()...)
+			cur = nil
+		}
+	}
+
+	for i, line := range lines {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			flush()
+
+			continue
+		}
+		line = strings.Join(words, " ")
+		bullet := false
+		if loc := bulletMarker.FindStringIndex(line); loc != nil {
+			line, bullet = line[loc[1]:], true
+		}
+
+		switch {
+		case cur == nil || bullet:
+			flush()
+			cur = new(lineWords)
+		case brokenWord(cur.text(), line):
+			// Rest of the word belongs to the line the word started at
+			last := len(cur.words) - 1
+			rest, tail, _ := strings.Cut(line, " ")
+			cur.words[last] = strings.TrimSuffix(cur.words[last], "-") + rest
+			line = tail
+		case !continues(cur.text(), line):
+			flush()
+			cur = new(lineWords)
+		}
+		cur.add(line, i)
+	}
+	flush()
+
+	return sentences
+}
+
+// lineWords are words of a sentence being assembled and indexes of lines
+// they come from.
+type lineWords struct {
+	words []string
+	lines []int
+}
+
+func (lw *lineWords) add(line string, i int) {
+	for _, w := range strings.Fields(line) {
+		lw.words = append(lw.words, w)
+		lw.lines = append(lw.lines, i)
+	}
+}
+
+func (lw *lineWords) text() string {
+	return strings.Join(lw.words, " ")
+}
+
+// sentences splits words at ".", "!" or "?" followed by an upper case
+// letter or a digit, unless the dot ends an abbreviation.
+func (lw *lineWords) sentences() []Sentence {
+	var (
+		out   []Sentence
+		start int
+	)
+	for i := range lw.words {
+		if i+1 < len(lw.words) && !boundary(lw.words[i], lw.words[i+1]) {
+			continue
+		}
+		out = append(out, Sentence{
+			Text:  strings.Join(lw.words[start:i+1], " "),
+			Start: lw.lines[start],
+			End:   lw.lines[i],
+		})
+		start = i + 1
+	}
+
+	return out
+}
+
+func boundary(word, next string) bool {
+	last, _ := utf8.DecodeLastRuneInString(word)
+	first, _ := utf8.DecodeRuneInString(next)
+	if !strings.ContainsRune(".!?", last) || abbreviations[strings.ToLower(word)] {
+		return false
+	}
+
+	return unicode.IsUpper(first) || unicode.IsDigit(first)
+}
+
+// continues reports whether line continues text of the previous lines.
+func continues(prev, line string) bool {
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(line)
+
+	switch {
+	case strings.ContainsRune(".!?:;", last) && !abbreviations[strings.ToLower(lastWord(prev))]:
+		return false
+	case strings.ContainsRune(",(/&+", last):
+		return true
+	case connectors[strings.ToLower(lastWord(prev))]:
+		return true
+	case unicode.IsLower(first) || unicode.IsDigit(first):
+		return true
+	case strings.ContainsRune(",.;:)]/&", first):
+		return true
+	}
+
+	return false
+}
+
+// brokenWord reports whether prev ends with a word broken with a hyphen
+// which line continues.
+func brokenWord(prev, line string) bool {
+	before, ok := strings.CutSuffix(prev, "-")
+	if !ok {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(before)
+	first, _ := utf8.DecodeRuneInString(line)
+
+	return unicode.IsLetter(last) && unicode.IsLower(first)
+}
+
+func lastWord(s string) string {
+	if i := strings.LastIndexByte(s, ' '); i >= 0 {
+		return s[i+1:]
+	}
+
+	return s
+}
@@ -0,0 +1,114 @@
+package textproc_test
+
+import (
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssembleSentences(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		lines []string
+		want  []textproc.Sentence
+	}{
+		{
+			desc: "wrapped_bullet_is_rejoined",
+
+			lines: []string{
+				"- Experience with distributed systems and Linux",
+				"networking, including TCP/IP, SSH and HTTP",
+				"- Kubernetes",
+			},
+			want: []textproc.Sentence{
+				{Text: "Experience with distributed systems and Linux networking, including TCP/IP, SSH and HTTP", Start: 0, End: 1},
+				{Text: "Kubernetes", Start: 2, End: 2},
+			},
+		},
+		{
+			desc: "hyphenated_words_are_joined",
+
+			lines: []string{
+				"Strong experience with infra-",
+				"structure as code and hands-on",
+				"production experience.",
+			},
+			want: []textproc.Sentence{
+				{Text: "Strong experience with infrastructure as code and hands-on production experience.", Start: 0, End: 2},
+			},
+		},
+		{
+			desc: "connector_continues_a_capitalized_line",
+
+			lines: []string{
+				"Experience with",
+				"Kubernetes and Helm",
+				"Terraform",
+			},
+			want: []textproc.Sentence{
+				{Text: "Experience with Kubernetes and Helm", Start: 0, End: 1},
+				{Text: "Terraform", Start: 2, End: 2},
+			},
+		},
+		{
+			desc: "sentence_boundaries_split_lines",
+
+			lines: []string{
+				"We build payments. You will own services, e.g. billing",
+				"and invoicing. 3+ years of Go!",
+			},
+			want: []textproc.Sentence{
+				{Text: "We build payments.", Start: 0, End: 0},
+				{Text: "You will own services, e.g. billing and invoicing.", Start: 0, End: 1},
+				{Text: "3+ years of Go!", Start: 1, End: 1},
+			},
+		},
+		{
+			desc: "headers_and_empty_lines_end_sentences",
+
+			lines: []string{
+				"Requirements:",
+				"golang",
+				"",
+				"docker",
+				"1. Benefits",
+				"a) private healthcare",
+			},
+			want: []textproc.Sentence{
+				{Text: "Requirements:", Start: 0, End: 0},
+				{Text: "golang", Start: 1, End: 1},
+				{Text: "docker", Start: 3, End: 3},
+				{Text: "Benefits", Start: 4, End: 4},
+				{Text: "private healthcare", Start: 5, End: 5},
+			},
+		},
+		{
+			desc: "polish_offer",
+
+			lines: []string{
+				"• Doświadczenie w pracy z Go oraz",
+				"PostgreSQL, m.in. projektowanie",
+				"schematów.",
+				"• Znajomość Dockera",
+			},
+			want: []textproc.Sentence{
+				{Text: "Doświadczenie w pracy z Go oraz PostgreSQL, m.in. projektowanie schematów.", Start: 0, End: 2},
+				{Text: "Znajomość Dockera", Start: 3, End: 3},
+			},
+		},
+		{
+			desc: "empty",
+
+			lines: []string{"", "  "},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			require.Equal(t, tC.want, textproc.AssembleSentences(tC.lines))
+		})
+	}
+}
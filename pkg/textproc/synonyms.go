@@ -0,0 +1,143 @@
+package textproc
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed synonyms.yaml
+var synonymsYAML []byte
+
+// Synonyms maps variants of terms to their canonical form, e.g. "k8s" and
+// "kube" to "kubernetes", so that they're counted as one term.
+type Synonyms map[string]string
+
+// defaultSynonyms parses the dictionary shipped with the package once.
+var defaultSynonyms = sync.OnceValue(func() Synonyms {
+	s, err := ParseSynonyms(synonymsYAML)
+	if err != nil {
+		panic(fmt.Sprintf("textproc: shipped synonyms: %v", err))
+	}
+
+	return s
+})
+
+// DefaultSynonyms returns the dictionary shipped with the package.
+func DefaultSynonyms() Synonyms {
+	return defaultSynonyms().Merge(nil)
+}
+
+// ParseSynonyms parses YAML mapping canonical terms to lists of their
+// variants, e.g.
+//
+//	kubernetes: [k8s, kube]
+//	go: [golang]
+//
+// Terms are tokenized first, so they match words returned by Words.
+func ParseSynonyms(data []byte) (Synonyms, error) {
+	var entries map[string][]string
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	s := make(Synonyms)
+	canonicals := make(map[string][]string, len(entries))
+	for c, variants := range entries {
+		canonical, err := term(c)
+		if err != nil {
+			return nil, err
+		}
+		s[canonical] = canonical
+		canonicals[canonical] = variants
+	}
+	for canonical, variants := range canonicals {
+		for _, v := range variants {
+			variant, err := term(v)
+			if err != nil {
+				return nil, err
+			}
+			if prev, ok := s[variant]; ok && prev != canonical {
+				return nil, fmt.Errorf("variant %q of %q is %q already", variant, canonical, prev)
+			}
+			s[variant] = canonical
+		}
+	}
+
+	return s, nil
+}
+
+// term returns s as a single token.
+func term(s string) (string, error) {
+	tokens := Tokenize(s)
+	if len(tokens) != 1 {
+		return "", fmt.Errorf("term %q isn't a single word", s)
+	}
+
+	return tokens[0].Text, nil
+}
+
+// LoadSynonyms returns the shipped dictionary with entries of YAML file at
+// path overriding it, see ParseSynonyms. Overrides may map a variant to
+// another term, or to itself to count it separately again. Empty path
+// returns the shipped dictionary.
+func LoadSynonyms(path string) (Synonyms, error) {
+	if path == "" {
+		return DefaultSynonyms(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	overrides, err := ParseSynonyms(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return DefaultSynonyms().Merge(overrides), nil
+}
+
+// Merge returns a copy of s with entries of overrides replacing its own.
+func (s Synonyms) Merge(overrides Synonyms) Synonyms {
+	merged := make(Synonyms, len(s)+len(overrides))
+	for variant, canonical := range s {
+		merged[variant] = canonical
+	}
+	for variant, canonical := range overrides {
+		merged[variant] = canonical
+	}
+
+	return merged
+}
+
+// Canonical returns canonical form of word, the word itself if it has
+// none. Word is tokenized like terms of the dictionary first, so "K8S" is
+// "kubernetes" too, and a word without canonical form is returned as its
+// token. Text which isn't a single word is returned as it is.
+func (s Synonyms) Canonical(word string) string {
+	if canonical, ok := s[word]; ok {
+		return canonical
+	}
+	t, err := term(word)
+	if err != nil {
+		return word
+	}
+	if canonical, ok := s[t]; ok {
+		return canonical
+	}
+
+	return t
+}
+
+// CanonicalWords returns canonical forms of words, see Canonical.
+func (s Synonyms) CanonicalWords(words []string) []string {
+	canonical := make([]string, len(words))
+	for i, w := range words {
+		canonical[i] = s.Canonical(w)
+	}
+
+	return canonical
+}
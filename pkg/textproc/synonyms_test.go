@@ -0,0 +1,123 @@
+package textproc_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultSynonyms(t *testing.T) {
+	t.Parallel()
+
+	s := textproc.DefaultSynonyms()
+
+	testCases := []struct {
+		word string
+		want string
+	}{
+		{word: "k8s", want: "kubernetes"},
+		{word: "kubernetes", want: "kubernetes"},
+		{word: "golang", want: "go"},
+		{word: "postgres", want: "postgresql"},
+		{word: "nodejs", want: "node.js"},
+		{word: "dotnet", want: ".net"},
+		{word: "rust", want: "rust"},
+		{word: "K8S", want: "kubernetes"},
+		{word: "Golang,", want: "go"},
+		{word: "Rust", want: "rust"},
+		{word: "remote work", want: "remote work"},
+	}
+	for _, tC := range testCases {
+		require.Equal(t, tC.want, s.Canonical(tC.word), tC.word)
+	}
+
+	// Words are tokenized the same way as ingested text
+	words := textproc.Words("K8S, Golang and Postgres")
+	require.Equal(t, []string{"kubernetes", "go", "and", "postgresql"}, s.CanonicalWords(words))
+
+	// Copies can be changed
+	s["k8s"] = "k8s"
+	require.Equal(t, "kubernetes", textproc.DefaultSynonyms().Canonical("k8s"))
+}
+
+func TestParseSynonyms(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		data    string
+		want    textproc.Synonyms
+		wantErr bool
+	}{
+		{
+			desc: "terms_are_tokenized",
+
+			data: "Kubernetes: [K8s, \"kube,\"]\n",
+			want: textproc.Synonyms{"kubernetes": "kubernetes", "k8s": "kubernetes", "kube": "kubernetes"},
+		},
+		{
+			desc: "variant_of_two_terms",
+
+			data:    "go: [golang]\ngolang-lang: [golang]\n",
+			wantErr: true,
+		},
+		{
+			desc: "variant_which_is_a_term",
+
+			data:    "go: [golang]\ngolang: [gopher]\n",
+			wantErr: true,
+		},
+		{
+			desc: "many_words",
+
+			data:    "machine learning: [ml]\n",
+			wantErr: true,
+		},
+		{
+			desc: "not_a_mapping",
+
+			data:    "- go\n",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := textproc.ParseSynonyms([]byte(tC.data))
+			if tC.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "synonyms.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("golang: [go]\nk8s: []\nspring: [spring-boot]\n"), 0o600))
+
+	s, err := textproc.LoadSynonyms(path)
+	require.NoError(t, err)
+	require.Equal(t, "golang", s.Canonical("go"))
+	require.Equal(t, "golang", s.Canonical("golang"))
+	require.Equal(t, "k8s", s.Canonical("k8s"))
+	require.Equal(t, "kubernetes", s.Canonical("kube"))
+	require.Equal(t, "spring", s.Canonical("spring-boot"))
+
+	s, err = textproc.LoadSynonyms("")
+	require.NoError(t, err)
+	require.Equal(t, textproc.DefaultSynonyms(), s)
+
+	_, err = textproc.LoadSynonyms(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
@@ -0,0 +1,207 @@
+package textproc
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed taxonomy.yaml
+var taxonomyYAML []byte
+
+// Taxonomy maps technology terms to their categories, e.g. "kafka" to
+// "messaging" or "github actions" to "ci/cd". Words of terms are joined
+// with single spaces.
+type Taxonomy map[string]string
+
+// defaultTaxonomy parses the taxonomy shipped with the package once.
+var defaultTaxonomy = sync.OnceValue(func() Taxonomy {
+	t, err := ParseTaxonomy(taxonomyYAML)
+	if err != nil {
+		panic(fmt.Sprintf("textproc: shipped taxonomy: %v", err))
+	}
+
+	return t
+})
+
+// DefaultTaxonomy returns the taxonomy shipped with the package.
+func DefaultTaxonomy() Taxonomy {
+	return defaultTaxonomy().Merge(nil)
+}
+
+// ParseTaxonomy parses YAML mapping categories to lists of their terms,
+// e.g.
+//
+//	messaging: [kafka, rabbitmq, google pub/sub]
+//	observability: [prometheus, new relic]
+//
+// Terms are tokenized first, so they match words returned by Words.
+func ParseTaxonomy(data []byte) (Taxonomy, error) {
+	var entries map[string][]string
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	t := make(Taxonomy)
+	for c, terms := range entries {
+		category := strings.ToLower(strings.TrimSpace(c))
+		// Categories are stored joined with commas
+		if category == "" || strings.Contains(category, ",") {
+			return nil, fmt.Errorf("invalid category %q", c)
+		}
+		for _, term := range terms {
+			words := Words(term)
+			if len(words) == 0 {
+				return nil, fmt.Errorf("term %q of %q has no words", term, category)
+			}
+			key := strings.Join(words, " ")
+			if prev, ok := t[key]; ok && prev != category {
+				return nil, fmt.Errorf("term %q of %q is of %q already", key, category, prev)
+			}
+			t[key] = category
+		}
+	}
+
+	return t, nil
+}
+
+// LoadTaxonomy returns the shipped taxonomy with entries of YAML file at
+// path overriding it, see ParseTaxonomy. Empty path returns the shipped
+// taxonomy.
+func LoadTaxonomy(path string) (Taxonomy, error) {
+	if path == "" {
+		return DefaultTaxonomy(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	overrides, err := ParseTaxonomy(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return DefaultTaxonomy().Merge(overrides), nil
+}
+
+// Merge returns a copy of t with entries of overrides replacing its own.
+func (t Taxonomy) Merge(overrides Taxonomy) Taxonomy {
+	merged := make(Taxonomy, len(t)+len(overrides))
+	for term, category := range t {
+		merged[term] = category
+	}
+	for term, category := range overrides {
+		merged[term] = category
+	}
+
+	return merged
+}
+
+// Categories returns sorted categories of t.
+func (t Taxonomy) Categories() []string {
+	var categories []string
+	for _, category := range t {
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	slices.Sort(categories)
+
+	return categories
+}
+
+// TermMatch is a term of a taxonomy found in words.
+type TermMatch struct {
+	Term     string
+	Category string
+	// Start is index of the first word of the term, End of the word after
+	// its last one.
+	Start, End int
+}
+
+// Matcher finds terms of a taxonomy in words, including terms of many
+// words.
+type Matcher struct {
+	synonyms Synonyms
+	terms    map[string]string
+	maxWords int
+}
+
+// NewMatcher returns matcher of terms of t. Matched words are
+// canonicalized with s, so that a term matches its variants too, e.g.
+// "kubernetes" matches "k8s". A term which is a variant itself matches only
+// as written, e.g. "golang" doesn't match "go", which is a common word of
+// other languages.
+func NewMatcher(t Taxonomy, s Synonyms) *Matcher {
+	m := &Matcher{
+		synonyms: s,
+		terms:    make(map[string]string, len(t)),
+		maxWords: 1,
+	}
+	for term, category := range t {
+		m.terms[term] = category
+		m.maxWords = max(m.maxWords, strings.Count(term, " ")+1)
+	}
+
+	return m
+}
+
+// Match returns terms found in words, as returned by Words, in order.
+// Terms are reported in canonical form. The longest term wins, e.g.
+// "google cloud platform" is one term rather than "google cloud" followed
+// by a word.
+func (m *Matcher) Match(words []string) []TermMatch {
+	canonical := m.synonyms.CanonicalWords(words)
+
+	var matches []TermMatch
+	for i := 0; i < len(canonical); {
+		n, category := m.longest(words[i:], canonical[i:])
+		if n == 0 {
+			i++
+
+			continue
+		}
+		matches = append(matches, TermMatch{
+			Term:     strings.Join(canonical[i:i+n], " "),
+			Category: category,
+			Start:    i,
+			End:      i + n,
+		})
+		i += n
+	}
+
+	return matches
+}
+
+// longest returns number of words of the longest term words start with,
+// as written or canonicalized, and its category. It's zero if there's none.
+func (m *Matcher) longest(words, canonical []string) (int, string) {
+	for n := min(m.maxWords, len(words)); n > 0; n-- {
+		if category, ok := m.terms[strings.Join(words[:n], " ")]; ok {
+			return n, category
+		}
+		if category, ok := m.terms[strings.Join(canonical[:n], " ")]; ok {
+			return n, category
+		}
+	}
+
+	return 0, ""
+}
+
+// Categories returns sorted categories of terms found in text.
+func (m *Matcher) Categories(text string) []string {
+	var categories []string
+	for _, match := range m.Match(Words(text)) {
+		if !slices.Contains(categories, match.Category) {
+			categories = append(categories, match.Category)
+		}
+	}
+	slices.Sort(categories)
+
+	return categories
+}
@@ -0,0 +1,162 @@
+package textproc_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	t.Parallel()
+
+	m := textproc.NewMatcher(textproc.DefaultTaxonomy(), textproc.DefaultSynonyms())
+
+	testCases := []struct {
+		desc string
+
+		text string
+		want []textproc.TermMatch
+	}{
+		{
+			desc: "variants_of_terms",
+
+			text: "Golang, K8s and Postgres",
+			want: []textproc.TermMatch{
+				{Term: "go", Category: "languages", Start: 0, End: 1},
+				{Term: "kubernetes", Category: "containers", Start: 1, End: 2},
+				{Term: "postgresql", Category: "databases", Start: 3, End: 4},
+			},
+		},
+		{
+			desc: "terms_of_many_words",
+
+			text: "Experience with GitHub Actions and infrastructure as code",
+			want: []textproc.TermMatch{
+				{Term: "github actions", Category: "ci/cd", Start: 2, End: 4},
+				{Term: "infrastructure as code", Category: "infrastructure-as-code", Start: 5, End: 8},
+			},
+		},
+		{
+			desc: "longest_term_wins",
+
+			text: "google cloud platform or google cloud",
+			want: []textproc.TermMatch{
+				{Term: "google cloud platform", Category: "cloud", Start: 0, End: 3},
+				{Term: "google cloud", Category: "cloud", Start: 4, End: 6},
+			},
+		},
+		{
+			desc: "common_words",
+
+			text: "The rest of the team will go with Spring",
+			want: nil,
+		},
+		{
+			desc: "common_polish_words",
+
+			text: "Nie znam go, ale praca jest zdalna",
+			want: nil,
+		},
+		{
+			desc: "common_words_with_disambiguating_words",
+
+			text: "REST API and RESTful services in Spring Boot",
+			want: []textproc.TermMatch{
+				{Term: "rest api", Category: "apis", Start: 0, End: 2},
+				{Term: "rest", Category: "apis", Start: 3, End: 4},
+				{Term: "spring boot", Category: "backend", Start: 6, End: 8},
+			},
+		},
+		{
+			desc: "no_terms",
+
+			text: "friendly team and multisport card",
+			want: nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, m.Match(textproc.Words(tC.text)))
+		})
+	}
+
+	require.Equal(t,
+		[]string{"cloud", "messaging", "observability"},
+		m.Categories("Kafka on AWS, monitored with Prometheus and Grafana"),
+	)
+	require.Empty(t, m.Categories("the rest of the team"))
+}
+
+func TestParseTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+
+		data    string
+		want    textproc.Taxonomy
+		wantErr bool
+	}{
+		{
+			desc: "terms_are_tokenized",
+
+			data: "Messaging: [Kafka, \"Google Pub/Sub\"]\n",
+			want: textproc.Taxonomy{"kafka": "messaging", "google pub/sub": "messaging"},
+		},
+		{
+			desc: "term_of_two_categories",
+
+			data:    "messaging: [kafka]\nstreaming: [kafka]\n",
+			wantErr: true,
+		},
+		{
+			desc: "term_without_words",
+
+			data:    "messaging: [\"--\"]\n",
+			wantErr: true,
+		},
+		{
+			desc: "category_with_comma",
+
+			data:    "\"a,b\": [kafka]\n",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := textproc.ParseTaxonomy([]byte(tC.data))
+			if tC.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestLoadTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("streaming: [kafka, flink]\n"), 0o600))
+
+	taxonomy, err := textproc.LoadTaxonomy(path)
+	require.NoError(t, err)
+	require.Equal(t, "streaming", taxonomy["kafka"])
+	require.Equal(t, "streaming", taxonomy["flink"])
+	require.Equal(t, "messaging", taxonomy["rabbitmq"])
+	require.Contains(t, taxonomy.Categories(), "streaming")
+
+	taxonomy, err = textproc.LoadTaxonomy("")
+	require.NoError(t, err)
+	require.Equal(t, textproc.DefaultTaxonomy(), taxonomy)
+}
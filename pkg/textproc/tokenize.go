@@ -0,0 +1,170 @@
+package textproc
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// TokenKind is the kind of a token.
+type TokenKind string
+
+const (
+	TokenWord   TokenKind = "word"
+	TokenNumber TokenKind = "number"
+	TokenURL    TokenKind = "url"
+	TokenEmail  TokenKind = "email"
+)
+
+// Token is a lower cased, NFC normalized word, number, URL or email address.
+type Token struct {
+	Text string
+	Kind TokenKind
+}
+
+var (
+	urlPattern    = regexp.MustCompile(`^(?:https?://|www\.)\S+$`)
+	emailPattern  = regexp.MustCompile(`^[\pL\pN._%+-]+@[\pL\pN.-]+\.\pL{2,}$`)
+	numberPattern = regexp.MustCompile(`^[+-]?\pN+(?:[.,]\pN+)*(?:%|\+|k)?$`)
+)
+
+// techTokens keep punctuation which would split or trim them otherwise.
+var techTokens = map[string]bool{
+	".net": true, "c++": true, "c#": true, "f#": true, "j#": true,
+	"ci/cd": true, "tcp/ip": true, "ui/ux": true, "i/o": true, "pl/sql": true,
+	"t-sql": true, "a/b": true, "r&d": true, "b2b/b2c": true,
+}
+
+// joiners are punctuation runes kept inside tokens, e.g. in "node.js",
+// "full-stack" or "ci/cd". Other punctuation separates tokens.
+const joiners = ".-/'_+#&@%"
+
+// Tokenize splits text into tokens: words, with punctuation around them
+// trimmed, numbers, URLs and email addresses. Technical terms like "c++",
+// "c#", "node.js", "ci/cd" or "tcp/ip" are kept whole. Text is NFC
+// normalized and lower cased first, so the same word is always the same
+// token.
+func Tokenize(text string) []Token {
+	text = strings.ToLower(norm.NFC.String(text))
+	text = strings.NewReplacer("’", "'", "‘", "'").Replace(text)
+
+	var tokens []Token
+	for _, field := range strings.Fields(text) {
+		trimmed := strings.TrimRightFunc(strings.TrimLeftFunc(field, isOpening), isClosing)
+		switch {
+		case urlPattern.MatchString(trimmed):
+			tokens = append(tokens, Token{Text: trimmed, Kind: TokenURL})
+
+			continue
+		case emailPattern.MatchString(trimmed):
+			tokens = append(tokens, Token{Text: trimmed, Kind: TokenEmail})
+
+			continue
+		case numberPattern.MatchString(trimmed):
+			// Commas would split "20,000"
+			tokens = append(tokens, Token{Text: trimmed, Kind: TokenNumber})
+
+			continue
+		}
+
+		for _, part := range strings.FieldsFunc(field, isSeparator) {
+			for _, word := range splitSlashes(trimToken(part)) {
+				if token, ok := classify(word); ok {
+					tokens = append(tokens, token)
+				}
+			}
+		}
+	}
+
+	return tokens
+}
+
+// Words returns tokens of text which are words, see Tokenize.
+func Words(text string) []string {
+	var words []string
+	for _, token := range Tokenize(text) {
+		if token.Kind == TokenWord {
+			words = append(words, token.Text)
+		}
+	}
+
+	return words
+}
+
+func isSeparator(r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r) {
+		return false
+	}
+
+	return !strings.ContainsRune(joiners, r)
+}
+
+func isOpening(r rune) bool {
+	return strings.ContainsRune(`([{<"'«„`, r)
+}
+
+func isClosing(r rune) bool {
+	return strings.ContainsRune(`)]}>"'».,;:!?…`, r)
+}
+
+// trimToken trims joiners around a token, except ones of known terms and
+// "+" or "#" ending words like "c++".
+func trimToken(s string) string {
+	if techTokens[s] {
+		return s
+	}
+	s = strings.TrimLeftFunc(s, isJoiner)
+	if techTokens[s] {
+		return s
+	}
+	end := strings.TrimRightFunc(s, isJoiner)
+	rest := s[len(end):]
+
+	// "c++", "3+" and "100%"
+	return end + rest[:len(rest)-len(strings.TrimLeft(rest, "+#%"))]
+}
+
+func isJoiner(r rune) bool {
+	return strings.ContainsRune(joiners, r)
+}
+
+// splitSlashes splits s on slashes, e.g. "golang/python", unless it's a
+// known term or all its parts are short abbreviations like in "ci/cd".
+func splitSlashes(s string) []string {
+	if !strings.Contains(s, "/") || techTokens[s] {
+		return []string{s}
+	}
+
+	parts := strings.Split(s, "/")
+	short := true
+	for _, p := range parts {
+		if p == "" || len([]rune(p)) > 3 || strings.IndexFunc(p, func(r rune) bool { return !unicode.IsLetter(r) }) >= 0 {
+			short = false
+		}
+	}
+	if short {
+		return []string{s}
+	}
+
+	words := make([]string, 0, len(parts))
+	for _, p := range parts {
+		words = append(words, trimToken(p))
+	}
+
+	return words
+}
+
+// classify returns token of s, it reports false if s has no letters nor
+// digits.
+func classify(s string) (Token, bool) {
+	if strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) < 0 {
+		return Token{}, false
+	}
+	if numberPattern.MatchString(s) {
+		return Token{Text: s, Kind: TokenNumber}, true
+	}
+
+	return Token{Text: s, Kind: TokenWord}, true
+}
@@ -0,0 +1,79 @@
+package textproc_test
+
+import (
+	"testing"
+
+	"github.com/kndrad/piccrack/pkg/textproc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+
+	word := func(s string) textproc.Token { return textproc.Token{Text: s, Kind: textproc.TokenWord} }
+
+	testCases := []struct {
+		desc string
+
+		text string
+		want []textproc.Token
+	}{
+		{
+			desc: "punctuation_is_trimmed",
+
+			text: `Kubernetes, kubernetes. (Kubernetes) "Docker" will-`,
+			want: []textproc.Token{word("kubernetes"), word("kubernetes"), word("kubernetes"), word("docker"), word("will")},
+		},
+		{
+			desc: "tech_tokens_are_kept",
+
+			text: "C/C++, C#, Node.js; CI/CD (TCP/IP) .NET asp.net full-stack Golang/Python",
+			want: []textproc.Token{
+				word("c"), word("c++"), word("c#"), word("node.js"), word("ci/cd"), word("tcp/ip"),
+				word(".net"), word("asp.net"), word("full-stack"), word("golang"), word("python"),
+			},
+		},
+		{
+			desc: "numbers_urls_and_emails_are_classified",
+
+			text: "3+ years, 100% remote, 20,000 PLN, 10k. Apply at https://example.com/jobs?id=1. or jobs@example.com!",
+			want: []textproc.Token{
+				{Text: "3+", Kind: textproc.TokenNumber}, word("years"),
+				{Text: "100%", Kind: textproc.TokenNumber}, word("remote"),
+				{Text: "20,000", Kind: textproc.TokenNumber}, word("pln"),
+				{Text: "10k", Kind: textproc.TokenNumber}, word("apply"), word("at"),
+				{Text: "https://example.com/jobs?id=1", Kind: textproc.TokenURL}, word("or"),
+				{Text: "jobs@example.com", Kind: textproc.TokenEmail},
+			},
+		},
+		{
+			desc: "unicode_is_normalized",
+
+			// "Doświadczenie" with a combining acute accent
+			text: "Doświadczenie • Zespół – Bachelor’s",
+			want: []textproc.Token{word("doświadczenie"), word("zespół"), word("bachelor's")},
+		},
+		{
+			desc: "symbols_only",
+
+			text: "• - | ... ++",
+			want: nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tC.want, textproc.Tokenize(tC.text))
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t,
+		[]string{"golang", "developer", "c++", "years"},
+		textproc.Words("Golang developer (C++), 5 years https://example.com"),
+	)
+}